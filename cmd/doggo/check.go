@@ -0,0 +1,50 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/axbarsan/doggo/internal/runner"
+)
+
+func checkCommand(c *cli, args []string) int {
+	flags := c.newFlagSet("check", "[-json] [files...]")
+	asJSON := flags.Bool("json", false, "report the errors as JSON on the standard output, for the tools reading them")
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	fileNames := flags.Args()
+	if len(fileNames) == 0 {
+		fileNames = []string{"-"}
+	}
+
+	// The JSON is the result of the command, so it goes to the standard output.
+	out := c.stderr
+	if *asJSON {
+		out = c.stdout
+	}
+	rep := newReporter(out, *asJSON)
+	defer rep.flush()
+
+	exitCode := exitOK
+	for _, fileName := range fileNames {
+		code, err := c.readSource(fileName)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			exitCode = exitUsage
+
+			continue
+		}
+
+		_, errors := runner.Parse(displayName(fileName), code)
+		for _, d := range errors {
+			rep.report(d, displayName(fileName), code)
+		}
+
+		if len(errors) != 0 && exitCode == exitOK {
+			exitCode = exitFailure
+		}
+	}
+
+	return exitCode
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/axbarsan/doggo/internal/format"
+)
+
+// fmtCommand formats the given files, or the standard input, printing them in the canonical style.
+// With -w, the files are rewritten instead, and with -l, only the names of the ones not formatted yet are printed.
+func fmtCommand(c *cli, args []string) int {
+	flags := c.newFlagSet("fmt", "[-w] [-l] [files...]")
+	write := flags.Bool("w", false, "write the result back to the files, instead of printing it")
+	list := flags.Bool("l", false, "list the files whose formatting differs, instead of printing them")
+
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	fileNames := flags.Args()
+	if len(fileNames) == 0 {
+		if *write {
+			fmt.Fprintln(c.stderr, "cannot use -w with the standard input")
+
+			return exitUsage
+		}
+
+		fileNames = []string{"-"}
+	}
+
+	exitCode := exitOK
+	for _, fileName := range fileNames {
+		if code := c.formatFile(fileName, *write, *list); code > exitCode {
+			exitCode = code
+		}
+	}
+
+	return exitCode
+}
+
+func (c *cli) formatFile(fileName string, write, list bool) int {
+	code, err := c.readSource(fileName)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitUsage
+	}
+
+	formatted, errors := format.Source(displayName(fileName), code)
+	if len(errors) != 0 {
+		for _, msg := range errors {
+			fmt.Fprintln(c.stderr, msg)
+		}
+
+		return exitFailure
+	}
+
+	if list && formatted != code {
+		fmt.Fprintln(c.stdout, displayName(fileName))
+	}
+
+	if write {
+		if formatted == code {
+			return exitOK
+		}
+
+		if err := writeFile(fileName, formatted); err != nil {
+			fmt.Fprintln(c.stderr, err)
+
+			return exitUsage
+		}
+
+		return exitOK
+	}
+
+	if !list {
+		io.WriteString(c.stdout, formatted)
+	}
+
+	return exitOK
+}
+
+// writeFile replaces the contents of the file, keeping its permissions.
+func writeFile(fileName, contents string) error {
+	info, err := os.Stat(fileName)
+	if err != nil {
+		return fmt.Errorf("cannot write file: %s", err)
+	}
+
+	if err := ioutil.WriteFile(fileName, []byte(contents), info.Mode().Perm()); err != nil {
+		return fmt.Errorf("cannot write file: %s", err)
+	}
+
+	return nil
+}
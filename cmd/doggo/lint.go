@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/lint"
+	"github.com/axbarsan/doggo/internal/runner"
+)
+
+// lintCommand reports the problems of the given files, or of the standard input, that are found without running them.
+// The rules are picked by the config file, if there's one.
+func lintCommand(c *cli, args []string) int {
+	flags := c.newFlagSet("lint", "[-config file] [-json] [files...]")
+	configFile := flags.String("config", "", fmt.Sprintf("the config file (default %s, if there's one)", lint.CONFIG_FILE))
+	asJSON := flags.Bool("json", false, "report the problems as JSON on the standard output, for the tools reading them")
+	listRules := flags.Bool("rules", false, "list the rules, instead of linting")
+
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	if *listRules {
+		for _, rule := range lint.Rules {
+			fmt.Fprintf(c.stdout, "%-20s %s\n", rule.ID, rule.Description)
+		}
+
+		return exitOK
+	}
+
+	config, err := loadLintConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitUsage
+	}
+
+	// The scripts run by the command line get their arguments.
+	config.Globals = append(config.Globals, "args")
+
+	fileNames := flags.Args()
+	if len(fileNames) == 0 {
+		fileNames = []string{"-"}
+	}
+
+	// The JSON is the result of the command, so it goes to the standard output.
+	out := c.stderr
+	if *asJSON {
+		out = c.stdout
+	}
+	rep := newReporter(out, *asJSON)
+	defer rep.flush()
+
+	exitCode := exitOK
+	for _, fileName := range fileNames {
+		code, err := c.readSource(fileName)
+		if err != nil {
+			fmt.Fprintln(c.stderr, err)
+			exitCode = exitUsage
+
+			continue
+		}
+
+		program, errors := runner.Parse(displayName(fileName), code)
+		for _, d := range errors {
+			rep.report(d, displayName(fileName), code)
+		}
+
+		var diagnostics []*diag.Diagnostic
+		if len(errors) == 0 {
+			diagnostics = lint.Lint(fileName, program, config)
+		}
+
+		for _, d := range diagnostics {
+			if *asJSON {
+				rep.report(d, displayName(fileName), code)
+			} else {
+				fmt.Fprintf(c.stdout, "%s (%s)\n", d, d.Code)
+			}
+		}
+
+		if len(errors)+len(diagnostics) != 0 && exitCode == exitOK {
+			exitCode = exitFailure
+		}
+	}
+
+	return exitCode
+}
+
+// loadLintConfig reads the config from the file, or from the default one if it's there.
+func loadLintConfig(fileName string) (*lint.Config, error) {
+	if fileName != "" {
+		return lint.LoadConfig(fileName)
+	}
+
+	if _, err := os.Stat(lint.CONFIG_FILE); err != nil {
+		return &lint.Config{}, nil
+	}
+
+	return lint.LoadConfig(lint.CONFIG_FILE)
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/axbarsan/doggo/internal/lint"
+	"github.com/axbarsan/doggo/internal/lsp"
+)
+
+// lspCommand runs the language server, talking to the editor over the standard streams.
+// The lint rules behind its diagnostics are picked like the ones of 'doggo lint'.
+func lspCommand(c *cli, args []string) int {
+	flags := c.newFlagSet("lsp", "[-config file]")
+	configFile := flags.String("config", "", fmt.Sprintf("the lint config file (default %s, if there's one)", lint.CONFIG_FILE))
+
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	config, err := loadLintConfig(*configFile)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitUsage
+	}
+	config.Globals = append(config.Globals, "args")
+
+	if err := lsp.NewServer(c.stdin, c.stdout, config).Serve(); err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitFailure
+	}
+
+	return exitOK
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/runner"
+)
+
+// The exit codes of the commands.
+const (
+	exitOK = 0
+	// exitFailure means the code failed: it has syntax errors, raised an error, or failed its tests.
+	exitFailure = 1
+	// exitUsage means the command was used wrong, or its files couldn't be read.
+	exitUsage = 2
+)
+
+// cli holds the streams the commands read from and write to.
+type cli struct {
+	stdin  io.Reader
+	stdout io.Writer
+	stderr io.Writer
+}
+
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(c *cli, args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"run", "[flags] [-e code | file | -] [args...]", "run a file, some code, or the standard input", runCommand},
+		{"repl", "[flags] [args...]", "start an interactive session", replCommand},
+		{"check", "[-json] [files...]", "report the syntax errors of the files, without running them", checkCommand},
+		{"fmt", "[-w] [-l] [files...]", "format the files in the canonical style", fmtCommand},
+		{"lint", "[-config file] [-json] [files...]", "report the likely mistakes in the files, without running them", lintCommand},
+		{"lsp", "[-config file]", "run the language server, for the editors", lspCommand},
+		{"test", "[flags] [files or directories...]", "run the tests in the _test.doggo files", testCommand},
+		{"help", "", "show this help", helpCommand},
+	}
+}
+
+func main() {
+	c := &cli{stdin: os.Stdin, stdout: os.Stdout, stderr: os.Stderr}
+	os.Exit(c.main(os.Args[1:]))
+}
+
+// main runs the command given by the arguments, returning the exit code.
+// Without a command, the arguments are run, or the REPL is started if there are none.
+func (c *cli) main(args []string) int {
+	if len(args) == 0 {
+		return replCommand(c, nil)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return cmd.run(c, args[1:])
+		}
+	}
+
+	if args[0] == "-h" || args[0] == "--help" {
+		return helpCommand(c, nil)
+	}
+
+	return runCommand(c, args)
+}
+
+func helpCommand(c *cli, args []string) int {
+	fmt.Fprintln(c.stdout, "doggo runs doggo code.")
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, "Usage:")
+	for _, cmd := range commands {
+		fmt.Fprintf(c.stdout, "  doggo %-6s %-40s %s\n", cmd.name, cmd.usage, cmd.help)
+	}
+	fmt.Fprintln(c.stdout)
+	fmt.Fprintln(c.stdout, "Without a command, doggo runs its arguments, or starts the REPL if there are none.")
+	fmt.Fprintln(c.stdout, "Run 'doggo <command> -h' to see the flags of a command.")
+
+	return exitOK
+}
+
+// newFlagSet creates the flags of a command, which report their errors to the error stream.
+func (c *cli) newFlagSet(name, usage string) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(c.stderr)
+	flags.Usage = func() {
+		fmt.Fprintf(c.stderr, "Usage: doggo %s %s\n", name, usage)
+		flags.PrintDefaults()
+	}
+
+	return flags
+}
+
+// runtimeFlags configure how the code runs, for the commands running it.
+type runtimeFlags struct {
+	engine         string
+	strict         bool
+	timeout        time.Duration
+	maxSteps       int64
+	maxDepth       int
+	maxAllocations int64
+}
+
+// register adds the flags to the set. The timeout is left out for the commands running code for an unknown while.
+func (f *runtimeFlags) register(flags *flag.FlagSet, withTimeout bool) {
+	flags.StringVar(&f.engine, "engine", string(runner.EngineEval), "the backend that runs the code: 'eval' or 'vm'")
+	flags.BoolVar(&f.strict, "strict", false, "report integer overflows as runtime errors")
+	if withTimeout {
+		flags.DurationVar(&f.timeout, "timeout", 0, "stop the code after running for this long, like 5s (0 means no timeout)")
+	}
+	flags.Int64Var(&f.maxSteps, "max-steps", 0, "stop the code after this many steps (0 means no limit)")
+	flags.IntVar(&f.maxDepth, "max-depth", 0, fmt.Sprintf("the deepest function calls can nest (0 means %d)", object.DefaultMaxCallDepth))
+	flags.Int64Var(&f.maxAllocations, "max-allocations", 0, "stop the code after creating this many objects (0 means no limit)")
+}
+
+// newRunner creates a runner configured by the flags, whose code uses the streams of the command line.
+func (f *runtimeFlags) newRunner(c *cli) (*runner.Runner, error) {
+	if f.engine != string(runner.EngineEval) && f.engine != string(runner.EngineVM) {
+		return nil, fmt.Errorf("unknown engine: %s", f.engine)
+	}
+
+	r := runner.NewWithEngine(runner.Engine(f.engine))
+	r.SetStrict(f.strict)
+	r.SetLimits(object.Limits{MaxSteps: f.maxSteps, MaxCallDepth: f.maxDepth, MaxAllocations: f.maxAllocations})
+	r.SetIO(c.stdin, c.stdout, c.stderr)
+
+	return r, nil
+}
+
+// context returns the context the code runs in, which is done once the timeout, if any, passes.
+func (f *runtimeFlags) context() (context.Context, context.CancelFunc) {
+	if f.timeout > 0 {
+		return context.WithTimeout(context.Background(), f.timeout)
+	}
+
+	return context.WithCancel(context.Background())
+}
+
+// setArgs exposes the arguments of the script to its code, as the 'args' array of strings.
+func setArgs(r *runner.Runner, args []string) {
+	elements := make([]object.Object, len(args))
+	for i, arg := range args {
+		elements[i] = &object.String{Value: arg}
+	}
+
+	r.SetGlobal("args", &object.Array{Elements: elements})
+}
+
+// execute runs the code, reporting its errors, if any, to the reporter.
+// It returns the result of the code, and whether it ran without errors.
+func (c *cli) execute(ctx context.Context, r *runner.Runner, rep *reporter, fileName, code string) (object.Object, bool) {
+	program, errors := runner.Parse(fileName, code)
+	if len(errors) != 0 {
+		for _, d := range errors {
+			rep.report(d, fileName, code)
+		}
+
+		return nil, false
+	}
+
+	result, err := r.Exec(ctx, program)
+	if err != nil {
+		// The compiler points at the code it can't compile.
+		rep.report(err.(*diag.Diagnostic), fileName, code)
+
+		return nil, false
+	}
+
+	if err, ok := result.(*object.Error); ok {
+		rep.report(err.Diagnostic(), fileName, code)
+
+		return nil, false
+	}
+
+	return result, true
+}
+
+// readSource reads the file, or the standard input for '-'.
+func (c *cli) readSource(fileName string) (string, error) {
+	var code []byte
+	var err error
+	if fileName == "-" {
+		code, err = ioutil.ReadAll(c.stdin)
+	} else {
+		code, err = ioutil.ReadFile(fileName)
+	}
+
+	if err != nil {
+		return "", fmt.Errorf("cannot read file: %s", err)
+	}
+
+	return string(code), nil
+}
+
+// displayName is how the file is called in messages.
+func displayName(fileName string) string {
+	if fileName == "-" {
+		return "<stdin>"
+	}
+
+	return fileName
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doggo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"greet.doggo":        `print("hello ${args[0]}, ${length(args)} args");`,
+		"broken.doggo":       `let x = ;`,
+		"ugly.doggo":         `let x=[1,2]`,
+		"sloppy.doggo":       `const unused = 1; print(args, request);`,
+		"lint.json":          `{"rules": {"unused-const": false}, "globals": ["request"]}`,
+		"failing.doggo":      `print("before"); 1 + true;`,
+		"tests/a_test.doggo": `const testSum = fn() { assert(1 + 1 == 2) }; const testNothing = 1;`,
+		"tests/b_test.doggo": `const testBad = fn() { assert(false, "nope") }; const testGood = fn() {};`,
+		"tests/helper.doggo": `const testIgnored = fn() { assert(false) };`,
+	}
+
+	for name, code := range files {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(fileName, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	testCases := []struct {
+		args     []string
+		stdin    string
+		exitCode int
+		stdout   string
+		stderr   string
+	}{
+		{args: []string{"-e", "1 + 2"}, stdout: "3\n"},
+		{args: []string{"run", "-e", `print(args)`, "a", "b"}, stdout: "[a, b]\n"},
+		{args: []string{"run", "-e", `let x = 1;`}},
+		{args: []string{"run", "$DIR/greet.doggo", "doggo", "-v"}, stdout: "hello doggo, 2 args\n"},
+		{args: []string{"--engine=vm", "$DIR/greet.doggo", "vm"}, stdout: "hello vm, 1 args\n"},
+		{args: []string{"run"}, stdin: `print(readLine() == {}["x"])`, stdout: "true\n"},
+		{args: []string{"run", "-"}, stdin: `1 + true`, exitCode: 1, stderr: "<stdin>:1:1: type mismatch: INTEGER + BOOLEAN\n  |\n1 | 1 + true\n  | ^\n"},
+		{args: []string{"$DIR/failing.doggo"}, exitCode: 1, stdout: "before\n", stderr: "$DIR/failing.doggo:1:18: type mismatch: INTEGER + BOOLEAN\n"},
+		{args: []string{"run", "-json", "-"}, stdin: `1 + true`, exitCode: 1, stderr: `"code": "type-mismatch",`},
+		{args: []string{"run", "--engine=vm", "-"}, stdin: strings.Repeat("1;\n", 65537), exitCode: 1, stderr: "<stdin>:65537:1: too many constants, the limit is 65535\n      |\n65537 | 1;\n      | ^\n"},
+		{args: []string{"run", "--engine=vm", "-json", "-"}, stdin: strings.Repeat("1;\n", 65537), exitCode: 1, stderr: `"line": 65537,`},
+		{args: []string{"run", "$DIR/broken.doggo"}, exitCode: 1, stderr: "$DIR/broken.doggo:1:9: expected an expression, got ';' instead\n  |\n1 | let x = ;\n  |         ^\n"},
+		{args: []string{"run", "$DIR/missing.doggo"}, exitCode: 2, stderr: "cannot read file: "},
+		{args: []string{"run", "--engine=jit", "-e", "1"}, exitCode: 2, stderr: "unknown engine: jit\n"},
+		{args: []string{"run", "--nope"}, exitCode: 2, stderr: "flag provided but not defined: -nope\n"},
+		{args: []string{"run", "--timeout=10ms", "-e", "while (true) {}"}, exitCode: 1, stderr: "execution stopped: context deadline exceeded\n"},
+		{args: []string{"check", "$DIR/greet.doggo"}},
+		{args: []string{"check", "$DIR/greet.doggo", "$DIR/broken.doggo"}, exitCode: 1, stderr: "$DIR/broken.doggo:1:9: expected an expression, got ';' instead\n"},
+		{args: []string{"check"}, stdin: "let = 1;", exitCode: 1, stderr: "<stdin>:1:5: expected a name, got '=' instead\n"},
+		{args: []string{"check", "-json", "$DIR/greet.doggo"}, stdout: "[]\n"},
+		{args: []string{"check", "-json"}, stdin: "let = 1;", exitCode: 1, stdout: `"code": "unexpected-token",`},
+		{args: []string{"fmt", "$DIR/ugly.doggo"}, stdout: "let x = [1, 2];\n"},
+		{args: []string{"fmt"}, stdin: "print( 1 )", stdout: "print(1);\n"},
+		{args: []string{"fmt", "-l", "$DIR/ugly.doggo", "$DIR/greet.doggo"}, stdout: "$DIR/ugly.doggo\n"},
+		{args: []string{"fmt", "$DIR/broken.doggo"}, exitCode: 1, stderr: "$DIR/broken.doggo:1:9: expected an expression, got ';' instead\n"},
+		{args: []string{"fmt", "-w"}, exitCode: 2, stderr: "cannot use -w with the standard input\n"},
+		{args: []string{"fmt", "-w", "$DIR/ugly.doggo"}},
+		{args: []string{"lint", "$DIR/greet.doggo"}},
+		{args: []string{"lint", "$DIR/sloppy.doggo"}, exitCode: 1, stdout: "$DIR/sloppy.doggo:1:7: constant unused is never used (unused-const)\n$DIR/sloppy.doggo:1:31: identifier not found: request (unknown-identifier)\n"},
+		{args: []string{"lint", "-config", "$DIR/lint.json", "$DIR/sloppy.doggo"}},
+		{args: []string{"lint", "-json", "$DIR/sloppy.doggo"}, exitCode: 1, stdout: `"code": "unknown-identifier",`},
+		{args: []string{"lint", "-config", "$DIR/missing.json"}, exitCode: 2, stderr: "cannot read config: "},
+		{args: []string{"lint"}, stdin: "let = 1;", exitCode: 1, stderr: "<stdin>:1:5: expected a name, got '=' instead\n"},
+		{args: []string{"lint", "-rules"}, stdout: "unused-const         constants and imports that are never used\n"},
+		{args: []string{"test", "-v", "$DIR/tests/a_test.doggo"}, stdout: "--- PASS: testSum (0.00s)\nok\t$DIR/tests/a_test.doggo\t1 passed\n"},
+		{args: []string{"test", "$DIR/tests"}, exitCode: 1, stdout: "ERROR: $DIR/tests/b_test.doggo:1:24: assertion failed: nope\nFAIL\t$DIR/tests/b_test.doggo\t1 passed, 1 failed\n"},
+		// The traceback of a failed test starts at its declaration.
+		{args: []string{"test", "$DIR/tests/b_test.doggo"}, exitCode: 1, stdout: "Traceback (most recent call last):\n    \t$DIR/tests/b_test.doggo:1:7, in <main>\n"},
+		{args: []string{"test", "$DIR/failing.doggo"}, exitCode: 1, stdout: "before\nFAIL\t$DIR/failing.doggo\n", stderr: "type mismatch: INTEGER + BOOLEAN\n"},
+		{args: []string{"test", "$DIR/missing"}, exitCode: 2, stderr: "cannot find tests: "},
+		{
+			args:   []string{"lsp"},
+			stdin:  lspMessages(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{}}`, `{"jsonrpc":"2.0","id":2,"method":"shutdown"}`, `{"jsonrpc":"2.0","method":"exit"}`),
+			stdout: `{"jsonrpc":"2.0","id":2,"result":null}`,
+		},
+		{args: []string{"lsp"}, exitCode: 1, stderr: "the editor left without shutting the server down\n"},
+		{args: []string{"lsp", "-config", "$DIR/missing.json"}, exitCode: 2, stderr: "cannot read config: "},
+		{args: []string{"help"}, stdout: "Usage:\n"},
+		{args: []string{"repl"}, stdin: "1 + 1", stdout: "This is the doggo programming language!\n"},
+		{args: []string{"repl", "a", "b"}, stdin: "args\n:reset\nargs\n", stdout: "all the bindings are gone\n>> [a, b]\n"},
+	}
+
+	for _, tc := range testCases {
+		args := make([]string, len(tc.args))
+		for i, arg := range tc.args {
+			args[i] = strings.ReplaceAll(arg, "$DIR", dir)
+		}
+
+		stdout := new(strings.Builder)
+		stderr := new(strings.Builder)
+		c := &cli{stdin: strings.NewReader(tc.stdin), stdout: stdout, stderr: stderr}
+
+		exitCode := c.main(args)
+		if exitCode != tc.exitCode {
+			t.Errorf("wrong exit code for %q. expected=%d, got=%d (stderr=%q)", tc.args, tc.exitCode, exitCode, stderr.String())
+		}
+
+		if expected := strings.ReplaceAll(tc.stdout, "$DIR", dir); !strings.Contains(stdout.String(), expected) {
+			t.Errorf("wrong output for %q.\nexpected to contain=%q\ngot=%q", tc.args, expected, stdout.String())
+		}
+
+		if expected := strings.ReplaceAll(tc.stderr, "$DIR", dir); !strings.Contains(stderr.String(), expected) {
+			t.Errorf("wrong error output for %q.\nexpected to contain=%q\ngot=%q", tc.args, expected, stderr.String())
+		}
+	}
+
+	if formatted, _ := ioutil.ReadFile(filepath.Join(dir, "ugly.doggo")); string(formatted) != "let x = [1, 2];\n" {
+		t.Errorf("wrong file written by fmt -w. got=%q", formatted)
+	}
+}
+
+// lspMessages frames the messages sent to the language server.
+func lspMessages(messages ...string) string {
+	var out strings.Builder
+	for _, msg := range messages {
+		fmt.Fprintf(&out, "Content-Length: %d\r\n\r\n%s", len(msg), msg)
+	}
+
+	return out.String()
+}
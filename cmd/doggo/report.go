@@ -0,0 +1,45 @@
+package main
+
+import (
+	"io"
+	"io/ioutil"
+
+	"github.com/axbarsan/doggo/internal/diag"
+)
+
+// reporter writes the problems found by a command: as text, along with the lines of code they're about,
+// or, with the -json flag, as a JSON array written once the command is done, for the tools reading them.
+type reporter struct {
+	w    io.Writer
+	json bool
+
+	diagnostics []*diag.Diagnostic
+}
+
+func newReporter(w io.Writer, json bool) *reporter {
+	return &reporter{w: w, json: json}
+}
+
+// report writes the problem found in the code of the file. The code of the other files
+// the problems can be about, like the modules imported by the file, is read from the disk.
+func (r *reporter) report(d *diag.Diagnostic, fileName, code string) {
+	if r.json {
+		r.diagnostics = append(r.diagnostics, d)
+
+		return
+	}
+
+	if name := d.Span.Start.Filename; name != fileName {
+		source, _ := ioutil.ReadFile(name)
+		code = string(source)
+	}
+
+	diag.Render(r.w, d, code)
+}
+
+// flush writes the problems reported so far as JSON, if the -json flag was given.
+func (r *reporter) flush() {
+	if r.json {
+		diag.WriteJSON(r.w, r.diagnostics)
+	}
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os/user"
+
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/repl"
+)
+
+func runCommand(c *cli, args []string) int {
+	flags := c.newFlagSet("run", "[flags] [-e code | file | -] [args...]")
+	var rf runtimeFlags
+	rf.register(flags, true)
+	inline := flags.String("e", "", "run the `code` instead of a file, and print its result")
+	asJSON := flags.Bool("json", false, "report the errors as JSON, for the tools reading them")
+
+	// The flags after the file, if any, are arguments of the script.
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	r, err := rf.newRunner(c)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitUsage
+	}
+
+	ctx, cancel := rf.context()
+	defer cancel()
+
+	isInline := false
+	flags.Visit(func(f *flag.Flag) {
+		isInline = isInline || f.Name == "e"
+	})
+
+	fileName, code, scriptArgs := "", *inline, flags.Args()
+	if !isInline {
+		fileName = "-"
+		if len(scriptArgs) > 0 {
+			fileName, scriptArgs = scriptArgs[0], scriptArgs[1:]
+		}
+
+		if code, err = c.readSource(fileName); err != nil {
+			fmt.Fprintln(c.stderr, err)
+
+			return exitUsage
+		}
+		fileName = displayName(fileName)
+	}
+
+	setArgs(r, scriptArgs)
+
+	rep := newReporter(c.stderr, *asJSON)
+	result, ok := c.execute(ctx, r, rep, fileName, code)
+	rep.flush()
+	if !ok {
+		return exitFailure
+	}
+
+	if isInline && result != nil && result != object.NULL {
+		fmt.Fprintln(c.stdout, result.Inspect())
+	}
+
+	return exitOK
+}
+
+func replCommand(c *cli, args []string) int {
+	flags := c.newFlagSet("repl", "[flags] [args...]")
+	var rf runtimeFlags
+	rf.register(flags, false)
+
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	r, err := rf.newRunner(c)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitUsage
+	}
+	setArgs(r, flags.Args())
+	r.OnReset(func() { setArgs(r, flags.Args()) })
+
+	name := "there"
+	if u, err := user.Current(); err == nil && u.Name != "" {
+		name = u.Name
+	}
+	fmt.Fprintf(c.stdout, "Hello %s! This is the doggo programming language!\n", name)
+	fmt.Fprintf(c.stdout, "Feel free to type in commands, or :help\n")
+	repl.Start(c.stdin, c.stdout, c.stderr, r)
+
+	return exitOK
+}
+
+// usageError returns the exit code for flags that couldn't be parsed. Their error was already reported.
+func usageError(err error) int {
+	if err == flag.ErrHelp {
+		return exitOK
+	}
+
+	return exitUsage
+}
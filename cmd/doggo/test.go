@@ -0,0 +1,205 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/runner"
+)
+
+const (
+	// testFileSuffix ends the names of the files holding tests.
+	testFileSuffix = "_test.doggo"
+	// testPrefix starts the names of the functions that are tests.
+	testPrefix = "test"
+)
+
+// testCommand runs the tests found in the given files, or in the test files of the given directories.
+// Every file runs on its own, and then each of its global functions named like 'testSomething' is called.
+// A test fails when it raises an error, like the one of a failed assert.
+func testCommand(c *cli, args []string) int {
+	flags := c.newFlagSet("test", "[flags] [files or directories...]")
+	var rf runtimeFlags
+	rf.register(flags, true)
+	verbose := flags.Bool("v", false, "list every test as it runs")
+
+	if err := flags.Parse(args); err != nil {
+		return usageError(err)
+	}
+
+	if _, err := rf.newRunner(c); err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitUsage
+	}
+
+	paths := flags.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	fileNames, err := findTestFiles(paths)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return exitUsage
+	}
+
+	if len(fileNames) == 0 {
+		fmt.Fprintln(c.stdout, "no test files")
+
+		return exitOK
+	}
+
+	exitCode := exitOK
+	for _, fileName := range fileNames {
+		if !c.runTestFile(rf, fileName, *verbose) {
+			exitCode = exitFailure
+		}
+	}
+
+	return exitCode
+}
+
+// findTestFiles returns the given files, along with the test files found in the given directories, recursively.
+func findTestFiles(paths []string) ([]string, error) {
+	var fileNames []string
+
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, fmt.Errorf("cannot find tests: %s", err)
+		}
+
+		if !info.IsDir() {
+			fileNames = append(fileNames, path)
+
+			continue
+		}
+
+		err = filepath.Walk(path, func(fileName string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !info.IsDir() && strings.HasSuffix(fileName, testFileSuffix) {
+				fileNames = append(fileNames, fileName)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("cannot find tests: %s", err)
+		}
+	}
+
+	sort.Strings(fileNames)
+
+	return fileNames, nil
+}
+
+// runTestFile runs the file and its tests, reporting whether they all passed.
+func (c *cli) runTestFile(rf runtimeFlags, fileName string, verbose bool) bool {
+	code, err := c.readSource(fileName)
+	if err != nil {
+		fmt.Fprintln(c.stderr, err)
+
+		return false
+	}
+
+	r, _ := rf.newRunner(c)
+	setArgs(r, nil)
+
+	ctx, cancel := rf.context()
+	defer cancel()
+
+	if _, ok := c.execute(ctx, r, newReporter(c.stderr, false), fileName, code); !ok {
+		fmt.Fprintf(c.stdout, "FAIL\t%s\n", fileName)
+
+		return false
+	}
+
+	// Each test is called from where it's declared, which is where its traceback starts.
+	program, _ := runner.Parse(fileName, code)
+	tests := testDeclarations(program)
+
+	passed, failed := 0, 0
+	for _, name := range r.Names() {
+		decl, ok := tests[name]
+		if fn, _ := r.Global(name); !ok || fn.Type() != object.FUNCTION_OBJ {
+			continue
+		}
+
+		start := time.Now()
+		result, err := r.Exec(ctx, callAt(decl))
+		elapsed := time.Since(start).Seconds()
+
+		var failure string
+		if err != nil {
+			failure = fmt.Sprintf("compiler error: %s", err)
+		} else if err, ok := result.(*object.Error); ok {
+			failure = runner.Traceback(err)
+		}
+
+		if failure == "" {
+			passed++
+			if verbose {
+				fmt.Fprintf(c.stdout, "--- PASS: %s (%.2fs)\n", name, elapsed)
+			}
+
+			continue
+		}
+
+		failed++
+		fmt.Fprintf(c.stdout, "--- FAIL: %s (%.2fs)\n", name, elapsed)
+		for _, line := range strings.Split(failure, "\n") {
+			fmt.Fprintf(c.stdout, "    %s\n", line)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(c.stdout, "FAIL\t%s\t%d passed, %d failed\n", fileName, passed, failed)
+
+		return false
+	}
+
+	fmt.Fprintf(c.stdout, "ok\t%s\t%d passed\n", fileName, passed)
+
+	return true
+}
+
+// testDeclarations returns the names declared by the program that are named like tests, by name.
+func testDeclarations(program *ast.Program) map[string]*ast.Identifier {
+	tests := make(map[string]*ast.Identifier)
+
+	for _, s := range program.Statements {
+		var name *ast.Identifier
+		switch s := s.(type) {
+		case *ast.ConstStatement:
+			name = s.Name
+		case *ast.LetStatement:
+			name = s.Name
+		case *ast.ExportStatement:
+			name = s.Name()
+		}
+
+		if name != nil && strings.HasPrefix(name.Value, testPrefix) {
+			tests[name.Value] = name
+		}
+	}
+
+	return tests
+}
+
+// callAt returns a program calling the function the identifier names, as if the call was written where the identifier is.
+func callAt(name *ast.Identifier) *ast.Program {
+	call := &ast.CallExpression{Token: name.Token, Function: name, Rparen: name.Token}
+
+	return &ast.Program{Statements: []ast.Statement{&ast.ExpressionStatement{Token: name.Token, Expression: call}}}
+}
@@ -0,0 +1,490 @@
+package doggo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+// Object is the representation of any value in the doggo language.
+type Object = object.Object
+
+var objectType = reflect.TypeOf((*Object)(nil)).Elem()
+
+// ToObject converts a Go value to a doggo one:
+//
+//	nil, nil pointers, slices and maps  null
+//	bool                                boolean
+//	int, int8, ..., uint64              integer
+//	float32, float64                    float
+//	string                              string
+//	slices and arrays                   array
+//	maps                                map, with boolean, number or string keys
+//	structs                             map, keyed by the names of the exported fields
+//	functions                           builtin function, see NewFunc
+//	Object                              the value itself
+//
+// Pointers and interfaces are converted by the values they point to, and the members of
+// arrays and maps are converted the same way. Struct fields can be renamed with a `doggo:"name"`
+// tag, or left out with a `doggo:"-"` one. Values holding themselves, like a map stored in itself, can't be converted.
+func ToObject(value interface{}) (Object, error) {
+	return toObject(reflect.ValueOf(value))
+}
+
+func toObject(v reflect.Value) (Object, error) {
+	return visiting{}.toObject(v)
+}
+
+// visiting holds the containers being converted, the Go ones or the doggo ones, to tell the ones holding themselves.
+type visiting map[interface{}]bool
+
+// goContainer identifies a Go pointer, slice or map. Slices sharing their backing array, but not their length, differ.
+type goContainer struct {
+	typ     reflect.Type
+	pointer uintptr
+	len     int
+}
+
+// enter marks the container as being converted, reporting false if it already is, since it then holds itself.
+func (seen visiting) enter(container interface{}) bool {
+	if seen[container] {
+		return false
+	}
+	seen[container] = true
+
+	return true
+}
+
+func (seen visiting) toObject(v reflect.Value) (Object, error) {
+	if !v.IsValid() {
+		return object.NULL, nil
+	}
+
+	if v.Type().Implements(objectType) {
+		if v.Kind() == reflect.Interface || v.Kind() == reflect.Ptr {
+			if v.IsNil() {
+				return object.NULL, nil
+			}
+		}
+
+		return v.Interface().(Object), nil
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		if v.Bool() {
+			return object.TRUE, nil
+		}
+
+		return object.FALSE, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &object.Integer{Value: v.Int()}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n := v.Uint()
+		if int64(n) < 0 {
+			return nil, fmt.Errorf("cannot convert %d to a doggo value: integer overflow", n)
+		}
+
+		return &object.Integer{Value: int64(n)}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &object.Float{Value: v.Float()}, nil
+
+	case reflect.String:
+		return &object.String{Value: v.String()}, nil
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return object.NULL, nil
+		}
+
+		if v.Kind() == reflect.Ptr {
+			container := goContainer{typ: v.Type(), pointer: v.Pointer()}
+			if !seen.enter(container) {
+				return nil, cycleError(v)
+			}
+			defer delete(seen, container)
+		}
+
+		return seen.toObject(v.Elem())
+
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return object.NULL, nil
+		}
+
+		if v.Kind() == reflect.Slice {
+			container := goContainer{typ: v.Type(), pointer: v.Pointer(), len: v.Len()}
+			if !seen.enter(container) {
+				return nil, cycleError(v)
+			}
+			defer delete(seen, container)
+		}
+
+		elements := make([]object.Object, v.Len())
+		for i := range elements {
+			element, err := seen.toObject(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+
+		return &object.Array{Elements: elements}, nil
+
+	case reflect.Map:
+		if v.IsNil() {
+			return object.NULL, nil
+		}
+
+		container := goContainer{typ: v.Type(), pointer: v.Pointer()}
+		if !seen.enter(container) {
+			return nil, cycleError(v)
+		}
+		defer delete(seen, container)
+
+		m := object.NewMap()
+		iter := v.MapRange()
+		for iter.Next() {
+			if err := seen.setMapPair(m, iter.Key(), iter.Value()); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+
+	case reflect.Struct:
+		m := object.NewMap()
+		for _, field := range structFields(v.Type()) {
+			key := reflect.ValueOf(field.name)
+			if err := seen.setMapPair(m, key, v.FieldByIndex(field.index)); err != nil {
+				return nil, err
+			}
+		}
+
+		return m, nil
+
+	case reflect.Func:
+		if v.IsNil() {
+			return object.NULL, nil
+		}
+
+		return newFunc("", v)
+
+	default:
+		return nil, fmt.Errorf("cannot convert %s to a doggo value", v.Type())
+	}
+}
+
+func cycleError(v reflect.Value) error {
+	return fmt.Errorf("cannot convert %s to a doggo value: it holds itself", v.Type())
+}
+
+func (seen visiting) setMapPair(m *object.Map, k, v reflect.Value) error {
+	key, err := seen.toObject(k)
+	if err != nil {
+		return err
+	}
+
+	mapKey, ok := key.(object.Mappable)
+	if !ok {
+		return fmt.Errorf("unusable as map key: %s", key.Type())
+	}
+
+	value, err := seen.toObject(v)
+	if err != nil {
+		return err
+	}
+	m.Set(mapKey, value)
+
+	return nil
+}
+
+type structField struct {
+	name  string
+	index []int
+}
+
+// structFields returns the exported fields of the struct type, under the names they get in doggo code.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("doggo"); ok {
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		fields = append(fields, structField{name: name, index: field.Index})
+	}
+
+	return fields
+}
+
+// FromObject converts a doggo value to a Go one:
+//
+//	null        nil
+//	boolean     bool
+//	integer     int64
+//	float       float64
+//	string      string
+//	array       []interface{}
+//	map         map[interface{}]interface{}
+//
+// Members of arrays and maps are converted the same way. Other values, like functions, are returned as they are,
+// and so are the arrays and maps held by themselves, where they're held again. FromObjectInto converts to any other Go type.
+func FromObject(obj Object) interface{} {
+	return visiting{}.toGo(obj)
+}
+
+func (seen visiting) toGo(obj Object) interface{} {
+	switch obj := obj.(type) {
+	case nil, *object.Null:
+		return nil
+
+	case *object.Boolean:
+		return obj.Value
+
+	case *object.Integer:
+		return obj.Value
+
+	case *object.Float:
+		return obj.Value
+
+	case *object.String:
+		return obj.Value
+
+	case *object.Array:
+		if !seen.enter(obj) {
+			return obj
+		}
+		defer delete(seen, obj)
+
+		elements := make([]interface{}, len(obj.Elements))
+		for i, element := range obj.Elements {
+			elements[i] = seen.toGo(element)
+		}
+
+		return elements
+
+	case *object.Map:
+		if !seen.enter(obj) {
+			return obj
+		}
+		defer delete(seen, obj)
+
+		m := make(map[interface{}]interface{}, len(obj.Pairs))
+		for _, pair := range obj.Pairs {
+			m[seen.toGo(pair.Key)] = seen.toGo(pair.Value)
+		}
+
+		return m
+
+	default:
+		return obj
+	}
+}
+
+// FromObjectInto converts a doggo value to the Go value the target points to. It's the reverse of ToObject:
+// integers fit into any Go integer type they don't overflow, arrays into slices and arrays, and maps into
+// maps and structs. Null turns pointers, slices, maps, functions, channels and interfaces into nil. Arrays and maps
+// holding themselves can't be converted into Go types, except for the empty interface, see FromObject.
+func FromObjectInto(obj Object, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("cannot convert to %T: not a pointer", target)
+	}
+
+	return fromObject(obj, v.Elem())
+}
+
+// fromObject sets the Go value to the doggo one.
+func fromObject(obj Object, v reflect.Value) error {
+	return visiting{}.into(obj, v)
+}
+
+func (seen visiting) into(obj Object, v reflect.Value) error {
+	t := v.Type()
+	mismatch := func() error {
+		return fmt.Errorf("cannot use %s as %s", obj.Type(), t)
+	}
+
+	if obj == nil {
+		obj = object.NULL
+	}
+
+	if t.Kind() == reflect.Interface {
+		switch {
+		case t.NumMethod() == 0:
+			if converted := seen.toGo(obj); converted != nil {
+				v.Set(reflect.ValueOf(converted))
+			} else {
+				v.Set(reflect.Zero(t))
+			}
+
+		case reflect.TypeOf(obj).Implements(t):
+			v.Set(reflect.ValueOf(obj))
+
+		case obj == object.NULL:
+			v.Set(reflect.Zero(t))
+
+		default:
+			return mismatch()
+		}
+
+		return nil
+	}
+
+	if obj == object.NULL {
+		switch t.Kind() {
+		case reflect.Ptr, reflect.Slice, reflect.Map, reflect.Func, reflect.Chan:
+			v.Set(reflect.Zero(t))
+
+			return nil
+		}
+
+		return mismatch()
+	}
+
+	// A pointer gets the value converted into what it points to, which is the same one, so it's only checked there.
+	switch obj.(type) {
+	case *object.Array, *object.Map:
+		if t.Kind() != reflect.Ptr {
+			if !seen.enter(obj) {
+				return fmt.Errorf("cannot use %s as %s: it holds itself", obj.Type(), t)
+			}
+			defer delete(seen, obj)
+		}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem := reflect.New(t.Elem())
+		if err := seen.into(obj, elem.Elem()); err != nil {
+			return err
+		}
+		v.Set(elem)
+
+	case reflect.Bool:
+		b, ok := obj.(*object.Boolean)
+		if !ok {
+			return mismatch()
+		}
+		v.SetBool(b.Value)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, ok := obj.(*object.Integer)
+		if !ok {
+			return mismatch()
+		}
+
+		if v.OverflowInt(i.Value) {
+			return fmt.Errorf("cannot use %d as %s: integer overflow", i.Value, t)
+		}
+		v.SetInt(i.Value)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		i, ok := obj.(*object.Integer)
+		if !ok {
+			return mismatch()
+		}
+
+		if i.Value < 0 || v.OverflowUint(uint64(i.Value)) {
+			return fmt.Errorf("cannot use %d as %s: integer overflow", i.Value, t)
+		}
+		v.SetUint(uint64(i.Value))
+
+	case reflect.Float32, reflect.Float64:
+		switch n := obj.(type) {
+		case *object.Float:
+			v.SetFloat(n.Value)
+		case *object.Integer:
+			v.SetFloat(float64(n.Value))
+		default:
+			return mismatch()
+		}
+
+	case reflect.String:
+		s, ok := obj.(*object.String)
+		if !ok {
+			return mismatch()
+		}
+		v.SetString(s.Value)
+
+	case reflect.Slice, reflect.Array:
+		arr, ok := obj.(*object.Array)
+		if !ok {
+			return mismatch()
+		}
+
+		if t.Kind() == reflect.Array {
+			if len(arr.Elements) != t.Len() {
+				return fmt.Errorf("cannot use an array of %d members as %s", len(arr.Elements), t)
+			}
+		} else {
+			v.Set(reflect.MakeSlice(t, len(arr.Elements), len(arr.Elements)))
+		}
+
+		for i, element := range arr.Elements {
+			if err := seen.into(element, v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		m, ok := obj.(*object.Map)
+		if !ok {
+			return mismatch()
+		}
+
+		converted := reflect.MakeMapWithSize(t, len(m.Pairs))
+		for _, pair := range m.Pairs {
+			key := reflect.New(t.Key()).Elem()
+			if err := seen.into(pair.Key, key); err != nil {
+				return err
+			}
+
+			value := reflect.New(t.Elem()).Elem()
+			if err := seen.into(pair.Value, value); err != nil {
+				return err
+			}
+
+			converted.SetMapIndex(key, value)
+		}
+		v.Set(converted)
+
+	case reflect.Struct:
+		m, ok := obj.(*object.Map)
+		if !ok {
+			return mismatch()
+		}
+
+		for _, field := range structFields(t) {
+			value, ok := m.Get(&object.String{Value: field.name})
+			if !ok {
+				continue
+			}
+
+			if err := seen.into(value, v.FieldByIndex(field.index)); err != nil {
+				return fmt.Errorf("field %s: %s", field.name, err)
+			}
+		}
+
+	default:
+		return mismatch()
+	}
+
+	return nil
+}
@@ -0,0 +1,243 @@
+// Package doggo embeds the doggo scripting language in Go programs.
+//
+// An Interpreter runs doggo code, keeping its global bindings around between runs.
+// The host can hand values over to the code with SetGlobal, read them back with Global,
+// and let the code call Go functions registered with SetFunc or SetBuiltin:
+//
+//	interp := doggo.NewInterpreter()
+//	interp.SetGlobal("limit", 10)
+//	interp.SetFunc("double", func(n int) int {
+//		return n * 2
+//	})
+//	result, err := interp.Run(ctx, "double(limit)") // int64(20)
+//
+// The code stops once the context is done, or once it goes over the limits set with SetLimits,
+// which makes it safe to run scripts that can't be trusted to end.
+package doggo
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/runner"
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// Engine is the backend used to execute the code.
+type Engine = runner.Engine
+
+const (
+	// EngineEval walks the syntax tree of the code.
+	EngineEval = runner.EngineEval
+	// EngineVM compiles the code to bytecode and executes it on a virtual machine.
+	EngineVM = runner.EngineVM
+)
+
+// Position describes a location in the source code.
+type Position = token.Position
+
+// Interpreter runs doggo code. The bindings declared by the code stay around for the next runs.
+type Interpreter struct {
+	runner *runner.Runner
+}
+
+func NewInterpreter() *Interpreter {
+	return NewInterpreterWithEngine(EngineEval)
+}
+
+// NewInterpreterWithEngine creates an interpreter that executes the code on the given backend.
+func NewInterpreterWithEngine(engine Engine) *Interpreter {
+	return &Interpreter{runner: runner.NewWithEngine(engine)}
+}
+
+// SetStrict turns the strict mode on or off. In strict mode, integer overflows are runtime errors.
+func (i *Interpreter) SetStrict(strict bool) {
+	i.runner.SetStrict(strict)
+}
+
+// SetIO sets the streams the code reads its input from, with the input and readLine functions,
+// and writes to with the print and eprint ones. By default, they are the standard streams of the process.
+func (i *Interpreter) SetIO(in io.Reader, out, errOut io.Writer) {
+	i.runner.SetIO(in, out, errOut)
+}
+
+// Limits bound the resources used by each run. Zero means no limit, except for the call depth,
+// which defaults to DefaultMaxCallDepth.
+type Limits = object.Limits
+
+// DefaultMaxCallDepth is the call depth limit used when none is given.
+const DefaultMaxCallDepth = object.DefaultMaxCallDepth
+
+// LimitError is the kind of the runtime errors stopping a run that was cancelled or went over its limits.
+// The code can't catch them.
+const LimitError = object.LimitError
+
+// SetLimits bounds the resources used by each run of the code.
+func (i *Interpreter) SetLimits(limits Limits) {
+	i.runner.SetLimits(limits)
+}
+
+// Program is parsed doggo code, which can be run any number of times.
+type Program struct {
+	program *ast.Program
+}
+
+// Compile parses the code, reporting its syntax errors as a *SyntaxError.
+// The file name, if any, is mentioned by the positions in error messages.
+func Compile(fileName, code string) (*Program, error) {
+	program, errors := runner.Parse(fileName, code)
+	if len(errors) != 0 {
+		return nil, &SyntaxError{Errors: diag.Errors(errors)}
+	}
+
+	return &Program{program: program}, nil
+}
+
+// Run compiles the code and runs it, returning its result as a Go value. See Exec.
+func (i *Interpreter) Run(ctx context.Context, code string) (interface{}, error) {
+	program, err := Compile("", code)
+	if err != nil {
+		return nil, err
+	}
+
+	return i.Exec(ctx, program)
+}
+
+// Exec runs the program, returning its result as a Go value, converted by FromObject.
+// Errors raised and not caught by the code are reported as a *RuntimeError. So is stopping the
+// program once the context is done, or once it goes over its limits, with the LimitError kind.
+func (i *Interpreter) Exec(ctx context.Context, program *Program) (interface{}, error) {
+	result, err := i.runner.Exec(ctx, program.program)
+	if err != nil {
+		return nil, fmt.Errorf("compiler error: %w", err)
+	}
+
+	if err, ok := result.(*object.Error); ok {
+		return nil, newRuntimeError(err)
+	}
+
+	return FromObject(result), nil
+}
+
+// SetGlobal binds the name to the value, converted by ToObject, as a global variable of the code.
+func (i *Interpreter) SetGlobal(name string, value interface{}) error {
+	obj, err := ToObject(value)
+	if err != nil {
+		return err
+	}
+
+	i.runner.SetGlobal(name, obj)
+
+	return nil
+}
+
+// Global returns the value bound to the global name, converted by FromObject.
+func (i *Interpreter) Global(name string) (interface{}, bool) {
+	obj, ok := i.runner.Global(name)
+	if !ok {
+		return nil, false
+	}
+
+	return FromObject(obj), true
+}
+
+// Builtin is a Go function the code can call. The arguments are converted by FromObject, and the result
+// by ToObject. Returning an error raises it in the code, where it can be caught.
+type Builtin func(args ...interface{}) (interface{}, error)
+
+// SetBuiltin binds the name to the Go function, as a global variable of the code.
+func (i *Interpreter) SetBuiltin(name string, fn Builtin) {
+	i.runner.SetGlobal(name, NewBuiltin(fn))
+}
+
+// SetFunc binds the name to the plain Go function, as a global variable of the code. See NewFunc.
+func (i *Interpreter) SetFunc(name string, fn interface{}) error {
+	obj, err := NewFunc(name, fn)
+	if err != nil {
+		return err
+	}
+
+	i.runner.SetGlobal(name, obj)
+
+	return nil
+}
+
+// NewBuiltin wraps the Go function in an object the code can call.
+func NewBuiltin(fn Builtin) Object {
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		values := make([]interface{}, len(args))
+		for i, arg := range args {
+			values[i] = FromObject(arg)
+		}
+
+		result, err := fn(values...)
+		if err != nil {
+			return &object.Error{Code: "host-error", Message: err.Error()}
+		}
+
+		obj, err := ToObject(result)
+		if err != nil {
+			return &object.Error{Code: "host-error", Message: err.Error()}
+		}
+
+		return obj
+	}}
+}
+
+// SyntaxError holds the problems found while parsing the code.
+type SyntaxError struct {
+	Errors []string
+}
+
+func (e *SyntaxError) Error() string {
+	return strings.Join(e.Errors, "\n")
+}
+
+// RuntimeError is an error raised by the code, and not caught by it.
+type RuntimeError struct {
+	// Kind is either "RuntimeError" for the errors raised by the language, "LimitError" for the ones stopping
+	// a run, or the one given by a throw statement.
+	Kind string
+	// Code tells the errors raised by the language apart, like "type-mismatch" or "division-by-zero".
+	// The errors thrown by the code have the "thrown" code.
+	Code    string
+	Message string
+	// Value is the value thrown by the code, if any.
+	Value    interface{}
+	Position Position
+	// Traceback renders the error, along with the function calls it unwound through.
+	Traceback string
+}
+
+func newRuntimeError(err *object.Error) *RuntimeError {
+	e := &RuntimeError{
+		Kind:      err.Kind,
+		Code:      err.Code,
+		Message:   err.Message,
+		Position:  err.Pos,
+		Traceback: runner.Traceback(err),
+	}
+
+	if e.Kind == "" {
+		e.Kind = object.RuntimeError
+	}
+
+	if e.Code == "" {
+		e.Code = "runtime-error"
+	}
+
+	if err.Value != nil {
+		e.Value = FromObject(err.Value)
+	}
+
+	return e
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Position, e.Message)
+}
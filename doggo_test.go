@@ -0,0 +1,439 @@
+package doggo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var engines = []Engine{EngineEval, EngineVM}
+
+func TestInterpreterRun(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"1 + 2", int64(3)},
+		{"1.5 * 2", 3.0},
+		{`"dog" + "go"`, "doggo"},
+		{"!true", false},
+		{`[1, [2.5, {}["x"]]]`, []interface{}{int64(1), []interface{}{2.5, nil}}},
+		{`{"a": 1, 2: true}`, map[interface{}]interface{}{"a": int64(1), int64(2): true}},
+		{"let x = 1;", nil},
+	}
+
+	for _, engine := range engines {
+		for _, tc := range testCases {
+			interp := NewInterpreterWithEngine(engine)
+
+			result, err := interp.Run(context.Background(), tc.input)
+			if err != nil {
+				t.Errorf("unexpected error on the %q engine for %q: %s", engine, tc.input, err)
+
+				continue
+			}
+
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("wrong result on the %q engine for %q. expected=%#v, got=%#v", engine, tc.input, tc.expected, result)
+			}
+		}
+	}
+}
+
+func TestInterpreterGlobals(t *testing.T) {
+	for _, engine := range engines {
+		interp := NewInterpreterWithEngine(engine)
+
+		if err := interp.SetGlobal("limits", map[string]interface{}{"max": 10, "names": []interface{}{"a", "b"}}); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		if _, err := interp.Run(context.Background(), `let total = limits["max"] + length(limits["names"]);`); err != nil {
+			t.Fatalf("unexpected error on the %q engine: %s", engine, err)
+		}
+
+		total, ok := interp.Global("total")
+		if !ok || total != int64(12) {
+			t.Errorf("wrong global on the %q engine. got=%#v (%t)", engine, total, ok)
+		}
+
+		if _, ok := interp.Global("missing"); ok {
+			t.Errorf("unexpected global on the %q engine", engine)
+		}
+
+		if err := interp.SetGlobal("bad", make(chan int)); err == nil {
+			t.Errorf("expected an error for an unsupported value on the %q engine", engine)
+		}
+	}
+}
+
+func TestInterpreterBuiltins(t *testing.T) {
+	for _, engine := range engines {
+		interp := NewInterpreterWithEngine(engine)
+
+		interp.SetBuiltin("sum", func(args ...interface{}) (interface{}, error) {
+			var sum int64
+			for _, arg := range args {
+				n, ok := arg.(int64)
+				if !ok {
+					return nil, errors.New("sum only takes integers")
+				}
+				sum += n
+			}
+
+			return sum, nil
+		})
+
+		result, err := interp.Run(context.Background(), `let r = ""; try { sum(1, "2") } catch (e) { r = e["message"] }; [sum(1, 2, 3), r]`)
+		if err != nil {
+			t.Fatalf("unexpected error on the %q engine: %s", engine, err)
+		}
+
+		expected := []interface{}{int64(6), "sum only takes integers"}
+		if !reflect.DeepEqual(result, expected) {
+			t.Errorf("wrong result on the %q engine. expected=%#v, got=%#v", engine, expected, result)
+		}
+	}
+}
+
+func TestInterpreterErrors(t *testing.T) {
+	for _, engine := range engines {
+		interp := NewInterpreterWithEngine(engine)
+
+		_, err := interp.Run(context.Background(), "let x = ;")
+		if _, ok := err.(*SyntaxError); !ok {
+			t.Errorf("expected a syntax error on the %q engine. got=%T (%v)", engine, err, err)
+		}
+
+		_, err = interp.Run(context.Background(), `1 + true`)
+		runtimeErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Fatalf("expected a runtime error on the %q engine. got=%T (%v)", engine, err, err)
+		}
+
+		if runtimeErr.Kind != "RuntimeError" || runtimeErr.Code != "type-mismatch" || runtimeErr.Error() != "1:1: type mismatch: INTEGER + BOOLEAN" {
+			t.Errorf("wrong runtime error on the %q engine. got=%+v", engine, runtimeErr)
+		}
+
+		_, err = interp.Run(context.Background(), `throw {"message": "too big", "kind": "LimitError", "limit": 3}`)
+		runtimeErr, ok = err.(*RuntimeError)
+		if !ok {
+			t.Fatalf("expected a runtime error on the %q engine. got=%T (%v)", engine, err, err)
+		}
+
+		if runtimeErr.Kind != "LimitError" || runtimeErr.Code != "thrown" || runtimeErr.Message != "too big" {
+			t.Errorf("wrong thrown error on the %q engine. got=%+v", engine, runtimeErr)
+		}
+	}
+}
+
+func TestInterpreterIO(t *testing.T) {
+	for _, engine := range engines {
+		interp := NewInterpreterWithEngine(engine)
+
+		out := new(strings.Builder)
+		interp.SetIO(strings.NewReader("21\n"), out, out)
+
+		result, err := interp.Run(context.Background(), `print("reading"); [readLine(), readLine()]`)
+		if err != nil {
+			t.Fatalf("unexpected error on the %q engine: %s", engine, err)
+		}
+
+		expected := []interface{}{"21", nil}
+		if !reflect.DeepEqual(result, expected) || out.String() != "reading\n" {
+			t.Errorf("wrong result on the %q engine. got=%#v, printed %q", engine, result, out.String())
+		}
+	}
+}
+
+func TestInterpreterLimits(t *testing.T) {
+	for _, engine := range engines {
+		interp := NewInterpreterWithEngine(engine)
+		interp.SetLimits(Limits{MaxSteps: 10000})
+
+		_, err := interp.Run(context.Background(), `let r = 0; try { while (true) { r += 1 } } catch (e) { r = -1 }`)
+		runtimeErr, ok := err.(*RuntimeError)
+		if !ok {
+			t.Fatalf("expected a runtime error on the %q engine. got=%T (%v)", engine, err, err)
+		}
+
+		if runtimeErr.Kind != LimitError || runtimeErr.Message != "step limit exceeded: 10000" {
+			t.Errorf("wrong limit error on the %q engine. got=%+v", engine, runtimeErr)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err = interp.Run(ctx, `while (true) {}`)
+		if runtimeErr, ok := err.(*RuntimeError); !ok || runtimeErr.Message != "execution stopped: context canceled" {
+			t.Errorf("wrong error for a cancelled run on the %q engine. got=%v", engine, err)
+		}
+	}
+}
+
+func TestCompiledProgramRunsAgain(t *testing.T) {
+	program, err := Compile("counter.doggo", "const step = fn() { 1 }; count += step(); count")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, engine := range engines {
+		interp := NewInterpreterWithEngine(engine)
+		if err := interp.SetGlobal("count", 0); err != nil {
+			t.Fatalf("unexpected error: %s", err)
+		}
+
+		var result interface{}
+		for i := 0; i < 3; i++ {
+			if result, err = interp.Exec(context.Background(), program); err != nil {
+				t.Fatalf("unexpected error on the %q engine: %s", engine, err)
+			}
+		}
+
+		if result != int64(3) {
+			t.Errorf("wrong result on the %q engine. got=%#v", engine, result)
+		}
+	}
+}
+
+type level int
+
+type point struct {
+	X     int
+	Y     int    `doggo:"-"`
+	Label string `doggo:"name"`
+	note  string
+}
+
+func TestToObject(t *testing.T) {
+	testCases := []struct {
+		input    interface{}
+		expected string
+	}{
+		{nil, "null"},
+		{true, "true"},
+		{uint8(7), "7"},
+		{float32(0.5), "0.5"},
+		{"doggo", "doggo"},
+		{[]interface{}{1, "a"}, "[1, a]"},
+		{map[interface{}]interface{}{1: "a", true: 2.5}, "{true: 2.5, 1: a}"},
+		{[]int{1, 2}, "[1, 2]"},
+		{[2]string{"a", "b"}, "[a, b]"},
+		{[]int(nil), "null"},
+		{map[string]bool{"ok": true}, "{ok: true}"},
+		{level(3), "3"},
+		{&point{X: 1, Y: 2, Label: "p"}, "{X: 1, name: p}"},
+		{(*point)(nil), "null"},
+		{[]*point{{X: 5}}, "[{X: 5, name: }]"},
+	}
+
+	for _, tc := range testCases {
+		obj, err := ToObject(tc.input)
+		if err != nil {
+			t.Errorf("unexpected error for %#v: %s", tc.input, err)
+
+			continue
+		}
+
+		if obj.Inspect() != tc.expected {
+			t.Errorf("wrong object for %#v. expected=%s, got=%s", tc.input, tc.expected, obj.Inspect())
+		}
+	}
+
+	invalid := []interface{}{uint64(1 << 63), complex(1, 2), map[interface{}]interface{}{NewBuiltin(nil): 1}, []interface{}{make(chan int)}}
+	for _, input := range invalid {
+		if _, err := ToObject(input); err == nil {
+			t.Errorf("expected an error for %#v", input)
+		}
+	}
+}
+
+func TestFromObjectInto(t *testing.T) {
+	interp := NewInterpreter()
+	if _, err := interp.Run(context.Background(), `let p = {"X": 3, "name": "origin", "extra": true}; let ps = [p, p]; let n = 300;`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	global := func(name string) Object {
+		obj, ok := interp.runner.Global(name)
+		if !ok {
+			t.Fatalf("global %s not found", name)
+		}
+
+		return obj
+	}
+
+	var p point
+	if err := FromObjectInto(global("p"), &p); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if p != (point{X: 3, Label: "origin"}) {
+		t.Errorf("wrong struct. got=%+v", p)
+	}
+
+	var ps []*point
+	if err := FromObjectInto(global("ps"), &ps); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(ps) != 2 || ps[1].Label != "origin" {
+		t.Errorf("wrong slice. got=%+v", ps)
+	}
+
+	var m map[string]interface{}
+	if err := FromObjectInto(global("p"), &m); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if m["X"] != int64(3) || m["extra"] != true {
+		t.Errorf("wrong map. got=%+v", m)
+	}
+
+	var f float64
+	if err := FromObjectInto(global("n"), &f); err != nil || f != 300 {
+		t.Errorf("wrong float. got=%v (%v)", f, err)
+	}
+
+	testCases := []struct {
+		target        interface{}
+		expectedError string
+	}{
+		{new(int8), "cannot use 300 as int8: integer overflow"},
+		{new(string), "cannot use INTEGER as string"},
+		{new([3]int), "cannot use INTEGER as [3]int"},
+		{p, "cannot convert to doggo.point: not a pointer"},
+	}
+
+	for _, tc := range testCases {
+		err := FromObjectInto(global("n"), tc.target)
+		if err == nil || err.Error() != tc.expectedError {
+			t.Errorf("wrong error for %T. expected=%q, got=%v", tc.target, tc.expectedError, err)
+		}
+	}
+}
+
+func TestConvertNilFields(t *testing.T) {
+	type fields struct {
+		F func(int) int
+		M map[string]int
+		P *point
+		E error
+	}
+
+	obj, err := ToObject(fields{})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	dst := fields{F: func(n int) int { return n }, M: map[string]int{}, P: &point{}, E: errors.New("set")}
+	if err := FromObjectInto(obj, &dst); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if dst.F != nil || dst.M != nil || dst.P != nil || dst.E != nil {
+		t.Errorf("expected the fields to be nil. got=%+v", dst)
+	}
+}
+
+func TestConvertCycles(t *testing.T) {
+	m := map[string]interface{}{"a": 1}
+	m["self"] = m
+	s := []interface{}{1}
+	s[0] = s
+
+	for _, input := range []interface{}{m, s} {
+		if _, err := ToObject(input); err == nil || !strings.HasSuffix(err.Error(), "it holds itself") {
+			t.Errorf("expected an error for a value holding itself. got=%v", err)
+		}
+	}
+
+	interp := NewInterpreter()
+	if _, err := interp.Run(context.Background(), `let a = [1]; a[0] = a;`); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	a, _ := interp.runner.Global("a")
+	value, ok := FromObject(a).([]interface{})
+	if !ok || len(value) != 1 || value[0] != a {
+		t.Errorf("expected the array held again to be kept as it is. got=%#v", value)
+	}
+
+	var target []interface{}
+	if err := FromObjectInto(a, &target); err != nil {
+		t.Errorf("unexpected error: %s", err)
+	}
+
+	var ints [][]int
+	if err := FromObjectInto(a, &ints); err == nil || err.Error() != "cannot use ARRAY as []int: it holds itself" {
+		t.Errorf("wrong error. got=%v", err)
+	}
+}
+
+func TestInterpreterFuncs(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`repeat("ab", 3)`, "ababab"},
+		{`join(", ")`, ""},
+		{`join("-", "a", "b")`, "a-b"},
+		{`distance({"X": 3, "Y": 4, "name": "p"})`, int64(3)},
+		{`safeDiv(6, 3)`, int64(2)},
+		{`let r = ""; try { safeDiv(1, 0) } catch (e) { r = e["message"] }; r`, "division by zero"},
+		{`let r = ""; try { repeat("ab") } catch (e) { r = e["message"] }; r`, "wrong number of arguments to repeat: want=2, got=1"},
+		{`let r = ""; try { repeat(1, 2) } catch (e) { r = e["message"] }; r`, "wrong argument 1 to repeat: cannot use INTEGER as string"},
+		{`let r = ""; try { join() } catch (e) { r = e["message"] }; r`, "wrong number of arguments to join: want=at least 1, got=0"},
+		{`noop()`, nil},
+	}
+
+	for _, engine := range engines {
+		interp := NewInterpreterWithEngine(engine)
+
+		funcs := map[string]interface{}{
+			"repeat": strings.Repeat,
+			"join": func(sep string, parts ...string) string {
+				return strings.Join(parts, sep)
+			},
+			"distance": func(p point) int {
+				return p.X
+			},
+			"safeDiv": func(a, b int) (int, error) {
+				if b == 0 {
+					return 0, errors.New("division by zero")
+				}
+
+				return a / b, nil
+			},
+			"noop": func() {},
+		}
+
+		for name, fn := range funcs {
+			if err := interp.SetFunc(name, fn); err != nil {
+				t.Fatalf("unexpected error for %s: %s", name, err)
+			}
+		}
+
+		for _, tc := range testCases {
+			result, err := interp.Run(context.Background(), tc.input)
+			if err != nil {
+				t.Errorf("unexpected error on the %q engine for %q: %s", engine, tc.input, err)
+
+				continue
+			}
+
+			if !reflect.DeepEqual(result, tc.expected) {
+				t.Errorf("wrong result on the %q engine for %q. expected=%#v, got=%#v", engine, tc.input, tc.expected, result)
+			}
+		}
+	}
+
+	invalid := []interface{}{1, func() (int, int) { return 1, 2 }, func() (error, int) { return nil, 1 }}
+	for _, fn := range invalid {
+		if _, err := NewFunc("f", fn); err == nil {
+			t.Errorf("expected an error for %T", fn)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package doggo
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// NewFunc wraps a plain Go function in an object the code can call, like func(a int, b string) (string, error).
+// The arguments are checked and converted by FromObjectInto, and the result by ToObject. The function may return
+// nothing, a value, an error, or a value and an error. Returning an error raises it in the code.
+// The name is used in the error messages about the arguments.
+func NewFunc(name string, fn interface{}) (Object, error) {
+	v := reflect.ValueOf(fn)
+	if v.Kind() != reflect.Func || v.IsNil() {
+		return nil, fmt.Errorf("cannot use %T as a function", fn)
+	}
+
+	return newFunc(name, v)
+}
+
+func newFunc(name string, fn reflect.Value) (Object, error) {
+	t := fn.Type()
+	if name == "" {
+		name = "<anonymous>"
+	}
+
+	// The error, if any, is the last result.
+	numOut := t.NumOut()
+	returnsError := numOut > 0 && t.Out(numOut-1) == errorType
+	returnsValue := numOut == 2 || numOut == 1 && !returnsError
+	if numOut > 2 || numOut == 2 && !returnsError {
+		return nil, fmt.Errorf("cannot use %s as a function: it must return at most a value and an error", t)
+	}
+
+	numIn := t.NumIn()
+	required := numIn
+	if t.IsVariadic() {
+		required--
+	}
+
+	return &object.Builtin{Fn: func(args ...object.Object) object.Object {
+		if len(args) < required || len(args) > numIn && !t.IsVariadic() {
+			want := fmt.Sprintf("%d", required)
+			if t.IsVariadic() {
+				want = fmt.Sprintf("at least %d", required)
+			}
+
+			return &object.Error{
+				Code:    "wrong-arguments",
+				Message: fmt.Sprintf("wrong number of arguments to %s: want=%s, got=%d", name, want, len(args)),
+			}
+		}
+
+		in := make([]reflect.Value, len(args))
+		for i, arg := range args {
+			var paramType reflect.Type
+			if i < required {
+				paramType = t.In(i)
+			} else {
+				paramType = t.In(numIn - 1).Elem()
+			}
+
+			in[i] = reflect.New(paramType).Elem()
+			if err := fromObject(arg, in[i]); err != nil {
+				return &object.Error{Code: "wrong-arguments", Message: fmt.Sprintf("wrong argument %d to %s: %s", i+1, name, err)}
+			}
+		}
+
+		out := fn.Call(in)
+
+		if returnsError {
+			if err, _ := out[numOut-1].Interface().(error); err != nil {
+				return &object.Error{Code: "host-error", Message: err.Error()}
+			}
+		}
+
+		if !returnsValue {
+			return object.NULL
+		}
+
+		result, err := toObject(out[0])
+		if err != nil {
+			return &object.Error{Code: "host-error", Message: fmt.Sprintf("wrong result of %s: %s", name, err)}
+		}
+
+		return result
+	}}, nil
+}
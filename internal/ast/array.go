@@ -10,6 +10,7 @@ import (
 type ArrayLiteral struct {
 	Token    token.Token // The 'token.LBRACKET' token.
 	Elements []Expression
+	Rbracket token.Token // The closing 'token.RBRACKET' token.
 }
 
 func (al *ArrayLiteral) expressionNode() {}
@@ -18,6 +19,14 @@ func (al *ArrayLiteral) TokenLiteral() string {
 	return al.Token.Literal
 }
 
+func (al *ArrayLiteral) Pos() token.Position {
+	return al.Token.Pos
+}
+
+func (al *ArrayLiteral) End() token.Position {
+	return al.Rbracket.End
+}
+
 func (al *ArrayLiteral) String() string {
 	var out bytes.Buffer
 
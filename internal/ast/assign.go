@@ -0,0 +1,47 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// AssignExpression rebinds a variable, or sets a member of an array or a map.
+// Compound operators, like '+=', combine the current value with the new one.
+type AssignExpression struct {
+	Token    token.Token // The assignment operator token, e.g. 'token.ASSIGN'.
+	Target   Expression  // Either an *Identifier or an *IndexExpression.
+	Operator string
+	Value    Expression
+}
+
+func (ae *AssignExpression) expressionNode() {}
+
+func (ae *AssignExpression) TokenLiteral() string {
+	return ae.Token.Literal
+}
+
+func (ae *AssignExpression) Pos() token.Position {
+	return ae.Target.Pos()
+}
+
+func (ae *AssignExpression) End() token.Position {
+	if ae.Value != nil {
+		return ae.Value.End()
+	}
+
+	return ae.Token.End
+}
+
+func (ae *AssignExpression) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ae.Target.String())
+	out.WriteString(" " + ae.Operator + " ")
+
+	if ae.Value != nil {
+		out.WriteString(ae.Value.String())
+	}
+
+	return out.String()
+}
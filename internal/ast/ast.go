@@ -2,11 +2,17 @@ package ast
 
 import (
 	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
 )
 
 type Node interface {
 	TokenLiteral() string
 	String() string
+	// Pos returns the position of the first character belonging to the node.
+	Pos() token.Position
+	// End returns the position of the first character immediately after the node.
+	End() token.Position
 }
 
 type Statement interface {
@@ -31,6 +37,22 @@ func (p *Program) TokenLiteral() string {
 	return ""
 }
 
+func (p *Program) Pos() token.Position {
+	if len(p.Statements) > 0 {
+		return p.Statements[0].Pos()
+	}
+
+	return token.Position{}
+}
+
+func (p *Program) End() token.Position {
+	if n := len(p.Statements); n > 0 {
+		return p.Statements[n-1].End()
+	}
+
+	return token.Position{}
+}
+
 func (p *Program) String() string {
 	var out bytes.Buffer
 
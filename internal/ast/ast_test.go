@@ -27,3 +27,39 @@ func TestString(t *testing.T) {
 		t.Errorf("program.String() wrong.got=%q", program.String())
 	}
 }
+
+func TestDump(t *testing.T) {
+	pos := func(column int) token.Position {
+		return token.Position{Line: 1, Column: column, Offset: column - 1}
+	}
+
+	program := &Program{
+		Statements: []Statement{
+			&ExpressionStatement{
+				Token: token.Token{Type: token.IDENT, Literal: "f", Pos: pos(1)},
+				Expression: &CallExpression{
+					Token:    token.Token{Type: token.LPAREN, Literal: "(", Pos: pos(2)},
+					Function: &Identifier{Token: token.Token{Type: token.IDENT, Literal: "f", Pos: pos(1)}, Value: "f"},
+					Arguments: []Expression{
+						&Boolean{Token: token.Token{Type: token.FALSE, Literal: "false", Pos: pos(3)}, Value: false},
+					},
+				},
+			},
+		},
+	}
+
+	expected := `Program 1:1
+  Statements:
+    0: ExpressionStatement 1:1
+      Expression: CallExpression 1:1
+        Function: Identifier 1:1
+          Value: "f"
+        Arguments:
+          0: Boolean 1:3
+            Value: false
+`
+
+	if dump := Dump(program); dump != expected {
+		t.Errorf("wrong dump.\nexpected=\n%s\ngot=\n%s", expected, dump)
+	}
+}
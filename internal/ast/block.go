@@ -9,6 +9,7 @@ import (
 type BlockStatement struct {
 	Token      token.Token // The 'token.LBRACE' token.
 	Statements []Statement
+	Rbrace     token.Token // The closing 'token.RBRACE' token.
 }
 
 func (bs *BlockStatement) statementNode() {}
@@ -17,6 +18,14 @@ func (bs *BlockStatement) TokenLiteral() string {
 	return bs.Token.Literal
 }
 
+func (bs *BlockStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BlockStatement) End() token.Position {
+	return bs.Rbrace.End
+}
+
 func (bs *BlockStatement) String() string {
 	var out bytes.Buffer
 
@@ -15,6 +15,14 @@ func (b *Boolean) TokenLiteral() string {
 	return b.Token.Literal
 }
 
+func (b *Boolean) Pos() token.Position {
+	return b.Token.Pos
+}
+
+func (b *Boolean) End() token.Position {
+	return b.Token.End
+}
+
 func (b *Boolean) String() string {
 	return b.Token.Literal
 }
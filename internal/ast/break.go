@@ -0,0 +1,27 @@
+package ast
+
+import (
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+type BreakStatement struct {
+	Token token.Token // The 'token.BREAK' token.
+}
+
+func (bs *BreakStatement) statementNode() {}
+
+func (bs *BreakStatement) TokenLiteral() string {
+	return bs.Token.Literal
+}
+
+func (bs *BreakStatement) Pos() token.Position {
+	return bs.Token.Pos
+}
+
+func (bs *BreakStatement) End() token.Position {
+	return bs.Token.End
+}
+
+func (bs *BreakStatement) String() string {
+	return bs.TokenLiteral() + ";"
+}
@@ -11,6 +11,7 @@ type CallExpression struct {
 	Token     token.Token // The 'token.LPAREN' token.
 	Function  Expression  // Identifier or FunctionLiteral.
 	Arguments []Expression
+	Rparen    token.Token // The closing 'token.RPAREN' token.
 }
 
 func (ce *CallExpression) expressionNode() {}
@@ -19,6 +20,14 @@ func (ce *CallExpression) TokenLiteral() string {
 	return ce.Token.Literal
 }
 
+func (ce *CallExpression) Pos() token.Position {
+	return ce.Function.Pos()
+}
+
+func (ce *CallExpression) End() token.Position {
+	return ce.Rparen.End
+}
+
 func (ce *CallExpression) String() string {
 	var args []string
 	for _, a := range ce.Arguments {
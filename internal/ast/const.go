@@ -18,6 +18,18 @@ func (cs *ConstStatement) TokenLiteral() string {
 	return cs.Token.Literal
 }
 
+func (cs *ConstStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ConstStatement) End() token.Position {
+	if cs.Value != nil {
+		return cs.Value.End()
+	}
+
+	return cs.Name.End()
+}
+
 func (cs *ConstStatement) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,27 @@
+package ast
+
+import (
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+type ContinueStatement struct {
+	Token token.Token // The 'token.CONTINUE' token.
+}
+
+func (cs *ContinueStatement) statementNode() {}
+
+func (cs *ContinueStatement) TokenLiteral() string {
+	return cs.Token.Literal
+}
+
+func (cs *ContinueStatement) Pos() token.Position {
+	return cs.Token.Pos
+}
+
+func (cs *ContinueStatement) End() token.Position {
+	return cs.Token.End
+}
+
+func (cs *ContinueStatement) String() string {
+	return cs.TokenLiteral() + ";"
+}
@@ -0,0 +1,104 @@
+package ast
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+var tokenType = reflect.TypeOf(token.Token{})
+
+// Dump renders the tree of the node, one field per line, for debugging. Every node is shown with its
+// type and position, and its fields are indented below it. The tokens and the empty fields are left out.
+func Dump(node Node) string {
+	buf := new(strings.Builder)
+	dumpValue(buf, reflect.ValueOf(node), 0)
+
+	return buf.String()
+}
+
+func dumpValue(w io.Writer, v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		if v.IsNil() {
+			fmt.Fprintln(w, "nil")
+
+			return
+		}
+
+		if node, ok := v.Interface().(Node); ok && v.Kind() == reflect.Ptr {
+			fmt.Fprintf(w, "%s %s\n", v.Type().Elem().Name(), node.Pos())
+			dumpFields(w, v.Elem(), depth+1)
+
+			return
+		}
+
+		dumpValue(w, v.Elem(), depth)
+
+	case reflect.Slice:
+		fmt.Fprintln(w)
+		for i := 0; i < v.Len(); i++ {
+			fmt.Fprintf(w, "%s  %d: ", indent, i)
+			dumpValue(w, v.Index(i), depth+1)
+		}
+
+	case reflect.Map:
+		// The pairs of map literals are shown in the order they were written in.
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool {
+			return keys[i].Interface().(Node).Pos().Offset < keys[j].Interface().(Node).Pos().Offset
+		})
+
+		fmt.Fprintln(w)
+		for _, key := range keys {
+			fmt.Fprintf(w, "%s  key: ", indent)
+			dumpValue(w, key, depth+1)
+			fmt.Fprintf(w, "%s  value: ", indent)
+			dumpValue(w, v.MapIndex(key), depth+1)
+		}
+
+	case reflect.String:
+		fmt.Fprintf(w, "%q\n", v.String())
+
+	default:
+		fmt.Fprintf(w, "%v\n", v.Interface())
+	}
+}
+
+func dumpFields(w io.Writer, v reflect.Value, depth int) {
+	indent := strings.Repeat("  ", depth)
+
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		value := v.Field(i)
+		if field.Type == tokenType || isEmpty(value) {
+			continue
+		}
+
+		// Lists start on the next line.
+		separator := " "
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Map {
+			separator = ""
+		}
+
+		fmt.Fprintf(w, "%s%s:%s", indent, field.Name, separator)
+		dumpValue(w, value, depth)
+	}
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Interface, reflect.Ptr:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	}
+
+	return false
+}
@@ -0,0 +1,43 @@
+package ast
+
+import (
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// ExportStatement makes the binding of a declaration available to the modules importing this one.
+type ExportStatement struct {
+	Token token.Token // The 'token.EXPORT' token.
+	// Declaration is either a *ConstStatement or a *LetStatement.
+	Declaration Statement
+}
+
+func (es *ExportStatement) statementNode() {}
+
+func (es *ExportStatement) TokenLiteral() string {
+	return es.Token.Literal
+}
+
+func (es *ExportStatement) Pos() token.Position {
+	return es.Token.Pos
+}
+
+func (es *ExportStatement) End() token.Position {
+	return es.Declaration.End()
+}
+
+func (es *ExportStatement) String() string {
+	return es.TokenLiteral() + " " + es.Declaration.String()
+}
+
+// Name returns the name bound by the exported declaration.
+func (es *ExportStatement) Name() *Identifier {
+	switch decl := es.Declaration.(type) {
+	case *ConstStatement:
+		return decl.Name
+
+	case *LetStatement:
+		return decl.Name
+	}
+
+	return nil
+}
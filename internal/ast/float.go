@@ -0,0 +1,28 @@
+package ast
+
+import (
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+type FloatLiteral struct {
+	Token token.Token // Any floating-point number.
+	Value float64
+}
+
+func (fl *FloatLiteral) expressionNode() {}
+
+func (fl *FloatLiteral) TokenLiteral() string {
+	return fl.Token.Literal
+}
+
+func (fl *FloatLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
+
+func (fl *FloatLiteral) End() token.Position {
+	return fl.Token.End
+}
+
+func (fl *FloatLiteral) String() string {
+	return fl.Token.Literal
+}
@@ -10,7 +10,12 @@ import (
 type FunctionLiteral struct {
 	Token      token.Token // The 'token.FUNCTION' token.
 	Parameters []*Identifier
-	Body       *BlockStatement
+	// Defaults holds the default values of the parameters, by index, or nil for the required ones.
+	Defaults []Expression
+	// Rest collects the arguments left over after the parameters, if set.
+	Rest *Identifier
+	Body *BlockStatement
+	Name string // The name of the constant the function is bound to, if any.
 }
 
 func (fl *FunctionLiteral) expressionNode() {}
@@ -19,18 +24,39 @@ func (fl *FunctionLiteral) TokenLiteral() string {
 	return fl.Token.Literal
 }
 
-func (fl *FunctionLiteral) String() string {
-	var params []string
-	for _, p := range fl.Parameters {
-		params = append(params, p.String())
-	}
+func (fl *FunctionLiteral) Pos() token.Position {
+	return fl.Token.Pos
+}
 
+func (fl *FunctionLiteral) End() token.Position {
+	return fl.Body.End()
+}
+
+func (fl *FunctionLiteral) String() string {
 	var out bytes.Buffer
 	out.WriteString(fl.TokenLiteral())
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(FormatParameters(fl.Parameters, fl.Defaults, fl.Rest))
 	out.WriteString(")")
 	out.WriteString(fl.Body.String())
 
 	return out.String()
 }
+
+// FormatParameters renders a parameter list the way it is written in the source code.
+func FormatParameters(parameters []*Identifier, defaults []Expression, rest *Identifier) string {
+	var params []string
+	for i, p := range parameters {
+		if i < len(defaults) && defaults[i] != nil {
+			params = append(params, p.String()+" = "+defaults[i].String())
+		} else {
+			params = append(params, p.String())
+		}
+	}
+
+	if rest != nil {
+		params = append(params, "..."+rest.String())
+	}
+
+	return strings.Join(params, ", ")
+}
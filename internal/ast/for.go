@@ -0,0 +1,49 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// ForStatement loops over the members of an array, a string or a map.
+// With a single variable, it is bound to the elements of arrays, the characters of strings and the keys of maps.
+// With two variables, the first one is bound to the index, or to the key for maps, and the second one to the value.
+type ForStatement struct {
+	Token    token.Token // The 'token.FOR' token.
+	Key      *Identifier // Nil when there's a single variable.
+	Value    *Identifier
+	Iterable Expression
+	Body     *BlockStatement
+}
+
+func (fs *ForStatement) statementNode() {}
+
+func (fs *ForStatement) TokenLiteral() string {
+	return fs.Token.Literal
+}
+
+func (fs *ForStatement) Pos() token.Position {
+	return fs.Token.Pos
+}
+
+func (fs *ForStatement) End() token.Position {
+	return fs.Body.End()
+}
+
+func (fs *ForStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("for (")
+	if fs.Key != nil {
+		out.WriteString(fs.Key.String())
+		out.WriteString(", ")
+	}
+	out.WriteString(fs.Value.String())
+	out.WriteString(" in ")
+	out.WriteString(fs.Iterable.String())
+	out.WriteString(") ")
+	out.WriteString(fs.Body.String())
+
+	return out.String()
+}
@@ -19,6 +19,18 @@ func (ie *IfExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IfExpression) Pos() token.Position {
+	return ie.Token.Pos
+}
+
+func (ie *IfExpression) End() token.Position {
+	if ie.Alternative != nil {
+		return ie.Alternative.End()
+	}
+
+	return ie.Consequence.End()
+}
+
 func (ie *IfExpression) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,40 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// ImportStatement runs the module found at the path, binding the constant name to it.
+type ImportStatement struct {
+	Token token.Token // The 'token.IMPORT' token.
+	Path  *StringLiteral
+	Name  *Identifier
+}
+
+func (is *ImportStatement) statementNode() {}
+
+func (is *ImportStatement) TokenLiteral() string {
+	return is.Token.Literal
+}
+
+func (is *ImportStatement) Pos() token.Position {
+	return is.Token.Pos
+}
+
+func (is *ImportStatement) End() token.Position {
+	return is.Name.End()
+}
+
+func (is *ImportStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(is.TokenLiteral() + " ")
+	out.WriteString(`"` + is.Path.Value + `"`)
+	out.WriteString(" as ")
+	out.WriteString(is.Name.String())
+	out.WriteString(";")
+
+	return out.String()
+}
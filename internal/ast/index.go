@@ -7,9 +7,10 @@ import (
 )
 
 type IndexExpression struct {
-	Token token.Token // The 'token.LBRACKET' token.
-	Left  Expression
-	Index Expression
+	Token    token.Token // The 'token.LBRACKET' token.
+	Left     Expression
+	Index    Expression
+	Rbracket token.Token // The closing 'token.RBRACKET' token.
 }
 
 func (ie *IndexExpression) expressionNode() {}
@@ -18,6 +19,14 @@ func (ie *IndexExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *IndexExpression) Pos() token.Position {
+	return ie.Left.Pos()
+}
+
+func (ie *IndexExpression) End() token.Position {
+	return ie.Rbracket.End
+}
+
 func (ie *IndexExpression) String() string {
 	var out bytes.Buffer
 
@@ -19,6 +19,14 @@ func (ie *InfixExpression) TokenLiteral() string {
 	return ie.Token.Literal
 }
 
+func (ie *InfixExpression) Pos() token.Position {
+	return ie.Left.Pos()
+}
+
+func (ie *InfixExpression) End() token.Position {
+	return ie.Right.End()
+}
+
 func (ie *InfixExpression) String() string {
 	var out bytes.Buffer
 
@@ -15,6 +15,14 @@ func (il *IntegerLiteral) TokenLiteral() string {
 	return il.Token.Literal
 }
 
+func (il *IntegerLiteral) Pos() token.Position {
+	return il.Token.Pos
+}
+
+func (il *IntegerLiteral) End() token.Position {
+	return il.Token.End
+}
+
 func (il *IntegerLiteral) String() string {
 	return il.Token.Literal
 }
@@ -0,0 +1,47 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+type LetStatement struct {
+	Token token.Token // The 'token.LET' token.
+	Name  *Identifier
+	Value Expression
+}
+
+func (ls *LetStatement) statementNode() {}
+
+func (ls *LetStatement) TokenLiteral() string {
+	return ls.Token.Literal
+}
+
+func (ls *LetStatement) Pos() token.Position {
+	return ls.Token.Pos
+}
+
+func (ls *LetStatement) End() token.Position {
+	if ls.Value != nil {
+		return ls.Value.End()
+	}
+
+	return ls.Name.End()
+}
+
+func (ls *LetStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ls.TokenLiteral() + " ")
+	out.WriteString(ls.Name.String())
+	out.WriteString(" = ")
+
+	if ls.Value != nil {
+		out.WriteString(ls.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
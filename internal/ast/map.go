@@ -9,8 +9,9 @@ import (
 )
 
 type MapLiteral struct {
-	Token token.Token // The 'token.LBRACE' token.
-	Pairs map[Expression]Expression
+	Token  token.Token // The 'token.LBRACE' token.
+	Pairs  map[Expression]Expression
+	Rbrace token.Token // The closing 'token.RBRACE' token.
 }
 
 func (ml *MapLiteral) expressionNode() {}
@@ -19,6 +20,14 @@ func (ml *MapLiteral) TokenLiteral() string {
 	return ml.Token.Literal
 }
 
+func (ml *MapLiteral) Pos() token.Position {
+	return ml.Token.Pos
+}
+
+func (ml *MapLiteral) End() token.Position {
+	return ml.Rbrace.End
+}
+
 func (ml *MapLiteral) String() string {
 	var out bytes.Buffer
 
@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// MemberExpression reads a member by its name, e.g. 'util.map'. It's a shorthand for 'util["map"]'.
+type MemberExpression struct {
+	Token    token.Token // The 'token.DOT' token.
+	Object   Expression
+	Property *Identifier
+}
+
+func (me *MemberExpression) expressionNode() {}
+
+func (me *MemberExpression) TokenLiteral() string {
+	return me.Token.Literal
+}
+
+func (me *MemberExpression) Pos() token.Position {
+	return me.Object.Pos()
+}
+
+func (me *MemberExpression) End() token.Position {
+	return me.Property.End()
+}
+
+func (me *MemberExpression) String() string {
+	return me.Object.String() + "." + me.Property.String()
+}
+
+// IndexExpression returns the index expression the member expression is a shorthand for.
+func (me *MemberExpression) IndexExpression() *IndexExpression {
+	return &IndexExpression{
+		Token: me.Token,
+		Left:  me.Object,
+		Index: &StringLiteral{Token: me.Property.Token, Value: me.Property.Value},
+		Rbracket: token.Token{
+			Type: token.RBRACKET,
+			Pos:  me.Property.Token.End,
+			End:  me.Property.Token.End,
+		},
+	}
+}
@@ -18,6 +18,14 @@ func (pe *PrefixExpression) TokenLiteral() string {
 	return pe.Token.Literal
 }
 
+func (pe *PrefixExpression) Pos() token.Position {
+	return pe.Token.Pos
+}
+
+func (pe *PrefixExpression) End() token.Position {
+	return pe.Right.End()
+}
+
 func (pe *PrefixExpression) String() string {
 	var out bytes.Buffer
 
@@ -15,6 +15,14 @@ func (sl *StringLiteral) TokenLiteral() string {
 	return sl.Token.Literal
 }
 
+func (sl *StringLiteral) Pos() token.Position {
+	return sl.Token.Pos
+}
+
+func (sl *StringLiteral) End() token.Position {
+	return sl.Token.End
+}
+
 func (sl *StringLiteral) String() string {
 	return sl.Token.Literal
 }
@@ -0,0 +1,45 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// TemplateLiteral is a string with interpolated expressions, like "hello ${name}".
+type TemplateLiteral struct {
+	Token token.Token // The 'token.TEMPLATE_START' token.
+	// Parts holds the pieces of the string, in order: the text as *StringLiteral, and the interpolated expressions.
+	Parts []Expression
+	Close token.Token // The closing 'token.TEMPLATE_END' token.
+}
+
+func (tl *TemplateLiteral) expressionNode() {}
+
+func (tl *TemplateLiteral) TokenLiteral() string {
+	return tl.Token.Literal
+}
+
+func (tl *TemplateLiteral) Pos() token.Position {
+	return tl.Token.Pos
+}
+
+func (tl *TemplateLiteral) End() token.Position {
+	return tl.Close.End
+}
+
+func (tl *TemplateLiteral) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(`"`)
+	for _, part := range tl.Parts {
+		if text, ok := part.(*StringLiteral); ok {
+			out.WriteString(text.Value)
+		} else {
+			out.WriteString("${" + part.String() + "}")
+		}
+	}
+	out.WriteString(`"`)
+
+	return out.String()
+}
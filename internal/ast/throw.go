@@ -0,0 +1,44 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+type ThrowStatement struct {
+	Token token.Token // The 'token.THROW' token.
+	Value Expression
+}
+
+func (ts *ThrowStatement) statementNode() {}
+
+func (ts *ThrowStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *ThrowStatement) Pos() token.Position {
+	return ts.Token.Pos
+}
+
+func (ts *ThrowStatement) End() token.Position {
+	if ts.Value != nil {
+		return ts.Value.End()
+	}
+
+	return ts.Token.End
+}
+
+func (ts *ThrowStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString(ts.TokenLiteral() + " ")
+
+	if ts.Value != nil {
+		out.WriteString(ts.Value.String())
+	}
+
+	out.WriteString(";")
+
+	return out.String()
+}
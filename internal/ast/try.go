@@ -0,0 +1,57 @@
+package ast
+
+import (
+	"bytes"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// TryStatement runs a block, handing the error it raises, if any, over to the catch block.
+// The finally block runs afterwards in any case. Either the catch or the finally block can be missing.
+type TryStatement struct {
+	Token token.Token // The 'token.TRY' token.
+	Block *BlockStatement
+	// Param is the name the caught error is bound to.
+	Param   *Identifier
+	Catch   *BlockStatement
+	Finally *BlockStatement
+}
+
+func (ts *TryStatement) statementNode() {}
+
+func (ts *TryStatement) TokenLiteral() string {
+	return ts.Token.Literal
+}
+
+func (ts *TryStatement) Pos() token.Position {
+	return ts.Token.Pos
+}
+
+func (ts *TryStatement) End() token.Position {
+	if ts.Finally != nil {
+		return ts.Finally.End()
+	}
+
+	return ts.Catch.End()
+}
+
+func (ts *TryStatement) String() string {
+	var out bytes.Buffer
+
+	out.WriteString("try ")
+	out.WriteString(ts.Block.String())
+
+	if ts.Catch != nil {
+		out.WriteString(" catch (")
+		out.WriteString(ts.Param.String())
+		out.WriteString(") ")
+		out.WriteString(ts.Catch.String())
+	}
+
+	if ts.Finally != nil {
+		out.WriteString(" finally ")
+		out.WriteString(ts.Finally.String())
+	}
+
+	return out.String()
+}
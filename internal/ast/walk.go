@@ -0,0 +1,176 @@
+package ast
+
+import (
+	"sort"
+)
+
+// Inspect traverses the AST in depth-first order, starting with the given node.
+// It calls f for each node, and if f returns true, it carries on with the node's children.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+
+	for _, child := range children(node) {
+		Inspect(child, f)
+	}
+}
+
+func children(node Node) []Node {
+	var nodes []Node
+
+	// Typed nil pointers, like a missing else block, have to be checked by the caller.
+	add := func(n Node) {
+		if n != nil {
+			nodes = append(nodes, n)
+		}
+	}
+
+	switch node := node.(type) {
+	case *Program:
+		for _, s := range node.Statements {
+			add(s)
+		}
+
+	case *BlockStatement:
+		for _, s := range node.Statements {
+			add(s)
+		}
+
+	case *ExpressionStatement:
+		add(node.Expression)
+
+	case *ConstStatement:
+		add(node.Name)
+		add(node.Value)
+
+	case *LetStatement:
+		add(node.Name)
+		add(node.Value)
+
+	case *AssignExpression:
+		add(node.Target)
+		add(node.Value)
+
+	case *ReturnStatement:
+		add(node.ReturnValue)
+
+	case *WhileStatement:
+		add(node.Condition)
+		if node.Body != nil {
+			add(node.Body)
+		}
+
+	case *TryStatement:
+		add(node.Block)
+		if node.Param != nil {
+			add(node.Param)
+		}
+		if node.Catch != nil {
+			add(node.Catch)
+		}
+		if node.Finally != nil {
+			add(node.Finally)
+		}
+
+	case *ThrowStatement:
+		if node.Value != nil {
+			add(node.Value)
+		}
+
+	case *ImportStatement:
+		add(node.Path)
+		add(node.Name)
+
+	case *ExportStatement:
+		add(node.Declaration)
+
+	case *ForStatement:
+		if node.Key != nil {
+			add(node.Key)
+		}
+		if node.Value != nil {
+			add(node.Value)
+		}
+		add(node.Iterable)
+		if node.Body != nil {
+			add(node.Body)
+		}
+
+	case *PrefixExpression:
+		add(node.Right)
+
+	case *InfixExpression:
+		add(node.Left)
+		add(node.Right)
+
+	case *IfExpression:
+		add(node.Condition)
+		if node.Consequence != nil {
+			add(node.Consequence)
+		}
+		if node.Alternative != nil {
+			add(node.Alternative)
+		}
+
+	case *FunctionLiteral:
+		for i, p := range node.Parameters {
+			add(p)
+			if i < len(node.Defaults) && node.Defaults[i] != nil {
+				add(node.Defaults[i])
+			}
+		}
+		if node.Rest != nil {
+			add(node.Rest)
+		}
+		if node.Body != nil {
+			add(node.Body)
+		}
+
+	case *TemplateLiteral:
+		for _, part := range node.Parts {
+			add(part)
+		}
+
+	case *CallExpression:
+		add(node.Function)
+		for _, a := range node.Arguments {
+			add(a)
+		}
+
+	case *ArrayLiteral:
+		for _, e := range node.Elements {
+			add(e)
+		}
+
+	case *IndexExpression:
+		add(node.Left)
+		add(node.Index)
+
+	case *MemberExpression:
+		add(node.Object)
+		add(node.Property)
+
+	case *MapLiteral:
+		for _, key := range node.SortedKeys() {
+			add(key)
+			add(node.Pairs[key])
+		}
+	}
+
+	return nodes
+}
+
+// SortedKeys returns the keys of the map literal, in the order they appear in the source code.
+func (ml *MapLiteral) SortedKeys() []Expression {
+	keys := make([]Expression, 0, len(ml.Pairs))
+	for key := range ml.Pairs {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Pos().Offset < keys[j].Pos().Offset
+	})
+
+	return keys
+}
@@ -0,0 +1,274 @@
+package code
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// Instructions is the bytecode produced by the compiler and executed by the virtual machine.
+// Each instruction is made of a one byte opcode, followed by its operands.
+type Instructions []byte
+
+func (ins Instructions) String() string {
+	var out bytes.Buffer
+
+	i := 0
+	for i < len(ins) {
+		def, err := Lookup(ins[i])
+		if err != nil {
+			fmt.Fprintf(&out, "ERROR: %s\n", err)
+
+			i++
+			continue
+		}
+
+		operands, read := ReadOperands(def, ins[i+1:])
+
+		fmt.Fprintf(&out, "%04d %s\n", i, ins.fmtInstruction(def, operands))
+
+		i += 1 + read
+	}
+
+	return out.String()
+}
+
+func (ins Instructions) fmtInstruction(def *Definition, operands []int) string {
+	operandCount := len(def.OperandWidths)
+
+	if len(operands) != operandCount {
+		return fmt.Sprintf("ERROR: operand len %d does not match defined %d\n", len(operands), operandCount)
+	}
+
+	switch operandCount {
+	case 0:
+		return def.Name
+	case 1:
+		return fmt.Sprintf("%s %d", def.Name, operands[0])
+	case 2:
+		return fmt.Sprintf("%s %d %d", def.Name, operands[0], operands[1])
+	}
+
+	return fmt.Sprintf("ERROR: unhandled operandCount for %s\n", def.Name)
+}
+
+type Opcode byte
+
+const (
+	OpConstant Opcode = iota
+	OpPop
+
+	OpAdd
+	OpSub
+	OpMul
+	OpDiv
+	OpMod
+
+	OpTrue
+	OpFalse
+	OpNull
+
+	OpEqual
+	OpNotEqual
+	OpGreaterThan
+	OpLessThan
+
+	OpMinus
+	OpBang
+
+	OpJumpNotTruthy
+	OpJump
+	OpJumpIfSet
+
+	OpGetGlobal
+	OpSetGlobal
+	OpDefineGlobal
+	OpGetLocal
+	OpSetLocal
+	OpDefineLocal
+	OpGetOuter
+	OpSetOuter
+	OpGetBuiltin
+
+	OpArray
+	OpMap
+	OpConcat
+	OpIndex
+	OpSetIndex
+	OpDup2
+
+	OpIter
+	OpIterNext
+	OpTry
+	OpEndTry
+	OpCatch
+	OpThrow
+	OpImport
+
+	OpClosure
+	OpCall
+	OpReturnValue
+	OpReturn
+)
+
+// Definition describes an opcode, for debugging purposes and for decoding its operands.
+type Definition struct {
+	Name string
+	// OperandWidths holds the number of bytes each operand takes up.
+	OperandWidths []int
+}
+
+var definitions = map[Opcode]*Definition{
+	// The operand is the index of the constant in the constant pool.
+	OpConstant: {"OpConstant", []int{2}},
+	OpPop:      {"OpPop", []int{}},
+
+	OpAdd: {"OpAdd", []int{}},
+	OpSub: {"OpSub", []int{}},
+	OpMul: {"OpMul", []int{}},
+	OpDiv: {"OpDiv", []int{}},
+	OpMod: {"OpMod", []int{}},
+
+	OpTrue:  {"OpTrue", []int{}},
+	OpFalse: {"OpFalse", []int{}},
+	OpNull:  {"OpNull", []int{}},
+
+	OpEqual:       {"OpEqual", []int{}},
+	OpNotEqual:    {"OpNotEqual", []int{}},
+	OpGreaterThan: {"OpGreaterThan", []int{}},
+	OpLessThan:    {"OpLessThan", []int{}},
+
+	OpMinus: {"OpMinus", []int{}},
+	OpBang:  {"OpBang", []int{}},
+
+	// The operand is the absolute offset of the instruction to jump to.
+	OpJumpNotTruthy: {"OpJumpNotTruthy", []int{2}},
+	OpJump:          {"OpJump", []int{2}},
+	// OpJumpIfSet skips the default value of a parameter when an argument was passed for it.
+	// The operands are the offset to jump to and the index of the local binding.
+	OpJumpIfSet: {"OpJumpIfSet", []int{2, 2}},
+
+	// The operand is the index of the binding. Setting a binding assigns to it,
+	// which fails if it isn't declared yet or if it's a constant.
+	OpGetGlobal: {"OpGetGlobal", []int{2}},
+	OpSetGlobal: {"OpSetGlobal", []int{2}},
+	OpGetLocal:  {"OpGetLocal", []int{2}},
+	OpSetLocal:  {"OpSetLocal", []int{2}},
+	// OpDefineGlobal and OpDefineLocal declare a binding, which fails if it's a constant declared elsewhere.
+	// The operands are the index of the binding and 1 if it's declared as a constant, 0 otherwise.
+	OpDefineGlobal: {"OpDefineGlobal", []int{2, 1}},
+	OpDefineLocal:  {"OpDefineLocal", []int{2, 1}},
+	// The operands are the number of enclosing functions to walk up and the index of the binding.
+	OpGetOuter: {"OpGetOuter", []int{1, 2}},
+	OpSetOuter: {"OpSetOuter", []int{1, 2}},
+	// The operand is the index of the builtin function.
+	OpGetBuiltin: {"OpGetBuiltin", []int{1}},
+
+	// The operand is the number of elements (for maps, both keys and values) on the stack.
+	OpArray: {"OpArray", []int{2}},
+	OpMap:   {"OpMap", []int{2}},
+	// OpConcat joins the values on the stack in a string, for the interpolated strings.
+	// The operand is the number of values.
+	OpConcat: {"OpConcat", []int{2}},
+	OpIndex:  {"OpIndex", []int{}},
+	// OpSetIndex sets a member of an array or a map, leaving the new value on the stack.
+	OpSetIndex: {"OpSetIndex", []int{}},
+	// OpDup2 duplicates the two values on top of the stack, e.g. a collection and an index.
+	OpDup2: {"OpDup2", []int{}},
+
+	// OpIter replaces the collection on the stack with an iterator over it.
+	OpIter: {"OpIter", []int{}},
+	// OpIterNext pushes the next members of the iterator on the stack, or jumps once it's done.
+	// The operands are the offset to jump to and the number of loop variables.
+	OpIterNext: {"OpIterNext", []int{2, 1}},
+
+	// OpTry installs an error handler, until the matching OpEndTry removes it. When an error is raised,
+	// the stack is unwound to where it was at OpTry and the error is pushed on it.
+	// The operand is the offset the handler jumps to.
+	OpTry:    {"OpTry", []int{2}},
+	OpEndTry: {"OpEndTry", []int{}},
+	// OpCatch replaces the error on the stack with the map the catch block gets.
+	OpCatch: {"OpCatch", []int{}},
+	// OpThrow raises the value on the stack, or raises the error on the stack again.
+	OpThrow: {"OpThrow", []int{}},
+	// OpImport pushes the module found at a path. The operand is the index of the path in the constant pool.
+	OpImport: {"OpImport", []int{2}},
+
+	// The operand is the index of the compiled function in the constant pool.
+	OpClosure: {"OpClosure", []int{2}},
+	// The operand is the number of arguments on the stack.
+	OpCall:        {"OpCall", []int{2}},
+	OpReturnValue: {"OpReturnValue", []int{}},
+	OpReturn:      {"OpReturn", []int{}},
+}
+
+func Lookup(op byte) (*Definition, error) {
+	def, ok := definitions[Opcode(op)]
+	if !ok {
+		return nil, fmt.Errorf("opcode %d undefined", op)
+	}
+
+	return def, nil
+}
+
+// Fits reports whether the operand can be encoded in the given number of bytes.
+func Fits(operand, width int) bool {
+	return operand >= 0 && operand < 1<<(8*uint(width))
+}
+
+// Make encodes an instruction with its operands. The operands have to fit their widths, see Fits.
+func Make(op Opcode, operands ...int) []byte {
+	def, ok := definitions[op]
+	if !ok {
+		return []byte{}
+	}
+
+	instructionLen := 1
+	for _, w := range def.OperandWidths {
+		instructionLen += w
+	}
+
+	instruction := make([]byte, instructionLen)
+	instruction[0] = byte(op)
+
+	offset := 1
+	for i, o := range operands {
+		width := def.OperandWidths[i]
+		switch width {
+		case 2:
+			binary.BigEndian.PutUint16(instruction[offset:], uint16(o))
+		case 1:
+			instruction[offset] = byte(o)
+		}
+		offset += width
+	}
+
+	return instruction
+}
+
+// ReadOperands decodes the operands of an instruction, returning them along with the number of bytes read.
+func ReadOperands(def *Definition, ins Instructions) ([]int, int) {
+	operands := make([]int, len(def.OperandWidths))
+	offset := 0
+
+	for i, width := range def.OperandWidths {
+		switch width {
+		case 2:
+			operands[i] = int(ReadUint16(ins[offset:]))
+		case 1:
+			operands[i] = int(ReadUint8(ins[offset:]))
+		}
+
+		offset += width
+	}
+
+	return operands, offset
+}
+
+func ReadUint16(ins Instructions) uint16 {
+	return binary.BigEndian.Uint16(ins)
+}
+
+func ReadUint8(ins Instructions) uint8 {
+	return uint8(ins[0])
+}
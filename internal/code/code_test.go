@@ -0,0 +1,115 @@
+package code
+
+import (
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+func TestMake(t *testing.T) {
+	testCases := []struct {
+		op       Opcode
+		operands []int
+		expected []byte
+	}{
+		{OpConstant, []int{65534}, []byte{byte(OpConstant), 255, 254}},
+		{OpAdd, []int{}, []byte{byte(OpAdd)}},
+		{OpGetOuter, []int{2, 258}, []byte{byte(OpGetOuter), 2, 1, 2}},
+	}
+
+	for _, tc := range testCases {
+		instruction := Make(tc.op, tc.operands...)
+
+		if len(instruction) != len(tc.expected) {
+			t.Errorf("instruction has wrong length. want=%d, got=%d", len(tc.expected), len(instruction))
+		}
+
+		for i, b := range tc.expected {
+			if instruction[i] != tc.expected[i] {
+				t.Errorf("wrong byte at pos %d. want=%d, got=%d", i, b, instruction[i])
+			}
+		}
+	}
+}
+
+func TestInstructionsString(t *testing.T) {
+	instructions := []Instructions{
+		Make(OpAdd),
+		Make(OpGetLocal, 1),
+		Make(OpConstant, 2),
+		Make(OpConstant, 65535),
+		Make(OpGetOuter, 1, 3),
+	}
+
+	expected := `0000 OpAdd
+0001 OpGetLocal 1
+0004 OpConstant 2
+0007 OpConstant 65535
+0010 OpGetOuter 1 3
+`
+
+	concatted := Instructions{}
+	for _, ins := range instructions {
+		concatted = append(concatted, ins...)
+	}
+
+	if concatted.String() != expected {
+		t.Errorf("instructions wrongly formatted.\nwant=%q\ngot=%q", expected, concatted.String())
+	}
+}
+
+func TestReadOperands(t *testing.T) {
+	testCases := []struct {
+		op        Opcode
+		operands  []int
+		bytesRead int
+	}{
+		{OpConstant, []int{65535}, 2},
+		{OpGetBuiltin, []int{255}, 1},
+		{OpGetOuter, []int{255, 65535}, 3},
+	}
+
+	for _, tc := range testCases {
+		instruction := Make(tc.op, tc.operands...)
+
+		def, err := Lookup(byte(tc.op))
+		if err != nil {
+			t.Fatalf("definition not found: %q\n", err)
+		}
+
+		operandsRead, n := ReadOperands(def, instruction[1:])
+		if n != tc.bytesRead {
+			t.Fatalf("n wrong. want=%d, got=%d", tc.bytesRead, n)
+		}
+
+		for i, want := range tc.operands {
+			if operandsRead[i] != want {
+				t.Errorf("operand wrong. want=%d, got=%d", want, operandsRead[i])
+			}
+		}
+	}
+}
+
+func TestSourceMapLookup(t *testing.T) {
+	sm := SourceMap{
+		{Offset: 0, Pos: token.Position{Line: 1, Column: 1}},
+		{Offset: 3, Pos: token.Position{Line: 1, Column: 5}},
+		{Offset: 4, Pos: token.Position{Line: 2, Column: 1}},
+	}
+
+	testCases := []struct {
+		offset   int
+		expected string
+	}{
+		{0, "1:1"},
+		{2, "1:1"},
+		{3, "1:5"},
+		{10, "2:1"},
+	}
+
+	for _, tc := range testCases {
+		if pos := sm.Lookup(tc.offset); pos.String() != tc.expected {
+			t.Errorf("wrong position for offset %d. want=%s, got=%s", tc.offset, tc.expected, pos)
+		}
+	}
+}
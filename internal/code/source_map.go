@@ -0,0 +1,29 @@
+package code
+
+import (
+	"sort"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// SourceMapping ties the instruction starting at Offset to the position of the node it was compiled from.
+type SourceMapping struct {
+	Offset int
+	Pos    token.Position
+}
+
+// SourceMap holds the source mappings of a sequence of instructions, ordered by offset.
+type SourceMap []SourceMapping
+
+// Lookup returns the position of the instruction that contains the given offset.
+func (sm SourceMap) Lookup(offset int) token.Position {
+	i := sort.Search(len(sm), func(i int) bool {
+		return sm[i].Offset > offset
+	})
+
+	if i == 0 {
+		return token.Position{}
+	}
+
+	return sm[i-1].Pos
+}
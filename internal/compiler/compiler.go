@@ -0,0 +1,984 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/code"
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// The compiler lowers the AST of a program to bytecode, which can then be executed by the virtual machine
+// a lot faster than walking the tree over and over again.
+
+type Compiler struct {
+	constants   []object.Object
+	symbolTable *SymbolTable
+
+	scopes     []CompilationScope
+	scopeIndex int
+
+	// sourcePos is the position of the node being compiled, which is attached to every emitted instruction.
+	sourcePos token.Position
+
+	// iterators counts the hidden bindings holding the iterators of the loops.
+	iterators int
+
+	// err is the first operand found too big for the instruction it was emitted with.
+	// Compile returns it, since the instructions holding it would be wrong.
+	err error
+}
+
+// CompilationScope holds the instructions of the function being compiled.
+type CompilationScope struct {
+	instructions        code.Instructions
+	sourceMap           code.SourceMap
+	declarations        map[int]ast.Node
+	lastInstruction     EmittedInstruction
+	previousInstruction EmittedInstruction
+
+	// loops holds the loops around the code being compiled, innermost last.
+	loops []*Loop
+	// tries holds the try statements around the code being compiled, innermost last.
+	tries []*Try
+}
+
+// Loop holds what break and continue statements need to jump out of a loop.
+type Loop struct {
+	// start is the offset continue statements jump to.
+	start int
+	// breaks holds the positions of the jumps emitted by break statements, to be fixed once the loop ends.
+	breaks []int
+}
+
+// Try holds what has to be done when the code in a try statement is left early,
+// e.g. by a return statement.
+type Try struct {
+	// handler tells whether the error handler of the statement is installed.
+	handler bool
+	// pendingError tells whether an error waits on the stack to be raised again, after the finally block.
+	pendingError bool
+	// finally is the block that has to run before leaving the statement, if any.
+	finally *ast.BlockStatement
+	// loops is the number of loops around the statement.
+	loops int
+}
+
+type EmittedInstruction struct {
+	Opcode   code.Opcode
+	Position int
+}
+
+func New() *Compiler {
+	mainScope := CompilationScope{
+		instructions: code.Instructions{},
+	}
+
+	c := &Compiler{
+		constants:   []object.Object{},
+		symbolTable: NewSymbolTable(),
+		scopes:      []CompilationScope{mainScope},
+		scopeIndex:  0,
+	}
+
+	return c
+}
+
+// NewWithState creates a compiler that carries on with the bindings and constants of a previous compilation,
+// so a REPL session can refer to them.
+func NewWithState(s *SymbolTable, constants []object.Object) *Compiler {
+	c := New()
+	c.symbolTable = s
+	c.constants = constants
+
+	return c
+}
+
+// Compile adds the bytecode of the node to the one compiled so far. The errors it returns are *diag.Diagnostic
+// values, pointing at the code they're about.
+func (c *Compiler) Compile(node ast.Node) (err error) {
+	prevPos := c.sourcePos
+	c.sourcePos = node.Pos()
+	defer func() {
+		c.sourcePos = prevPos
+		if err == nil {
+			err = c.err
+		}
+	}()
+
+	switch node := node.(type) {
+	case *ast.Program:
+		c.hoistDeclarations(node)
+
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+
+		// Programs ending with a statement, like a loop, evaluate to nothing.
+		if n := len(node.Statements); n > 0 && !c.lastInstructionIs(code.OpReturnValue) {
+			if _, ok := node.Statements[n-1].(*ast.ExpressionStatement); !ok {
+				c.emit(code.OpReturn)
+			}
+		}
+
+	case *ast.ExpressionStatement:
+		// Programs with syntax errors may hold empty statements, which evaluate to nothing.
+		if node.Expression == nil {
+			return nil
+		}
+
+		if err := c.Compile(node.Expression); err != nil {
+			return err
+		}
+		c.emit(code.OpPop)
+
+	case *ast.BlockStatement:
+		for _, s := range node.Statements {
+			if err := c.Compile(s); err != nil {
+				return err
+			}
+		}
+
+	case *ast.ConstStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		c.defineSymbol(c.symbolTable.Define(node.Name.Value), node)
+
+	case *ast.LetStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		c.defineSymbol(c.symbolTable.Define(node.Name.Value), nil)
+
+	case *ast.ImportStatement:
+		c.emit(code.OpImport, c.addConstant(&object.String{Value: node.Path.Value}))
+		c.defineSymbol(c.symbolTable.Define(node.Name.Value), node)
+
+	case *ast.ExportStatement:
+		return c.Compile(node.Declaration)
+
+	case *ast.AssignExpression:
+		return c.compileAssignment(node)
+
+	case *ast.ReturnStatement:
+		if err := c.Compile(node.ReturnValue); err != nil {
+			return err
+		}
+		if err := c.leaveTries(0, true); err != nil {
+			return err
+		}
+		c.emit(code.OpReturnValue)
+
+	case *ast.TryStatement:
+		return c.compileTry(node)
+
+	case *ast.ThrowStatement:
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+		c.emit(code.OpThrow)
+
+	case *ast.WhileStatement:
+		loop := c.enterLoop()
+
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+
+		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+		if err := c.Compile(node.Body); err != nil {
+			return err
+		}
+
+		c.emit(code.OpJump, loop.start)
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+		c.leaveLoop()
+
+	case *ast.ForStatement:
+		if err := c.Compile(node.Iterable); err != nil {
+			return err
+		}
+
+		// The iterator is kept in a binding no identifier can refer to.
+		iterator := c.symbolTable.Define(fmt.Sprintf("#iterator%d", c.iterators))
+		c.iterators++
+
+		c.emit(code.OpIter)
+		c.defineSymbol(iterator, nil)
+
+		loop := c.enterLoop()
+		c.loadSymbol(iterator)
+
+		numVars := 1
+		if node.Key != nil {
+			numVars = 2
+		}
+		iterNextPos := c.emit(code.OpIterNext, 9999, numVars)
+
+		// The value is on top of the key.
+		c.defineSymbol(c.symbolTable.Define(node.Value.Value), nil)
+		if node.Key != nil {
+			c.defineSymbol(c.symbolTable.Define(node.Key.Value), nil)
+		}
+
+		if err := c.Compile(node.Body); err != nil {
+			return err
+		}
+
+		c.emit(code.OpJump, loop.start)
+		c.changeOperand(iterNextPos, len(c.currentInstructions()), numVars)
+		c.leaveLoop()
+
+	case *ast.BreakStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return errorf("misplaced-statement", node.Pos(), "break is not in a loop")
+		}
+
+		if err := c.leaveTries(len(c.scopes[c.scopeIndex].loops), false); err != nil {
+			return err
+		}
+
+		loop.breaks = append(loop.breaks, c.emit(code.OpJump, 9999))
+
+	case *ast.ContinueStatement:
+		loop := c.currentLoop()
+		if loop == nil {
+			return errorf("misplaced-statement", node.Pos(), "continue is not in a loop")
+		}
+
+		if err := c.leaveTries(len(c.scopes[c.scopeIndex].loops), false); err != nil {
+			return err
+		}
+
+		c.emit(code.OpJump, loop.start)
+
+	case *ast.Identifier:
+		c.loadSymbol(c.resolve(node.Value))
+
+	case *ast.IntegerLiteral:
+		integer := &object.Integer{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(integer))
+
+	case *ast.FloatLiteral:
+		float := &object.Float{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(float))
+
+	case *ast.StringLiteral:
+		str := &object.String{Value: node.Value}
+		c.emit(code.OpConstant, c.addConstant(str))
+
+	case *ast.TemplateLiteral:
+		for _, part := range node.Parts {
+			if err := c.Compile(part); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpConcat, len(node.Parts))
+
+	case *ast.Boolean:
+		if node.Value {
+			c.emit(code.OpTrue)
+		} else {
+			c.emit(code.OpFalse)
+		}
+
+	case *ast.PrefixExpression:
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		switch node.Operator {
+		case "!":
+			c.emit(code.OpBang)
+		case "-":
+			c.emit(code.OpMinus)
+		default:
+			return errorf("unknown-operator", node.Pos(), "unknown operator %s", node.Operator)
+		}
+
+	case *ast.InfixExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+
+		if err := c.Compile(node.Right); err != nil {
+			return err
+		}
+
+		op, ok := infixOperators[node.Operator]
+		if !ok {
+			return errorf("unknown-operator", node.Pos(), "unknown operator %s", node.Operator)
+		}
+		c.emit(op)
+
+	case *ast.IfExpression:
+		if err := c.Compile(node.Condition); err != nil {
+			return err
+		}
+
+		// Emit the jumps with a bogus offset, and fix it once we know where to jump to.
+		jumpNotTruthyPos := c.emit(code.OpJumpNotTruthy, 9999)
+
+		if err := c.compileBlockValue(node.Consequence); err != nil {
+			return err
+		}
+
+		jumpPos := c.emit(code.OpJump, 9999)
+		c.changeOperand(jumpNotTruthyPos, len(c.currentInstructions()))
+
+		if node.Alternative == nil {
+			c.emit(code.OpNull)
+		} else if err := c.compileBlockValue(node.Alternative); err != nil {
+			return err
+		}
+
+		c.changeOperand(jumpPos, len(c.currentInstructions()))
+
+	case *ast.FunctionLiteral:
+		c.enterScope()
+
+		// The default values are only in the scope of the parameters before them.
+		for i, p := range node.Parameters {
+			symbol := c.symbolTable.Define(p.Value)
+
+			if i < len(node.Defaults) && node.Defaults[i] != nil {
+				jumpPos := c.emit(code.OpJumpIfSet, 9999, symbol.Index)
+				if err := c.Compile(node.Defaults[i]); err != nil {
+					return err
+				}
+				c.defineSymbol(symbol, nil)
+				c.changeOperand(jumpPos, len(c.currentInstructions()), symbol.Index)
+			}
+		}
+		if node.Rest != nil {
+			c.symbolTable.Define(node.Rest.Value)
+		}
+
+		c.hoistDeclarations(node.Body)
+
+		if err := c.Compile(node.Body); err != nil {
+			return err
+		}
+
+		// The value of the last expression is returned implicitly.
+		if endsWithExpression(node.Body) && c.lastInstructionIs(code.OpPop) {
+			c.replaceLastPopWithReturn()
+		}
+		if !c.lastInstructionIs(code.OpReturnValue) {
+			c.emit(code.OpReturn)
+		}
+
+		localNames := c.symbolTable.Names()
+		scope := c.leaveScope()
+
+		compiledFn := &object.CompiledFunction{
+			Name:         node.Name,
+			Parameters:   node.Parameters,
+			Defaults:     node.Defaults,
+			Rest:         node.Rest,
+			Body:         node.Body,
+			Instructions: scope.instructions,
+			SourceMap:    scope.sourceMap,
+			Declarations: scope.declarations,
+			LocalNames:   localNames,
+		}
+		c.emit(code.OpClosure, c.addConstant(compiledFn))
+
+	case *ast.CallExpression:
+		if err := c.Compile(node.Function); err != nil {
+			return err
+		}
+
+		for _, a := range node.Arguments {
+			if err := c.Compile(a); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpCall, len(node.Arguments))
+
+	case *ast.ArrayLiteral:
+		for _, el := range node.Elements {
+			if err := c.Compile(el); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpArray, len(node.Elements))
+
+	case *ast.MapLiteral:
+		keys := node.SortedKeys()
+		for _, k := range keys {
+			if err := c.Compile(k); err != nil {
+				return err
+			}
+
+			if err := c.Compile(node.Pairs[k]); err != nil {
+				return err
+			}
+		}
+
+		c.emit(code.OpMap, len(keys)*2)
+
+	case *ast.IndexExpression:
+		if err := c.Compile(node.Left); err != nil {
+			return err
+		}
+
+		if err := c.Compile(node.Index); err != nil {
+			return err
+		}
+
+		c.emit(code.OpIndex)
+
+	case *ast.MemberExpression:
+		return c.Compile(node.IndexExpression())
+
+	default:
+		return errorf("compiler-error", node.Pos(), "cannot compile %T", node)
+	}
+
+	return nil
+}
+
+var infixOperators = map[string]code.Opcode{
+	"+":  code.OpAdd,
+	"-":  code.OpSub,
+	"*":  code.OpMul,
+	"/":  code.OpDiv,
+	"%":  code.OpMod,
+	"==": code.OpEqual,
+	"!=": code.OpNotEqual,
+	">":  code.OpGreaterThan,
+	"<":  code.OpLessThan,
+}
+
+// compileBlockValue compiles a block whose last expression is used as a value, like the branches of an if.
+func (c *Compiler) compileBlockValue(block *ast.BlockStatement) error {
+	if err := c.Compile(block); err != nil {
+		return err
+	}
+
+	if endsWithExpression(block) && c.lastInstructionIs(code.OpPop) {
+		c.removeLastPop()
+	} else {
+		c.emit(code.OpNull)
+	}
+
+	return nil
+}
+
+// endsWithExpression reports whether the last statement of the block is an expression, which the block evaluates to.
+// Otherwise, the last instruction of the block may still be a pop, e.g. the last one of a catch block.
+func endsWithExpression(block *ast.BlockStatement) bool {
+	if len(block.Statements) == 0 {
+		return false
+	}
+
+	_, ok := block.Statements[len(block.Statements)-1].(*ast.ExpressionStatement)
+
+	return ok
+}
+
+// compileTry lays a try statement out as follows, where the parts in brackets are only there
+// when the statement has a catch or a finally block:
+//
+//	OpTry catch; <try block>; OpEndTry; [<finally block>]; OpJump end
+//	catch: [OpTry rethrow]; OpCatch; <bind the error>; <catch block>; [OpEndTry; <finally block>; OpJump end]
+//	rethrow: [<finally block>; OpThrow]
+//	end:
+//
+// The rethrow part runs the finally block when an error escapes the statement, before raising it again.
+func (c *Compiler) compileTry(node *ast.TryStatement) error {
+	var ends []int
+
+	c.enterTry(&Try{handler: true, finally: node.Finally})
+	handlerPos := c.emit(code.OpTry, 9999)
+	if err := c.Compile(node.Block); err != nil {
+		return err
+	}
+	c.emit(code.OpEndTry)
+	c.leaveTry()
+
+	if err := c.compileFinally(node.Finally); err != nil {
+		return err
+	}
+	ends = append(ends, c.emit(code.OpJump, 9999))
+
+	if node.Catch != nil {
+		c.changeOperand(handlerPos, len(c.currentInstructions()))
+
+		if node.Finally != nil {
+			c.enterTry(&Try{handler: true, finally: node.Finally})
+			handlerPos = c.emit(code.OpTry, 9999)
+		}
+
+		c.emit(code.OpCatch)
+		c.defineSymbol(c.symbolTable.Define(node.Param.Value), nil)
+		if err := c.Compile(node.Catch); err != nil {
+			return err
+		}
+
+		if node.Finally != nil {
+			c.emit(code.OpEndTry)
+			c.leaveTry()
+
+			if err := c.compileFinally(node.Finally); err != nil {
+				return err
+			}
+			ends = append(ends, c.emit(code.OpJump, 9999))
+		}
+	}
+
+	if node.Finally != nil {
+		c.changeOperand(handlerPos, len(c.currentInstructions()))
+
+		c.enterTry(&Try{pendingError: true})
+		if err := c.compileFinally(node.Finally); err != nil {
+			return err
+		}
+		c.leaveTry()
+		c.emit(code.OpThrow)
+	}
+
+	for _, pos := range ends {
+		c.changeOperand(pos, len(c.currentInstructions()))
+	}
+
+	return nil
+}
+
+func (c *Compiler) compileFinally(finally *ast.BlockStatement) error {
+	if finally == nil {
+		return nil
+	}
+
+	return c.Compile(finally)
+}
+
+// leaveTries emits what leaving the try statements around the current code takes, innermost first:
+// removing their error handlers and running their finally blocks. Only the statements within
+// the given number of loops are left. A function returning leaves them all, and it doesn't have to
+// clean the stack up.
+func (c *Compiler) leaveTries(loops int, returning bool) error {
+	tries := c.scopes[c.scopeIndex].tries
+	defer func() {
+		c.scopes[c.scopeIndex].tries = tries
+	}()
+
+	for i := len(tries) - 1; i >= 0 && tries[i].loops >= loops; i-- {
+		t := tries[i]
+
+		if t.handler {
+			c.emit(code.OpEndTry)
+		}
+		if t.pendingError && !returning {
+			c.emit(code.OpPop)
+		}
+
+		// The finally block is outside of the statement it belongs to.
+		c.scopes[c.scopeIndex].tries = tries[:i]
+		if err := c.compileFinally(t.finally); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *Compiler) enterTry(t *Try) {
+	scope := &c.scopes[c.scopeIndex]
+
+	t.loops = len(scope.loops)
+	scope.tries = append(scope.tries, t)
+}
+
+func (c *Compiler) leaveTry() {
+	scope := &c.scopes[c.scopeIndex]
+	scope.tries = scope.tries[:len(scope.tries)-1]
+}
+
+// hoistDeclarations defines every binding declared by a program or a function body up front,
+// so closures can refer to bindings which are declared later on, like the evaluator allows them to.
+// Which ones are constants is only known while running, once their declarations have run.
+func (c *Compiler) hoistDeclarations(node ast.Node) {
+	ast.Inspect(node, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			return false
+
+		case *ast.ConstStatement:
+			c.symbolTable.Define(n.Name.Value)
+
+		case *ast.LetStatement:
+			c.symbolTable.Define(n.Name.Value)
+
+		case *ast.ImportStatement:
+			c.symbolTable.Define(n.Name.Value)
+
+		case *ast.ForStatement:
+			if n.Key != nil {
+				c.symbolTable.Define(n.Key.Value)
+			}
+			c.symbolTable.Define(n.Value.Value)
+
+		case *ast.TryStatement:
+			if n.Param != nil {
+				c.symbolTable.Define(n.Param.Value)
+			}
+		}
+
+		return true
+	})
+}
+
+func (c *Compiler) compileAssignment(node *ast.AssignExpression) error {
+	// Compound assignments, like '+=', apply an operator to the current value and the new one.
+	compound := node.Operator != "="
+	op, ok := infixOperators[strings.TrimSuffix(node.Operator, "=")]
+	if compound && !ok {
+		return errorf("unknown-operator", node.Pos(), "unknown operator %s", node.Operator)
+	}
+
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		// Assigning to a builtin function, or to a binding that isn't declared yet, fails when it runs,
+		// like assigning to a constant does.
+		symbol, ok := c.symbolTable.Resolve(target.Value)
+		if !ok {
+			symbol = c.symbolTable.Global().DefineImplicit(target.Value)
+		}
+
+		if compound {
+			c.loadSymbol(symbol)
+		}
+
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(op)
+		}
+
+		// The assignment evaluates to the new value.
+		c.storeSymbol(symbol)
+		c.loadSymbol(symbol)
+
+	case *ast.IndexExpression:
+		if err := c.Compile(target.Left); err != nil {
+			return err
+		}
+
+		if err := c.Compile(target.Index); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(code.OpDup2)
+			c.emit(code.OpIndex)
+		}
+
+		if err := c.Compile(node.Value); err != nil {
+			return err
+		}
+
+		if compound {
+			c.emit(op)
+		}
+
+		c.emit(code.OpSetIndex)
+
+	case *ast.MemberExpression:
+		return c.compileAssignment(&ast.AssignExpression{
+			Token:    node.Token,
+			Target:   target.IndexExpression(),
+			Operator: node.Operator,
+			Value:    node.Value,
+		})
+
+	default:
+		return errorf("invalid-assignment", node.Pos(), "cannot assign to %s", node.Target.String())
+	}
+
+	return nil
+}
+
+func (c *Compiler) resolve(name string) Symbol {
+	symbol, ok := c.symbolTable.Resolve(name)
+	if ok && !symbol.Implicit {
+		return symbol
+	}
+
+	for i, b := range object.Builtins {
+		if b.Name == name {
+			return Symbol{Name: name, Scope: BuiltinScope, Index: i}
+		}
+	}
+
+	// Unknown names become global bindings that may still be declared later on, e.g. on the next REPL line.
+	// Reading them before that happens is a runtime error.
+	return c.symbolTable.Global().DefineImplicit(name)
+}
+
+func (c *Compiler) loadSymbol(s Symbol) {
+	switch s.Scope {
+	case GlobalScope:
+		c.emit(code.OpGetGlobal, s.Index)
+
+	case LocalScope:
+		if s.Depth == 0 {
+			c.emit(code.OpGetLocal, s.Index)
+		} else {
+			c.emit(code.OpGetOuter, s.Depth, s.Index)
+		}
+
+	case BuiltinScope:
+		c.emit(code.OpGetBuiltin, s.Index)
+	}
+}
+
+// defineSymbol declares the binding with the value on top of the stack. It's a constant when it's declared
+// by a statement, which the virtual machine keeps track of. The bindings are only ever declared in the function
+// they belong to.
+func (c *Compiler) defineSymbol(s Symbol, decl ast.Node) {
+	flag := 0
+	if decl != nil {
+		flag = 1
+	}
+
+	var pos int
+	if s.Scope == GlobalScope {
+		pos = c.emit(code.OpDefineGlobal, s.Index, flag)
+	} else {
+		pos = c.emit(code.OpDefineLocal, s.Index, flag)
+	}
+
+	if decl != nil {
+		scope := &c.scopes[c.scopeIndex]
+		if scope.declarations == nil {
+			scope.declarations = make(map[int]ast.Node)
+		}
+		scope.declarations[pos] = decl
+	}
+}
+
+// storeSymbol assigns the value on top of the stack to the binding.
+func (c *Compiler) storeSymbol(s Symbol) {
+	switch {
+	case s.Scope == GlobalScope:
+		c.emit(code.OpSetGlobal, s.Index)
+
+	case s.Depth == 0:
+		c.emit(code.OpSetLocal, s.Index)
+
+	default:
+		c.emit(code.OpSetOuter, s.Depth, s.Index)
+	}
+}
+
+func (c *Compiler) addConstant(obj object.Object) int {
+	c.constants = append(c.constants, obj)
+
+	return len(c.constants) - 1
+}
+
+// emit adds an instruction to the current scope, returning its position.
+func (c *Compiler) emit(op code.Opcode, operands ...int) int {
+	c.checkOperands(op, operands)
+	ins := code.Make(op, operands...)
+	pos := c.addInstruction(ins)
+
+	c.setLastInstruction(op, pos)
+
+	return pos
+}
+
+func (c *Compiler) addInstruction(ins []byte) int {
+	scope := &c.scopes[c.scopeIndex]
+
+	posNewInstruction := len(scope.instructions)
+	scope.instructions = append(scope.instructions, ins...)
+	scope.sourceMap = append(scope.sourceMap, code.SourceMapping{Offset: posNewInstruction, Pos: c.sourcePos})
+
+	return posNewInstruction
+}
+
+func (c *Compiler) setLastInstruction(op code.Opcode, pos int) {
+	scope := &c.scopes[c.scopeIndex]
+
+	scope.previousInstruction = scope.lastInstruction
+	scope.lastInstruction = EmittedInstruction{Opcode: op, Position: pos}
+}
+
+func (c *Compiler) currentInstructions() code.Instructions {
+	return c.scopes[c.scopeIndex].instructions
+}
+
+func (c *Compiler) lastInstructionIs(op code.Opcode) bool {
+	if len(c.currentInstructions()) == 0 {
+		return false
+	}
+
+	return c.scopes[c.scopeIndex].lastInstruction.Opcode == op
+}
+
+func (c *Compiler) removeLastPop() {
+	scope := &c.scopes[c.scopeIndex]
+	last := scope.lastInstruction
+
+	scope.instructions = scope.instructions[:last.Position]
+	scope.sourceMap = scope.sourceMap[:len(scope.sourceMap)-1]
+	scope.lastInstruction = scope.previousInstruction
+}
+
+func (c *Compiler) replaceLastPopWithReturn() {
+	lastPos := c.scopes[c.scopeIndex].lastInstruction.Position
+	c.replaceInstruction(lastPos, code.Make(code.OpReturnValue))
+
+	c.scopes[c.scopeIndex].lastInstruction.Opcode = code.OpReturnValue
+}
+
+func (c *Compiler) replaceInstruction(pos int, newInstruction []byte) {
+	ins := c.currentInstructions()
+
+	for i := 0; i < len(newInstruction); i++ {
+		ins[pos+i] = newInstruction[i]
+	}
+}
+
+func (c *Compiler) changeOperand(opPos int, operands ...int) {
+	op := code.Opcode(c.currentInstructions()[opPos])
+	c.checkOperands(op, operands)
+	newInstruction := code.Make(op, operands...)
+
+	c.replaceInstruction(opPos, newInstruction)
+}
+
+// checkOperands records an error for the first operand of the instruction that doesn't fit its width.
+func (c *Compiler) checkOperands(op code.Opcode, operands []int) {
+	def, _ := code.Lookup(byte(op))
+	if c.err != nil || def == nil {
+		return
+	}
+
+	for i, operand := range operands {
+		width := def.OperandWidths[i]
+		if code.Fits(operand, width) {
+			continue
+		}
+
+		limit := 1<<(8*uint(width)) - 1
+		switch {
+		case op == code.OpConstant || op == code.OpClosure || op == code.OpImport:
+			c.err = errorf("too-large", c.sourcePos, "too many constants, the limit is %d", limit)
+
+		case i == 0 && (op == code.OpJump || op == code.OpJumpNotTruthy || op == code.OpJumpIfSet ||
+			op == code.OpIterNext || op == code.OpTry):
+			c.err = errorf("too-large", c.sourcePos, "the code is too long to jump over, the limit is %d bytes of instructions", limit)
+
+		case op == code.OpGetGlobal || op == code.OpSetGlobal || op == code.OpDefineGlobal:
+			c.err = errorf("too-large", c.sourcePos, "too many global bindings, the limit is %d", limit)
+
+		case op == code.OpGetLocal || op == code.OpSetLocal || op == code.OpDefineLocal || op == code.OpGetOuter || op == code.OpSetOuter ||
+			op == code.OpJumpIfSet:
+			c.err = errorf("too-large", c.sourcePos, "too many local bindings or nested functions, the limit is %d", limit)
+
+		default:
+			c.err = errorf("too-large", c.sourcePos, "too many values for %s, like arguments or elements, the limit is %d", def.Name, limit)
+		}
+
+		return
+	}
+}
+
+// errorf returns an error about the code at the position, with the message formatted like fmt.Sprintf does.
+func errorf(code string, pos token.Position, format string, a ...interface{}) error {
+	return diag.Errorf(code, diag.Point(pos), format, a...)
+}
+
+// enterLoop starts a loop at the current offset.
+func (c *Compiler) enterLoop() *Loop {
+	scope := &c.scopes[c.scopeIndex]
+
+	loop := &Loop{start: len(scope.instructions)}
+	scope.loops = append(scope.loops, loop)
+
+	return loop
+}
+
+// leaveLoop ends the innermost loop at the current offset, which is where its break statements jump to.
+func (c *Compiler) leaveLoop() {
+	scope := &c.scopes[c.scopeIndex]
+
+	loop := scope.loops[len(scope.loops)-1]
+	scope.loops = scope.loops[:len(scope.loops)-1]
+
+	for _, pos := range loop.breaks {
+		c.changeOperand(pos, len(scope.instructions))
+	}
+}
+
+func (c *Compiler) currentLoop() *Loop {
+	loops := c.scopes[c.scopeIndex].loops
+	if len(loops) == 0 {
+		return nil
+	}
+
+	return loops[len(loops)-1]
+}
+
+func (c *Compiler) enterScope() {
+	scope := CompilationScope{
+		instructions: code.Instructions{},
+	}
+	c.scopes = append(c.scopes, scope)
+	c.scopeIndex++
+
+	c.symbolTable = NewEnclosedSymbolTable(c.symbolTable)
+}
+
+func (c *Compiler) leaveScope() CompilationScope {
+	scope := c.scopes[c.scopeIndex]
+
+	c.scopes = c.scopes[:len(c.scopes)-1]
+	c.scopeIndex--
+
+	c.symbolTable = c.symbolTable.Outer
+
+	return scope
+}
+
+func (c *Compiler) Bytecode() *Bytecode {
+	b := &Bytecode{
+		Instructions: c.currentInstructions(),
+		SourceMap:    c.scopes[c.scopeIndex].sourceMap,
+		Declarations: c.scopes[c.scopeIndex].declarations,
+		Constants:    c.constants,
+		GlobalNames:  c.symbolTable.Global().Names(),
+	}
+
+	return b
+}
+
+// Bytecode is the output of the compiler, which is fed to the virtual machine.
+type Bytecode struct {
+	Instructions code.Instructions
+	SourceMap    code.SourceMap
+	// Declarations holds the statements declaring constants, by the offset of the instruction declaring them.
+	Declarations map[int]ast.Node
+	Constants    []object.Object
+	// GlobalNames holds the names of the global bindings, by index.
+	GlobalNames []string
+}
@@ -0,0 +1,320 @@
+package compiler
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/code"
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/parser"
+)
+
+func parse(input string) *ast.Program {
+	l := lexer.New(input)
+	p := parser.New(l)
+
+	return p.ParseProgram()
+}
+
+func concatInstructions(s []code.Instructions) code.Instructions {
+	out := code.Instructions{}
+	for _, ins := range s {
+		out = append(out, ins...)
+	}
+
+	return out
+}
+
+func TestCompile(t *testing.T) {
+	testCases := []struct {
+		input                string
+		expectedInstructions []code.Instructions
+	}{
+		{
+			"1 < 2",
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpLessThan),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"if (true) { 10 }; 3333;",
+			[]code.Instructions{
+				code.Make(code.OpTrue),
+				code.Make(code.OpJumpNotTruthy, 10),
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpNull),
+				code.Make(code.OpPop),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"const one = 1; length(one); two;",
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpDefineGlobal, 0, 1),
+				code.Make(code.OpGetBuiltin, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpCall, 1),
+				code.Make(code.OpPop),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"let a = [1]; a[0] += 2;",
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpDefineGlobal, 0, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpConstant, 1),
+				code.Make(code.OpDup2),
+				code.Make(code.OpIndex),
+				code.Make(code.OpConstant, 2),
+				code.Make(code.OpAdd),
+				code.Make(code.OpSetIndex),
+				code.Make(code.OpPop),
+			},
+		},
+		{
+			"while (x) { break; }",
+			[]code.Instructions{
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpJumpNotTruthy, 12),
+				code.Make(code.OpJump, 12),
+				code.Make(code.OpJump, 0),
+				code.Make(code.OpReturn),
+			},
+		},
+		{
+			"for (x in [1]) { x; continue; }",
+			[]code.Instructions{
+				code.Make(code.OpConstant, 0),
+				code.Make(code.OpArray, 1),
+				code.Make(code.OpIter),
+				code.Make(code.OpDefineGlobal, 1, 0),
+				code.Make(code.OpGetGlobal, 1),
+				code.Make(code.OpIterNext, 32, 1),
+				code.Make(code.OpDefineGlobal, 0, 0),
+				code.Make(code.OpGetGlobal, 0),
+				code.Make(code.OpPop),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpJump, 11),
+				code.Make(code.OpReturn),
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		compiler := New()
+		if err := compiler.Compile(parse(tc.input)); err != nil {
+			t.Fatalf("compiler error: %s", err)
+		}
+
+		expected := concatInstructions(tc.expectedInstructions)
+		actual := compiler.Bytecode().Instructions
+
+		if actual.String() != expected.String() {
+			t.Errorf("wrong instructions for %q.\nwant=\n%s\ngot=\n%s", tc.input, expected, actual)
+		}
+	}
+}
+
+func TestCompileClosures(t *testing.T) {
+	input := `
+fn(a) {
+    const inner = fn() { a + later };
+    const later = 1;
+    inner
+}`
+
+	compiler := New()
+	if err := compiler.Compile(parse(input)); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	constants := compiler.Bytecode().Constants
+
+	inner, ok := constants[0].(*object.CompiledFunction)
+	if !ok {
+		t.Fatalf("constant 0 is not a CompiledFunction. got=%T", constants[0])
+	}
+
+	// The binding declared after the inner function is still reachable from it.
+	expected := concatInstructions([]code.Instructions{
+		code.Make(code.OpGetOuter, 1, 0),
+		code.Make(code.OpGetOuter, 1, 2),
+		code.Make(code.OpAdd),
+		code.Make(code.OpReturnValue),
+	})
+
+	if inner.Instructions.String() != expected.String() {
+		t.Errorf("wrong instructions.\nwant=\n%s\ngot=\n%s", expected, inner.Instructions)
+	}
+
+	outer, ok := constants[2].(*object.CompiledFunction)
+	if !ok {
+		t.Fatalf("constant 2 is not a CompiledFunction. got=%T", constants[2])
+	}
+
+	expectedNames := []string{"a", "inner", "later"}
+	if len(outer.LocalNames) != len(expectedNames) {
+		t.Fatalf("wrong local names. want=%v, got=%v", expectedNames, outer.LocalNames)
+	}
+
+	for i, name := range expectedNames {
+		if outer.LocalNames[i] != name {
+			t.Errorf("wrong local name at %d. want=%q, got=%q", i, name, outer.LocalNames[i])
+		}
+	}
+}
+
+func TestCompileDefaultParameters(t *testing.T) {
+	compiler := New()
+	if err := compiler.Compile(parse("fn(a, b = 2, ...rest) { b }")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	fn, ok := compiler.Bytecode().Constants[1].(*object.CompiledFunction)
+	if !ok {
+		t.Fatalf("constant 1 is not a CompiledFunction. got=%T", compiler.Bytecode().Constants[1])
+	}
+
+	// The default value is only evaluated when no argument was passed for the parameter.
+	expected := concatInstructions([]code.Instructions{
+		code.Make(code.OpJumpIfSet, 12, 1),
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpDefineLocal, 1, 0),
+		code.Make(code.OpGetLocal, 1),
+		code.Make(code.OpReturnValue),
+	})
+
+	if fn.Instructions.String() != expected.String() {
+		t.Errorf("wrong instructions.\nwant=\n%s\ngot=\n%s", expected, fn.Instructions)
+	}
+
+	expectedNames := []string{"a", "b", "rest"}
+	for i, name := range expectedNames {
+		if fn.LocalNames[i] != name {
+			t.Errorf("wrong local name at %d. want=%q, got=%q", i, name, fn.LocalNames[i])
+		}
+	}
+}
+
+func TestCompileTryStatement(t *testing.T) {
+	compiler := New()
+	if err := compiler.Compile(parse("try { 1 } catch (e) { e }")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	// The handler installed by OpTry points at the catch block, which the try block jumps over.
+	expected := concatInstructions([]code.Instructions{
+		code.Make(code.OpTry, 11),
+		code.Make(code.OpConstant, 0),
+		code.Make(code.OpPop),
+		code.Make(code.OpEndTry),
+		code.Make(code.OpJump, 20),
+		code.Make(code.OpCatch),
+		code.Make(code.OpDefineGlobal, 0, 0),
+		code.Make(code.OpGetGlobal, 0),
+		code.Make(code.OpPop),
+		code.Make(code.OpReturn),
+	})
+
+	if instructions := compiler.Bytecode().Instructions; instructions.String() != expected.String() {
+		t.Errorf("wrong instructions.\nwant=\n%s\ngot=\n%s", expected, instructions)
+	}
+}
+
+func TestCompilerSourceMap(t *testing.T) {
+	compiler := New()
+	if err := compiler.Compile(parse("1;\n2 + true")); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	bytecode := compiler.Bytecode()
+
+	// OpConstant 0, OpPop, OpConstant 1, OpTrue, OpAdd, OpPop
+	testCases := []struct {
+		offset   int
+		expected string
+	}{
+		{0, "1:1"},
+		{3, "1:1"},
+		{4, "2:1"},
+		{7, "2:5"},
+		{8, "2:1"},
+	}
+
+	for _, tc := range testCases {
+		if pos := bytecode.SourceMap.Lookup(tc.offset); pos.String() != tc.expected {
+			t.Errorf("wrong position for offset %d. want=%s, got=%s", tc.offset, tc.expected, pos)
+		}
+	}
+}
+
+func TestCompileOperandLimits(t *testing.T) {
+	// repeat joins the statement made for each index, up to n.
+	repeat := func(n int, statement func(i int) string) string {
+		var b strings.Builder
+		for i := 0; i < n; i++ {
+			b.WriteString(statement(i))
+		}
+
+		return b.String()
+	}
+
+	literal := func(i int) string { return "1;" }
+	// The names can't hold digits, so the index is written with letters instead.
+	global := func(i int) string {
+		name := ""
+		for ; i > 0 || name == ""; i /= 26 {
+			name = string(rune('a'+i%26)) + name
+		}
+
+		return fmt.Sprintf("let x%s = true;", name)
+	}
+	// Each statement takes two bytes: OpTrue and OpPop.
+	statement := func(i int) string { return "true;" }
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{repeat(65536, literal), ""},
+		{repeat(65537, literal), "1:131073: too many constants, the limit is 65535"},
+		{"if (true) {" + repeat(32000, statement) + "}", ""},
+		{"if (true) {" + repeat(33000, statement) + "}", "1:1: the code is too long to jump over, the limit is 65535 bytes of instructions"},
+		{repeat(65536, global), ""},
+		{repeat(65537, global), "too many global bindings, the limit is 65535"},
+		{"const f = fn(...a) { a }; f(true" + strings.Repeat(", true", 1000) + ")", ""},
+		{"[true" + strings.Repeat(", true", 65535) + "]", "too many values for OpArray, like arguments or elements, the limit is 65535"},
+	}
+
+	for i, tc := range testCases {
+		err := New().Compile(parse(tc.input))
+
+		switch {
+		case tc.expected == "" && err != nil:
+			t.Errorf("unexpected error for the input %d: %s", i, err)
+
+		case tc.expected != "" && (err == nil || !strings.HasSuffix(err.Error(), tc.expected)):
+			t.Errorf("wrong error for the input %d. want=%q, got=%v", i, tc.expected, err)
+
+		case err != nil:
+			// The errors point at the code, for the tools to show it.
+			if d, ok := err.(*diag.Diagnostic); !ok || d.Code != "too-large" || !d.Span.Start.IsValid() {
+				t.Errorf("wrong diagnostic for the input %d. got=%#v", i, err)
+			}
+		}
+	}
+}
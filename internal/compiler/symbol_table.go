@@ -0,0 +1,124 @@
+package compiler
+
+type SymbolScope string
+
+const (
+	GlobalScope  SymbolScope = "GLOBAL"
+	LocalScope   SymbolScope = "LOCAL"
+	BuiltinScope SymbolScope = "BUILTIN"
+)
+
+// Symbol holds everything the compiler needs to know about a binding, to emit instructions that reach it.
+type Symbol struct {
+	Name  string
+	Scope SymbolScope
+	Index int
+	// Depth is the number of enclosing functions to walk up to reach a local binding.
+	Depth int
+	// Implicit bindings stand for unknown names, which the compiler assumes to be globals declared later on.
+	Implicit bool
+}
+
+// SymbolTable keeps track of the bindings of a single function, or of the whole program for global bindings.
+type SymbolTable struct {
+	Outer *SymbolTable
+
+	store map[string]Symbol
+	names []string
+}
+
+func NewSymbolTable() *SymbolTable {
+	s := &SymbolTable{
+		store: make(map[string]Symbol),
+	}
+
+	return s
+}
+
+func NewEnclosedSymbolTable(outer *SymbolTable) *SymbolTable {
+	s := NewSymbolTable()
+	s.Outer = outer
+
+	return s
+}
+
+// Define adds a binding to the table. Defining a name that is already in the table
+// reuses the existing binding.
+func (s *SymbolTable) Define(name string) Symbol {
+	return s.define(name)
+}
+
+// DefineImplicit adds a binding for a name that hasn't been declared, unless it's already in the table.
+func (s *SymbolTable) DefineImplicit(name string) Symbol {
+	if symbol, ok := s.store[name]; ok {
+		return symbol
+	}
+
+	symbol := s.define(name)
+	symbol.Implicit = true
+	s.store[name] = symbol
+
+	return symbol
+}
+
+func (s *SymbolTable) define(name string) Symbol {
+	symbol, ok := s.store[name]
+	if !ok {
+		symbol = Symbol{
+			Name:  name,
+			Index: len(s.names),
+			Scope: LocalScope,
+		}
+		if s.Outer == nil {
+			symbol.Scope = GlobalScope
+		}
+
+		s.names = append(s.names, name)
+	}
+
+	symbol.Implicit = false
+	s.store[name] = symbol
+
+	return symbol
+}
+
+// Lookup looks the name up in this table only.
+func (s *SymbolTable) Lookup(name string) (Symbol, bool) {
+	symbol, ok := s.store[name]
+
+	return symbol, ok
+}
+
+// Resolve looks the name up in this table and in all the enclosing ones.
+func (s *SymbolTable) Resolve(name string) (Symbol, bool) {
+	depth := 0
+
+	for table := s; table != nil; table = table.Outer {
+		if symbol, ok := table.store[name]; ok {
+			if symbol.Scope == LocalScope {
+				symbol.Depth = depth
+			}
+
+			return symbol, true
+		}
+
+		depth++
+	}
+
+	return Symbol{}, false
+}
+
+// Names returns the names of the bindings defined in this table, by index.
+func (s *SymbolTable) Names() []string {
+	return s.names
+}
+
+// Global returns the outermost table, which holds the global bindings.
+func (s *SymbolTable) Global() *SymbolTable {
+	table := s
+	for table.Outer != nil {
+		table = table.Outer
+	}
+
+	return table
+}
@@ -0,0 +1,63 @@
+package compiler
+
+import (
+	"testing"
+)
+
+func TestDefine(t *testing.T) {
+	global := NewSymbolTable()
+	local := NewEnclosedSymbolTable(global)
+
+	testCases := []struct {
+		table    *SymbolTable
+		name     string
+		expected Symbol
+	}{
+		{global, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{global, "b", Symbol{Name: "b", Scope: GlobalScope, Index: 1}},
+		{global, "a", Symbol{Name: "a", Scope: GlobalScope, Index: 0}},
+		{local, "a", Symbol{Name: "a", Scope: LocalScope, Index: 0}},
+		{local, "c", Symbol{Name: "c", Scope: LocalScope, Index: 1}},
+	}
+
+	for _, tc := range testCases {
+		if symbol := tc.table.Define(tc.name); symbol != tc.expected {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", tc.name, tc.expected, symbol)
+		}
+	}
+}
+
+func TestResolveNestedLocals(t *testing.T) {
+	global := NewSymbolTable()
+	global.Define("a")
+
+	first := NewEnclosedSymbolTable(global)
+	first.Define("b")
+	first.Define("c")
+
+	second := NewEnclosedSymbolTable(first)
+	second.Define("c")
+
+	testCases := []Symbol{
+		{Name: "a", Scope: GlobalScope, Index: 0},
+		{Name: "b", Scope: LocalScope, Index: 0, Depth: 1},
+		{Name: "c", Scope: LocalScope, Index: 0, Depth: 0},
+	}
+
+	for _, expected := range testCases {
+		symbol, ok := second.Resolve(expected.Name)
+		if !ok {
+			t.Errorf("name %s not resolvable", expected.Name)
+
+			continue
+		}
+
+		if symbol != expected {
+			t.Errorf("expected %s to resolve to %+v, got=%+v", expected.Name, expected, symbol)
+		}
+	}
+
+	if _, ok := second.Resolve("d"); ok {
+		t.Errorf("name d resolved, but was never defined")
+	}
+}
@@ -0,0 +1,116 @@
+// Package diag describes the problems found in doggo code, by the parser, by the interpreter or by the tools,
+// so that they're all reported the same way: as text, along with the lines of code they're about, or as JSON.
+package diag
+
+import (
+	"fmt"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// Severity tells how bad a problem is.
+type Severity int
+
+const (
+	// Error is a problem that stops the code from running, or that stopped it.
+	Error Severity = iota
+	// Warning is a likely mistake, which doesn't stop the code from running.
+	Warning
+	// Note is a remark about the other problems, like there being too many of them.
+	Note
+)
+
+var severityNames = []string{"error", "warning", "note"}
+
+func (s Severity) String() string {
+	if s < 0 || int(s) >= len(severityNames) {
+		return fmt.Sprintf("Severity(%d)", int(s))
+	}
+
+	return severityNames[s]
+}
+
+// MarshalText gives the name of the severity, which is how it appears in JSON.
+func (s Severity) MarshalText() ([]byte, error) {
+	if s < 0 || int(s) >= len(severityNames) {
+		return nil, fmt.Errorf("unknown severity: %d", int(s))
+	}
+
+	return []byte(s.String()), nil
+}
+
+// UnmarshalText reads the severity from its name.
+func (s *Severity) UnmarshalText(text []byte) error {
+	for i, name := range severityNames {
+		if name == string(text) {
+			*s = Severity(i)
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown severity: %q", text)
+}
+
+// Span is the part of the code a problem is about. End is the position right after it,
+// or the same as Start when the span is a point, like for the errors raised while running the code.
+type Span struct {
+	Start token.Position `json:"start"`
+	End   token.Position `json:"end"`
+}
+
+// Point returns the span of a single position.
+func Point(pos token.Position) Span {
+	return Span{Start: pos, End: pos}
+}
+
+// Fix is a change of the code suggested to solve a problem: the text of the span is replaced by the new text.
+type Fix struct {
+	// Message tells what the fix does, like "insert ')'".
+	Message string `json:"message"`
+	Span    Span   `json:"span"`
+	NewText string `json:"newText"`
+}
+
+// Diagnostic is a problem found in the code.
+type Diagnostic struct {
+	// Code names the kind of the problem, like "unexpected-token", for the tools telling them apart.
+	Code     string   `json:"code"`
+	Severity Severity `json:"severity"`
+	Span     Span     `json:"span"`
+	Message  string   `json:"message"`
+	// Notes tell more about the problem, like the function calls an error unwound through.
+	Notes []string `json:"notes,omitempty"`
+	// Fix is the change solving the problem, if there's an obvious one.
+	Fix *Fix `json:"fix,omitempty"`
+}
+
+// Errorf creates an error about the span, with the message formatted like fmt.Sprintf does.
+func Errorf(code string, span Span, format string, a ...interface{}) *Diagnostic {
+	return &Diagnostic{Code: code, Severity: Error, Span: span, Message: fmt.Sprintf(format, a...)}
+}
+
+// Warningf creates a warning about the span, with the message formatted like fmt.Sprintf does.
+func Warningf(code string, span Span, format string, a ...interface{}) *Diagnostic {
+	return &Diagnostic{Code: code, Severity: Warning, Span: span, Message: fmt.Sprintf(format, a...)}
+}
+
+// Error returns the message of the problem, prefixed by its position if it has one,
+// like "main.doggo:1:5: expected a name, got '=' instead".
+func (d *Diagnostic) Error() string {
+	if d.Span.Start == (token.Position{}) {
+		return d.Message
+	}
+
+	return fmt.Sprintf("%s: %s", d.Span.Start, d.Message)
+}
+
+// Errors returns the diagnostics as text, the way their Error method gives it.
+func Errors(diagnostics []*Diagnostic) []string {
+	errors := make([]string, len(diagnostics))
+	for i, d := range diagnostics {
+		errors[i] = d.Error()
+	}
+
+	return errors
+}
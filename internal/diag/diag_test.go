@@ -0,0 +1,132 @@
+package diag
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+func span(startLine, startColumn, endLine, endColumn int) Span {
+	return Span{
+		Start: token.Position{Filename: "main.doggo", Line: startLine, Column: startColumn},
+		End:   token.Position{Filename: "main.doggo", Line: endLine, Column: endColumn},
+	}
+}
+
+func TestRender(t *testing.T) {
+	code := "let x = 1;\n\tlet 🐶 = y;\nprint(\"dog\n"
+
+	withOffset := func(s Span, offset int) Span {
+		s.Start.Offset = offset
+		s.End.Offset = offset
+
+		return s
+	}
+
+	testCases := []struct {
+		d        *Diagnostic
+		code     string
+		expected string
+	}{
+		{
+			Errorf("unexpected-token", withOffset(span(1, 5, 1, 6), 4), "expected a name"),
+			code,
+			"main.doggo:1:5: expected a name\n  |\n1 | let x = 1;\n  |     ^\n",
+		},
+		{
+			// The tabs are kept before the carets, and the columns count the runes.
+			Errorf("unknown-identifier", withOffset(span(2, 10, 2, 11), 23), "identifier not found: y"),
+			code,
+			"main.doggo:2:10: identifier not found: y\n  |\n2 | \tlet 🐶 = y;\n  | \t        ^\n",
+		},
+		{
+			// The spans going over several lines are marked up to the end of their first line.
+			Errorf("unterminated-string", withOffset(span(3, 7, 4, 1), 32), "unterminated string"),
+			code,
+			"main.doggo:3:7: unterminated string\n  |\n3 | print(\"dog\n  |       ^^^^\n",
+		},
+		{
+			&Diagnostic{
+				Code:     "unused-const",
+				Severity: Warning,
+				Span:     withOffset(span(1, 5, 1, 6), 4),
+				Message:  "constant x is never used",
+				Notes:    []string{"it's declared here"},
+				Fix:      &Fix{Message: "remove it"},
+			},
+			"",
+			"main.doggo:1:5: warning: constant x is never used\n = note: it's declared here\n = fix: remove it\n",
+		},
+		{
+			&Diagnostic{Code: "too-many-errors", Severity: Note, Message: "too many errors"},
+			code,
+			"note: too many errors\n",
+		},
+	}
+
+	for _, tc := range testCases {
+		buf := new(strings.Builder)
+		if err := Render(buf, tc.d, tc.code); err != nil {
+			t.Fatal(err)
+		}
+
+		if got := buf.String(); got != tc.expected {
+			t.Errorf("wrong rendering of %q.\nexpected=%q\ngot=     %q", tc.d.Message, tc.expected, got)
+		}
+	}
+}
+
+func TestError(t *testing.T) {
+	d := Errorf("unexpected-token", span(1, 5, 1, 6), "expected %s", "a name")
+	if got := d.Error(); got != "main.doggo:1:5: expected a name" {
+		t.Errorf("wrong error. got=%q", got)
+	}
+
+	d = &Diagnostic{Message: "too many errors"}
+	if got := d.Error(); got != "too many errors" {
+		t.Errorf("wrong error without a position. got=%q", got)
+	}
+}
+
+func TestWriteJSON(t *testing.T) {
+	diagnostics := []*Diagnostic{
+		Errorf("unexpected-token", span(1, 5, 1, 6), "expected a name"),
+		{
+			Code:     "unused-const",
+			Severity: Warning,
+			Span:     Point(token.Position{Line: 2, Column: 7}),
+			Message:  "constant x is never used",
+			Notes:    []string{"it's declared here"},
+			Fix:      &Fix{Message: "remove it", Span: span(2, 1, 2, 12), NewText: ""},
+		},
+	}
+
+	buf := new(strings.Builder)
+	if err := WriteJSON(buf, diagnostics); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, field := range []string{`"severity": "error"`, `"severity": "warning"`, `"filename": "main.doggo"`, `"newText": ""`} {
+		if !strings.Contains(buf.String(), field) {
+			t.Errorf("expected the JSON to contain %s. got=%s", field, buf)
+		}
+	}
+
+	var got []*Diagnostic
+	if err := json.Unmarshal([]byte(buf.String()), &got); err != nil {
+		t.Fatal(err)
+	}
+
+	if !reflect.DeepEqual(got, diagnostics) {
+		t.Errorf("wrong diagnostics read back.\nexpected=%+v\ngot=     %+v", diagnostics, got)
+	}
+
+	buf.Reset()
+	WriteJSON(buf, nil)
+	if buf.String() != "[]\n" {
+		t.Errorf("expected an empty array without diagnostics. got=%q", buf)
+	}
+}
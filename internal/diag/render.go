@@ -0,0 +1,125 @@
+package diag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Render writes the diagnostic as text, for people to read it: its position and its message, like
+// the Error method gives them, followed by the line of code it's about, with carets under its span,
+// then by its notes and its fix, like:
+//
+//	main.doggo:1:9: expected an expression, got ';' instead
+//	  |
+//	1 | let x = ;
+//	  |         ^
+//
+// The code is the one of the file of the span. Without it, or if the span is outside of it, the line is left out.
+func Render(w io.Writer, d *Diagnostic, code string) error {
+	buf := new(strings.Builder)
+
+	header := d.Message
+	if d.Severity != Error {
+		header = fmt.Sprintf("%s: %s", d.Severity, d.Message)
+	}
+	if d.Span.Start.IsValid() {
+		header = fmt.Sprintf("%s: %s", d.Span.Start, header)
+	}
+	fmt.Fprintln(buf, header)
+
+	gutter := ""
+	if line, ok := lineAt(code, d.Span.Start.Offset); ok && d.Span.Start.IsValid() {
+		number := strconv.Itoa(d.Span.Start.Line)
+		gutter = strings.Repeat(" ", len(number))
+
+		fmt.Fprintf(buf, "%s |\n", gutter)
+		fmt.Fprintf(buf, "%s | %s\n", number, line)
+		fmt.Fprintf(buf, "%s | %s\n", gutter, carets(line, d.Span))
+	}
+
+	for _, note := range d.Notes {
+		fmt.Fprintf(buf, "%s = note: %s\n", gutter, note)
+	}
+
+	if d.Fix != nil {
+		fmt.Fprintf(buf, "%s = fix: %s\n", gutter, d.Fix.Message)
+	}
+
+	_, err := io.WriteString(w, buf.String())
+
+	return err
+}
+
+// lineAt returns the line of the code holding the byte offset, without its line break.
+func lineAt(code string, offset int) (string, bool) {
+	if code == "" || offset < 0 || offset > len(code) {
+		return "", false
+	}
+
+	start := strings.LastIndexByte(code[:offset], '\n') + 1
+	end := len(code)
+	if i := strings.IndexByte(code[offset:], '\n'); i >= 0 {
+		end = offset + i
+	}
+
+	return strings.TrimSuffix(code[start:end], "\r"), true
+}
+
+// carets returns the carets marking the span on its first line. The text before them keeps the tabs
+// of the line, so that they line up with it. A span going over several lines is marked up to the end of the first one.
+func carets(line string, span Span) string {
+	start := span.Start.Column - 1
+	if start < 0 {
+		start = 0
+	}
+
+	buf := new(strings.Builder)
+	i := 0
+	for _, ch := range line {
+		if i == start {
+			break
+		}
+
+		if ch == '\t' {
+			buf.WriteByte('\t')
+		} else {
+			buf.WriteByte(' ')
+		}
+		i++
+	}
+
+	width := 1
+	switch {
+	case span.End.Line == span.Start.Line && span.End.Column > span.Start.Column:
+		width = span.End.Column - span.Start.Column
+
+	case span.End.Line > span.Start.Line:
+		if rest := utf8.RuneCountInString(line) - i; rest > 1 {
+			width = rest
+		}
+	}
+
+	buf.WriteString(strings.Repeat("^", width))
+
+	return buf.String()
+}
+
+// WriteJSON writes the diagnostics as a JSON array, for the tools reading them, like:
+//
+//	[{"code": "unexpected-token", "severity": "error", "span": {...}, "message": "expected a name, got '=' instead"}]
+//
+// The positions in the spans have the fields of token.Position, named in camel case.
+func WriteJSON(w io.Writer, diagnostics []*Diagnostic) error {
+	if diagnostics == nil {
+		diagnostics = []*Diagnostic{}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(diagnostics)
+}
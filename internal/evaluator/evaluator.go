@@ -2,19 +2,37 @@ package evaluator
 
 import (
 	"fmt"
+	"math"
+	"strings"
 
 	"github.com/axbarsan/doggo/internal/ast"
 	"github.com/axbarsan/doggo/internal/object"
 )
 
 var (
-	NULL = &object.Null{}
+	NULL = object.NULL
 
-	TRUE  = &object.Boolean{Value: true}
-	FALSE = &object.Boolean{Value: false}
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
 )
 
 func Eval(node ast.Node, env *object.Environment) object.Object {
+	var result object.Object
+	if err := env.Options().Meter.Step(); err != nil {
+		result = err
+	} else {
+		result = eval(node, env)
+	}
+
+	// The innermost node that produced an error is the one we want to point at.
+	if err, ok := result.(*object.Error); ok && !err.Pos.IsValid() {
+		err.Pos = node.Pos()
+	}
+
+	return result
+}
+
+func eval(node ast.Node, env *object.Environment) object.Object {
 	switch node := node.(type) {
 	case *ast.Program:
 		return evalProgram(node, env)
@@ -25,16 +43,22 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 	case *ast.IntegerLiteral:
 		return &object.Integer{Value: node.Value}
 
+	case *ast.FloatLiteral:
+		return &object.Float{Value: node.Value}
+
 	case *ast.StringLiteral:
 		return &object.String{Value: node.Value}
 
+	case *ast.TemplateLiteral:
+		return allocate(evalTemplateLiteral(node, env), env)
+
 	case *ast.PrefixExpression:
 		right := Eval(node.Right, env)
 		if isError(right) {
 			return right
 		}
 
-		return evalPrefixExpression(node.Operator, right)
+		return evalPrefixExpression(node.Operator, right, env)
 
 	case *ast.InfixExpression:
 		left := Eval(node.Left, env)
@@ -47,7 +71,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return right
 		}
 
-		return evalInfixExpression(node.Operator, left, right)
+		return allocate(evalInfixExpression(node.Operator, left, right, env), env)
 
 	case *ast.BlockStatement:
 		return evalBlockStatement(node, env)
@@ -59,23 +83,57 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 		return nativeBoolToBooleanObject(node.Value)
 
 	case *ast.ConstStatement:
+		return evalDeclaration(node, node.Name, node.Value, true, env)
+
+	case *ast.LetStatement:
+		return evalDeclaration(node, node.Name, node.Value, false, env)
+
+	case *ast.ImportStatement:
+		return evalImportStatement(node, env)
+
+	case *ast.ExportStatement:
+		return Eval(node.Declaration, env)
+
+	case *ast.AssignExpression:
+		return evalAssignExpression(node, env)
+
+	case *ast.WhileStatement:
+		return evalWhileStatement(node, env)
+
+	case *ast.ForStatement:
+		return evalForStatement(node, env)
+
+	case *ast.BreakStatement:
+		return &object.Break{}
+
+	case *ast.ContinueStatement:
+		return &object.Continue{}
+
+	case *ast.TryStatement:
+		return evalTryStatement(node, env)
+
+	case *ast.ThrowStatement:
 		val := Eval(node.Value, env)
 		if isError(val) {
 			return val
 		}
-		env.Set(node.Name.Value, val)
+
+		return object.NewThrownError(val)
 
 	case *ast.Identifier:
 		return evalIdentifier(node, env)
 
 	case *ast.FunctionLiteral:
 		fn := &object.Function{
+			Name:       node.Name,
 			Parameters: node.Parameters,
+			Defaults:   node.Defaults,
+			Rest:       node.Rest,
 			Body:       node.Body,
 			Env:        env,
 		}
 
-		return fn
+		return allocate(fn, env)
 
 	case *ast.CallExpression:
 		fn := Eval(node.Function, env)
@@ -87,7 +145,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return args[0]
 		}
 
-		return applyFunction(fn, args)
+		return applyFunction(fn, args, node, env)
 
 	case *ast.ReturnStatement:
 		val := Eval(node.ReturnValue, env)
@@ -103,7 +161,7 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 			return elements[0]
 		}
 
-		return &object.Array{Elements: elements}
+		return allocate(&object.Array{Elements: elements}, env)
 
 	case *ast.IndexExpression:
 		left := Eval(node.Left, env)
@@ -117,20 +175,33 @@ func Eval(node ast.Node, env *object.Environment) object.Object {
 
 		return evalIndexExpression(left, index)
 
+	case *ast.MemberExpression:
+		return Eval(node.IndexExpression(), env)
+
 	case *ast.MapLiteral:
-		return evalMapLiteral(node, env)
+		return allocate(evalMapLiteral(node, env), env)
 
 	}
 
 	return nil
 }
 
-func newError(format string, a ...interface{}) *object.Error {
+func newError(code, format string, a ...interface{}) *object.Error {
 	return &object.Error{
+		Code:    code,
 		Message: fmt.Sprintf(format, a...),
 	}
 }
 
+// allocate counts the newly created value against the allocation limit of the run.
+func allocate(obj object.Object, env *object.Environment) object.Object {
+	if err := env.Options().Meter.Allocate(obj); err != nil {
+		return err
+	}
+
+	return obj
+}
+
 func isError(obj object.Object) bool {
 	if obj != nil {
 		return obj.Type() == object.ERROR_OBJ
@@ -163,11 +234,8 @@ func evalBlockStatement(program *ast.BlockStatement, env *object.Environment) ob
 	for _, statement := range program.Statements {
 		result = Eval(statement, env)
 
-		if result != nil {
-			rt := result.Type()
-			if rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ {
-				return result
-			}
+		if leavesBlock(result) {
+			return result
 		}
 	}
 
@@ -182,16 +250,16 @@ func nativeBoolToBooleanObject(input bool) *object.Boolean {
 	return FALSE
 }
 
-func evalPrefixExpression(operator string, right object.Object) object.Object {
+func evalPrefixExpression(operator string, right object.Object, env *object.Environment) object.Object {
 	switch operator {
 	case "!":
 		return evalBangOperatorExpression(right)
 
 	case "-":
-		return evalMinusPrefixOperatorExpression(right)
+		return evalMinusPrefixOperatorExpression(right, env)
 
 	default:
-		return newError("unknown operator: %s%s", operator, right.Type())
+		return newError("unknown-operator", "unknown operator: %s%s", operator, right.Type())
 	}
 }
 
@@ -211,23 +279,31 @@ func evalBangOperatorExpression(right object.Object) object.Object {
 	}
 }
 
-func evalMinusPrefixOperatorExpression(right object.Object) object.Object {
-	if right.Type() != object.INTEGER_OBJ {
-		return newError("unknown operator: -%s", right.Type())
-	}
+func evalMinusPrefixOperatorExpression(right object.Object, env *object.Environment) object.Object {
+	switch right := right.(type) {
+	case *object.Integer:
+		if env.Options().Strict && right.Value == math.MinInt64 {
+			return newError("integer-overflow", "integer overflow: -%d", right.Value)
+		}
 
-	value := right.(*object.Integer).Value
-	obj := &object.Integer{
-		Value: -value,
-	}
+		return &object.Integer{Value: -right.Value}
 
-	return obj
+	case *object.Float:
+		return &object.Float{Value: -right.Value}
+
+	default:
+		return newError("unknown-operator", "unknown operator: -%s", right.Type())
+	}
 }
 
-func evalInfixExpression(operator string, left, right object.Object) object.Object {
+func evalInfixExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
 	switch {
 	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
-		return evalIntegerInfixExpression(operator, left, right)
+		return evalIntegerInfixExpression(operator, left, right, env)
+
+	case isNumber(left) && isNumber(right):
+		// Mixing integers and floats promotes the integers to floats.
+		return evalFloatInfixExpression(operator, left, right)
 
 	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
 		return evalStringInfixExpression(operator, left, right)
@@ -239,17 +315,21 @@ func evalInfixExpression(operator string, left, right object.Object) object.Obje
 		return nativeBoolToBooleanObject(left != right)
 
 	case left.Type() != right.Type():
-		return newError("type mismatch: %s %s %s", left.Type(), operator, right.Type())
+		return newError("type-mismatch", "type mismatch: %s %s %s", left.Type(), operator, right.Type())
 
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
-func evalIntegerInfixExpression(operator string, left, right object.Object) object.Object {
+func evalIntegerInfixExpression(operator string, left, right object.Object, env *object.Environment) object.Object {
 	leftVal := left.(*object.Integer).Value
 	rightVal := right.(*object.Integer).Value
 
+	if env.Options().Strict && object.IntegerOverflows(operator, leftVal, rightVal) {
+		return newError("integer-overflow", "integer overflow: %d %s %d", leftVal, operator, rightVal)
+	}
+
 	switch operator {
 	case "+":
 		return &object.Integer{
@@ -267,10 +347,68 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 		}
 
 	case "/":
+		if rightVal == 0 {
+			return newError("division-by-zero", "division by zero")
+		}
+
 		return &object.Integer{
 			Value: leftVal / rightVal,
 		}
 
+	case "%":
+		if rightVal == 0 {
+			return newError("division-by-zero", "modulo by zero")
+		}
+
+		return &object.Integer{
+			Value: leftVal % rightVal,
+		}
+
+	case "<":
+		return nativeBoolToBooleanObject(leftVal < rightVal)
+
+	case ">":
+		return nativeBoolToBooleanObject(leftVal > rightVal)
+
+	case "==":
+		return nativeBoolToBooleanObject(leftVal == rightVal)
+
+	case "!=":
+		return nativeBoolToBooleanObject(leftVal != rightVal)
+
+	default:
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), operator, right.Type())
+	}
+}
+
+func evalFloatInfixExpression(operator string, left, right object.Object) object.Object {
+	leftVal, _ := object.ToFloat(left)
+	rightVal, _ := object.ToFloat(right)
+
+	switch operator {
+	case "+":
+		return &object.Float{Value: leftVal + rightVal}
+
+	case "-":
+		return &object.Float{Value: leftVal - rightVal}
+
+	case "*":
+		return &object.Float{Value: leftVal * rightVal}
+
+	case "/":
+		if rightVal == 0 {
+			return newError("division-by-zero", "division by zero")
+		}
+
+		return &object.Float{Value: leftVal / rightVal}
+
+	case "%":
+		if rightVal == 0 {
+			return newError("division-by-zero", "modulo by zero")
+		}
+
+		return &object.Float{Value: math.Mod(leftVal, rightVal)}
+
 	case "<":
 		return nativeBoolToBooleanObject(leftVal < rightVal)
 
@@ -284,10 +422,16 @@ func evalIntegerInfixExpression(operator string, left, right object.Object) obje
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
+func isNumber(obj object.Object) bool {
+	_, ok := object.ToFloat(obj)
+
+	return ok
+}
+
 func evalStringInfixExpression(operator string, left, right object.Object) object.Object {
 	leftVal := left.(*object.String).Value
 	rightVal := right.(*object.String).Value
@@ -303,7 +447,7 @@ func evalStringInfixExpression(operator string, left, right object.Object) objec
 		return nativeBoolToBooleanObject(leftVal != rightVal)
 
 	default:
-		return newError("unknown operator: %s %s %s", left.Type(), operator, right.Type())
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), operator, right.Type())
 	}
 }
 
@@ -322,6 +466,280 @@ func evalIfExpression(ie *ast.IfExpression, env *object.Environment) object.Obje
 	return NULL
 }
 
+// evalDeclaration binds the name in the current environment. A constant can only be declared again
+// by the statement that declared it in the first place, e.g. on the next iteration of a loop.
+func evalDeclaration(decl ast.Statement, name *ast.Identifier, value ast.Expression, constant bool, env *object.Environment) object.Object {
+	val := Eval(value, env)
+	if isError(val) {
+		return val
+	}
+
+	return bind(decl, name, val, constant, env)
+}
+
+func bind(decl ast.Statement, name *ast.Identifier, val object.Object, constant bool, env *object.Environment) object.Object {
+	if prev, ok := env.Constant(name.Value); ok && prev != decl {
+		return newError("constant-redeclared", "cannot redeclare constant: %s", name.Value)
+	}
+
+	if constant {
+		env.SetConstant(name.Value, val, decl)
+	} else {
+		env.Set(name.Value, val)
+	}
+
+	return nil
+}
+
+// evalImportStatement binds the name to the module found at the path, as a constant.
+func evalImportStatement(is *ast.ImportStatement, env *object.Environment) object.Object {
+	loader := env.Options().Modules
+	if loader == nil {
+		return newError("misplaced-statement", "cannot import modules here")
+	}
+
+	module, err := loader.Load(is.Path.Value, is.Pos())
+	if err != nil {
+		return err
+	}
+
+	return bind(is, is.Name, module, true, env)
+}
+
+func evalAssignExpression(node *ast.AssignExpression, env *object.Environment) object.Object {
+	switch target := node.Target.(type) {
+	case *ast.Identifier:
+		return evalIdentifierAssignment(node, target, env)
+
+	case *ast.MemberExpression:
+		return evalIndexAssignment(node, target.IndexExpression(), env)
+
+	case *ast.IndexExpression:
+		return evalIndexAssignment(node, target, env)
+
+	default:
+		return newError("invalid-assignment", "cannot assign to %s", node.Target.String())
+	}
+}
+
+func evalIdentifierAssignment(node *ast.AssignExpression, target *ast.Identifier, env *object.Environment) object.Object {
+	// The current value is read before the new one is evaluated. Like on the virtual machine,
+	// the binding is only checked once the new value is there to be set.
+	var current object.Object
+	if node.Operator != "=" {
+		current = evalIdentifier(target, env)
+		if isError(current) {
+			return current
+		}
+	}
+
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if node.Operator != "=" {
+		val = evalInfixExpression(compoundOperator(node.Operator), current, val, env)
+		if isError(val) {
+			return val
+		}
+	}
+
+	scope, ok := env.Scope(target.Value)
+	if !ok {
+		return newError("unknown-identifier", "identifier not found: %s", target.Value)
+	}
+
+	if _, ok := scope.Constant(target.Value); ok {
+		return newError("constant-assigned", "cannot assign to constant: %s", target.Value)
+	}
+
+	return scope.Set(target.Value, val)
+}
+
+func evalIndexAssignment(node *ast.AssignExpression, target *ast.IndexExpression, env *object.Environment) object.Object {
+	left := Eval(target.Left, env)
+	if isError(left) {
+		return left
+	}
+
+	index := Eval(target.Index, env)
+	if isError(index) {
+		return index
+	}
+
+	var current object.Object
+	if node.Operator != "=" {
+		current = evalIndexExpression(left, index)
+		if isError(current) {
+			return current
+		}
+	}
+
+	val := Eval(node.Value, env)
+	if isError(val) {
+		return val
+	}
+
+	if node.Operator != "=" {
+		val = evalInfixExpression(compoundOperator(node.Operator), current, val, env)
+		if isError(val) {
+			return val
+		}
+	}
+
+	return evalSetIndexExpression(left, index, val)
+}
+
+// compoundOperator returns the operator a compound assignment applies, e.g. '+' for '+='.
+func compoundOperator(operator string) string {
+	return strings.TrimSuffix(operator, "=")
+}
+
+func evalSetIndexExpression(left, index, val object.Object) object.Object {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		idx := index.(*object.Integer).Value
+
+		if idx < 0 || idx >= int64(len(elements)) {
+			return newError("index-out-of-range", "index out of range: %d", idx)
+		}
+		elements[idx] = val
+
+	case left.Type() == object.MAP_OBJ:
+		key, ok := index.(object.Mappable)
+		if !ok {
+			return newError("unusable-key", "unusable as map key: %s", index.Type())
+		}
+
+		left.(*object.Map).Pairs[key.MapKey()] = object.MapPair{Key: key, Value: val}
+
+	default:
+		return newError("not-indexable", "index assignment not supported: %s", left.Type())
+	}
+
+	return val
+}
+
+func evalWhileStatement(ws *ast.WhileStatement, env *object.Environment) object.Object {
+	for {
+		condition := Eval(ws.Condition, env)
+		if isError(condition) {
+			return condition
+		}
+
+		if !isTruthy(condition) {
+			return nil
+		}
+
+		if result, stop := evalLoopBody(ws.Body, env); stop {
+			return result
+		}
+	}
+}
+
+func evalForStatement(fs *ast.ForStatement, env *object.Environment) object.Object {
+	iterable := Eval(fs.Iterable, env)
+	if isError(iterable) {
+		return iterable
+	}
+
+	it, ok := object.NewIterator(iterable)
+	if !ok {
+		return newError("not-iterable", "iteration not supported: %s", iterable.Type())
+	}
+
+	for {
+		if fs.Key != nil {
+			key, value, ok := it.Next()
+			if !ok {
+				return nil
+			}
+			if err := bind(fs, fs.Value, value, false, env); err != nil {
+				return err
+			}
+			if err := bind(fs, fs.Key, key, false, env); err != nil {
+				return err
+			}
+		} else {
+			member, ok := it.NextMember()
+			if !ok {
+				return nil
+			}
+			if err := bind(fs, fs.Value, member, false, env); err != nil {
+				return err
+			}
+		}
+
+		if result, stop := evalLoopBody(fs.Body, env); stop {
+			return result
+		}
+	}
+}
+
+// evalTryStatement runs the try block, then the catch block if the try block raised an error,
+// and the finally block in any case. Leaving the finally block early, like with a return statement,
+// overrides how the rest of the statement was left.
+func evalTryStatement(ts *ast.TryStatement, env *object.Environment) object.Object {
+	result := Eval(ts.Block, env)
+
+	// Once the run is stopped, neither the catch nor the finally block can run.
+	if env.Options().Meter.Tripped() {
+		return result
+	}
+
+	if err, ok := result.(*object.Error); ok && ts.Catch != nil {
+		if result = bind(ts, ts.Param, err.ToMap(), false, env); result == nil {
+			result = Eval(ts.Catch, env)
+		}
+	}
+
+	if ts.Finally != nil {
+		if finally := Eval(ts.Finally, env); leavesBlock(finally) {
+			return finally
+		}
+	}
+
+	if leavesBlock(result) {
+		return result
+	}
+
+	return nil
+}
+
+// leavesBlock reports whether the result of a statement stops the block it is in.
+func leavesBlock(result object.Object) bool {
+	if result == nil {
+		return false
+	}
+
+	rt := result.Type()
+
+	return rt == object.RETURN_VALUE_OBJ || rt == object.ERROR_OBJ ||
+		rt == object.BREAK_OBJ || rt == object.CONTINUE_OBJ
+}
+
+// evalLoopBody runs a single iteration of a loop. It reports whether the loop has to stop,
+// along with what the loop statement evaluates to in that case.
+func evalLoopBody(body *ast.BlockStatement, env *object.Environment) (object.Object, bool) {
+	result := Eval(body, env)
+	if result == nil {
+		return nil, false
+	}
+
+	switch result.Type() {
+	case object.RETURN_VALUE_OBJ, object.ERROR_OBJ:
+		return result, true
+
+	case object.BREAK_OBJ:
+		return nil, true
+
+	default:
+		return nil, false
+	}
+}
+
 func isTruthy(obj object.Object) bool {
 	switch obj {
 	case NULL:
@@ -343,11 +761,11 @@ func evalIdentifier(node *ast.Identifier, env *object.Environment) object.Object
 		return val
 	}
 
-	if b, ok := builtin[node.Value]; ok {
+	if b := object.GetBuiltinByName(node.Value); b != nil {
 		return b
 	}
 
-	return newError("identifier not found: %s", node.Value)
+	return newError("unknown-identifier", "identifier not found: %s", node.Value)
 }
 
 func evalExpressions(expressions []ast.Expression, env *object.Environment) []object.Object {
@@ -369,12 +787,36 @@ func evalIndexExpression(left, index object.Object) object.Object {
 	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
 		return evalArrayIndexExpression(left, index)
 
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		return evalStringIndexExpression(left, index)
+
 	case left.Type() == object.MAP_OBJ:
 		return evalMapIndexExpression(left, index)
 
+	case left.Type() == object.MODULE_OBJ:
+		member, err := left.(*object.Module).Member(index)
+		if err != nil {
+			return err
+		}
+
+		return member
+
 	default:
-		return newError("index operator not supported: %s", left.Type())
+		return newError("not-indexable", "index operator not supported: %s", left.Type())
+	}
+}
+
+// evalStringIndexExpression returns the char at the given index, as a string.
+func evalStringIndexExpression(str, index object.Object) object.Object {
+	chars := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+	max := int64(len(chars) - 1)
+
+	if idx < 0 || idx > max {
+		return NULL
 	}
+
+	return &object.String{Value: string(chars[idx])}
 }
 
 func evalArrayIndexExpression(array, index object.Object) object.Object {
@@ -400,7 +842,7 @@ func evalMapLiteral(node *ast.MapLiteral, env *object.Environment) object.Object
 
 		mapKey, ok := key.(object.Mappable)
 		if !ok {
-			return newError("unusable as map key: %s", key.Type())
+			return newError("unusable-key", "unusable as map key: %s", key.Type())
 		}
 
 		value := Eval(valueNode, env)
@@ -420,7 +862,7 @@ func evalMapIndexExpression(m, index object.Object) object.Object {
 
 	key, ok := index.(object.Mappable)
 	if !ok {
-		return newError("unusable as map key: %s", index.Type())
+		return newError("unusable-key", "unusable as map key: %s", index.Type())
 	}
 
 	pair, ok := mapObject.Pairs[key.MapKey()]
@@ -431,30 +873,95 @@ func evalMapIndexExpression(m, index object.Object) object.Object {
 	return pair.Value
 }
 
-func applyFunction(fn object.Object, args []object.Object) object.Object {
+func applyFunction(fn object.Object, args []object.Object, call *ast.CallExpression, env *object.Environment) object.Object {
+	meter := env.Options().Meter
+
 	switch function := fn.(type) {
 	case *object.Function:
-		extendedEnv := extendFunctionEnv(function, args)
-		evaluated := Eval(function.Body, extendedEnv)
+		if err := function.CheckArity(len(args)); err != nil {
+			return err
+		}
+
+		if err := meter.Enter(); err != nil {
+			return err
+		}
+		result := callFunction(function, args)
+		meter.Leave()
+		if err, ok := result.(*object.Error); ok {
+			addStackFrame(err, function, call)
+		}
 
-		return unwrapReturnValue(evaluated)
+		return result
 
 	case *object.Builtin:
-		return function.Fn(args...)
+		return allocate(function.Call(env.Options(), args...), env)
 
 	default:
-		return newError("not a function: %s", fn.Type())
+		return newError("not-callable", "not a function: %s", fn.Type())
+	}
+}
+
+// addStackFrame records the call of a user-defined function on the stack trace of an error unwinding through it.
+func addStackFrame(err *object.Error, fn *object.Function, call *ast.CallExpression) {
+	err.Stack = append(err.Stack, object.Frame{Function: fn.DisplayName(), Pos: call.Pos()})
+}
+
+func callFunction(fn *object.Function, args []object.Object) object.Object {
+	extendedEnv, err := extendFunctionEnv(fn, args)
+	if err != nil {
+		return err
 	}
+
+	evaluated := Eval(fn.Body, extendedEnv)
+
+	return unwrapReturnValue(evaluated)
 }
 
-func extendFunctionEnv(fn *object.Function, args []object.Object) *object.Environment {
+// extendFunctionEnv binds the arguments to the parameters of the function.
+// The default values are evaluated in order, so they can refer to the parameters before them.
+func extendFunctionEnv(fn *object.Function, args []object.Object) (*object.Environment, *object.Error) {
 	env := object.NewEnclosedEnvironment(fn.Env)
 
 	for paramID, param := range fn.Parameters {
-		env.Set(param.Value, args[paramID])
+		if paramID < len(args) {
+			env.Set(param.Value, args[paramID])
+
+			continue
+		}
+
+		val := Eval(fn.Defaults[paramID], env)
+		if err, ok := val.(*object.Error); ok {
+			return nil, err
+		}
+		env.Set(param.Value, val)
+	}
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if len(args) > len(fn.Parameters) {
+			rest = append(rest, args[len(fn.Parameters):]...)
+		}
+		env.Set(fn.Rest.Value, &object.Array{Elements: rest})
+	}
+
+	return env, nil
+}
+
+// evalTemplateLiteral joins the pieces of an interpolated string. The values
+// of the interpolated expressions appear the way they're printed.
+func evalTemplateLiteral(template *ast.TemplateLiteral, env *object.Environment) object.Object {
+	var out strings.Builder
+
+	for _, part := range template.Parts {
+		val := Eval(part, env)
+		if isError(val) {
+			return val
+		}
+
+		out.WriteString(val.Inspect())
 	}
 
-	return env
+	return &object.String{Value: out.String()}
 }
 
 func unwrapReturnValue(obj object.Object) object.Object {
@@ -462,5 +969,10 @@ func unwrapReturnValue(obj object.Object) object.Object {
 		return returnValue.Value
 	}
 
+	// A function ending with a statement, like a loop, returns null.
+	if obj == nil {
+		return NULL
+	}
+
 	return obj
 }
@@ -1,18 +1,60 @@
 package evaluator
 
 import (
+	"fmt"
+	"os"
 	"testing"
 
+	"github.com/axbarsan/doggo/internal/compiler"
 	"github.com/axbarsan/doggo/internal/lexer"
 	"github.com/axbarsan/doggo/internal/object"
 	"github.com/axbarsan/doggo/internal/parser"
+	"github.com/axbarsan/doggo/internal/vm"
 )
 
+// The whole suite runs once for every backend, since they must behave the same.
+var (
+	engines = []string{"eval", "vm"}
+	engine  string
+)
+
+func TestMain(m *testing.M) {
+	exitCode := 0
+
+	for _, e := range engines {
+		engine = e
+		fmt.Printf("running the suite on the %q engine\n", engine)
+
+		if code := m.Run(); code != 0 {
+			exitCode = code
+		}
+	}
+
+	os.Exit(exitCode)
+}
+
 func testEval(input string) object.Object {
+	return testEvalWithOptions(input, &object.Options{})
+}
+
+func testEvalWithOptions(input string, options *object.Options) object.Object {
 	l := lexer.New(input)
 	p := parser.New(l)
 	program := p.ParseProgram()
-	env := object.NewEnvironment()
+
+	if engine == "vm" {
+		comp := compiler.New()
+		if err := comp.Compile(program); err != nil {
+			return &object.Error{Message: fmt.Sprintf("compiler error: %s", err)}
+		}
+
+		machine := vm.New(comp.Bytecode())
+		machine.SetOptions(options)
+
+		return machine.Run()
+	}
+
+	env := object.NewEnvironmentWithOptions(options)
 
 	return Eval(program, env)
 }
@@ -37,6 +79,12 @@ func TestEvalIntegerExpression(t *testing.T) {
 		{"3 * 3 * 3 + 10", 37},
 		{"3 * (3 * 3) + 10", 37},
 		{"(5 + 10 * 2 + 15 / 3) * 2 + -10", 50},
+		{"7 % 3", 1},
+		{"-7 % 3", -1},
+		{"2 + 7 % 4 * 2", 8},
+		{"let x = 17; x %= 5; x", 2},
+		{"5 // five", 5},
+		{"/* one */ 1 + /* two /* nested */ */ 2", 3},
 	}
 
 	for _, tc := range testCases {
@@ -64,6 +112,52 @@ func testIntegerObject(t *testing.T) func(object.Object, int64) bool {
 	}
 }
 
+func TestEvalFloatExpression(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14", 3.14},
+		{"-2.5", -2.5},
+		{"1e3", 1000},
+		{"1.5 + 1", 2.5},
+		{"1 + 1.5", 2.5},
+		{"10 / 4.0", 2.5},
+		{"2.5 * 2", 5},
+		{"0.5 - 1", -0.5},
+		{"let x = 1; x += 0.5; x", 1.5},
+		{"(1 + 2 + 4) / 2.0", 3.5},
+		{"7.5 % 2", 1.5},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		testFloatObject(t)(evaluated, tc.expected)
+	}
+
+	// Integer division still truncates.
+	testIntegerObject(t)(testEval("7 / 2"), 3)
+}
+
+func testFloatObject(t *testing.T) func(object.Object, float64) bool {
+	return func(obj object.Object, expected float64) bool {
+		result, ok := obj.(*object.Float)
+		if !ok {
+			t.Errorf("object is not Float. got=%T (%+v)", obj, obj)
+
+			return false
+		}
+
+		if result.Value != expected {
+			t.Errorf("object has wrong value. got=%g, want=%g", result.Value, expected)
+
+			return false
+		}
+
+		return true
+	}
+}
+
 func TestEvalBooleanExpression(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -79,6 +173,10 @@ func TestEvalBooleanExpression(t *testing.T) {
 		{"1 != 1", false},
 		{"1 == 2", false},
 		{"1 != 2", true},
+		{"1 == 1.0", true},
+		{"1.5 < 2", true},
+		{"2.5 > 2.5", false},
+		{"0.1 + 0.2 != 0.3", true},
 		{"true == true", true},
 		{"false == false", true},
 		{"true == false", false},
@@ -207,6 +305,101 @@ if (10 > 1) {
 	}
 }
 
+func TestWhileStatements(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let i = 0; while (i < 5) { i += 1; }; i", 5},
+		{"let i = 0; while (false) { i += 1; }; i", 0},
+		{"let i = 0; while (true) { i += 1; if (i == 3) { break; } }; i", 3},
+		{
+			`
+let i = 0;
+let sum = 0;
+while (i < 5) {
+    i += 1;
+    if (i == 2) { continue; }
+    sum += i;
+}
+sum`,
+			13,
+		},
+		{"const f = fn() { while (true) { return 7; } }; f()", 7},
+		{"const f = fn() { while (false) { } }; f()", nil},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		integer, ok := tc.expected.(int)
+		if ok {
+			testIntegerObject(t)(evaluated, int64(integer))
+		} else {
+			testNullObject(t)(evaluated)
+		}
+	}
+
+	// A program ending with a statement evaluates to nothing.
+	if evaluated := testEval("while (false) { }"); evaluated != nil {
+		t.Errorf("object is not nil. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
+func TestForStatements(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"let sum = 0; for (x in [1, 2, 3]) { sum += x; }; sum", 6},
+		{"let sum = 0; for (i, x in [10, 20, 30]) { sum += i * x; }; sum", 80},
+		{"let sum = 0; for (x in []) { sum += x; }; sum", 0},
+		{`let s = ""; for (c in "dog") { s = c + s; }; s`, "god"},
+		{`let s = ""; for (i, c in "héllo") { if (i == 1) { s = c; } }; s`, "é"},
+		{`let s = ""; for (k in {"b": 2, "a": 1, "c": 3}) { s += k; }; s`, "abc"},
+		{`let s = 0; for (k, v in {"b": 2, "a": 1, "c": 3}) { s = s * 10 + v; }; s`, 123},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { break; }; sum += x; }; sum", 3},
+		{"let sum = 0; for (x in [1, 2, 3, 4]) { if (x == 3) { continue; }; sum += x; }; sum", 7},
+		{
+			`
+let sum = 0;
+for (x in [1, 2]) {
+    for (y in [10, 20, 30]) {
+        if (y == 30) { break; }
+        sum += x * y;
+    }
+}
+sum`,
+			90,
+		},
+		{"const first = fn(xs) { for (x in xs) { return x; } }; first([4, 5])", 4},
+		{"const first = fn(xs) { for (x in xs) { return x; } }; first([])", nil},
+		{"const f = fn() { for (x in [1, 2]) { fn() { x } } }; f()", nil},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t)(evaluated, int64(expected))
+
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+
+				continue
+			}
+
+			if str.Value != expected {
+				t.Errorf("String has wrong value. got=%q, want=%q", str.Value, expected)
+			}
+
+		default:
+			testNullObject(t)(evaluated)
+		}
+	}
+}
+
 func TestErrorHandling(t *testing.T) {
 	testCases := []struct {
 		input           string
@@ -238,8 +431,8 @@ func TestErrorHandling(t *testing.T) {
 		},
 		{
 			`
-if 10 > 1 {
-    if 10 > 1 {
+if (10 > 1) {
+    if (10 > 1) {
         return true + false;
     }
 
@@ -260,6 +453,78 @@ if 10 > 1 {
 			`{"name": "test"}[fn(x) { x }];`,
 			"unusable as map key: FUNCTION",
 		},
+		{
+			`1.5 + "a"`,
+			"type mismatch: FLOAT + STRING",
+		},
+		{
+			"-true + 1.5",
+			"unknown operator: -BOOLEAN",
+		},
+		{
+			"for (x in 5) { x }",
+			"iteration not supported: INTEGER",
+		},
+		{
+			"const a = [1, 2]; a[2] = 3",
+			"index out of range: 2",
+		},
+		{
+			"const a = [1, 2]; a[-1] = 3",
+			"index out of range: -1",
+		},
+		{
+			`const s = "dog"; s[0] = "f"`,
+			"index assignment not supported: STRING",
+		},
+		{
+			`const m = {}; m[fn() {}] = 1`,
+			"unusable as map key: FUNCTION",
+		},
+		{
+			`let x = 1; x += "a"`,
+			"type mismatch: INTEGER + STRING",
+		},
+		{
+			`const m = {}; m["a"] += 1`,
+			"type mismatch: NULL + INTEGER",
+		},
+		{
+			"let i = 0; while (i < 3) { i += 1; if (i == 2) { i + true } }",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			"10 / 0",
+			"division by zero",
+		},
+		{
+			"10 % 0",
+			"modulo by zero",
+		},
+		{
+			"1.5 / 0",
+			"division by zero",
+		},
+		{
+			"1.5 % 0.0",
+			"modulo by zero",
+		},
+		{
+			"let x = 1; x /= 0; x",
+			"division by zero",
+		},
+		{
+			"const half = fn(x) { x / 2 }; half(3) / (half(1))",
+			"division by zero",
+		},
+		{
+			`import "util.doggo" as util;`,
+			"cannot import modules here",
+		},
+		{
+			"const m = {}; m.a.b",
+			"index operator not supported: NULL",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -278,6 +543,222 @@ if 10 > 1 {
 	}
 }
 
+func TestStrictIntegerOverflow(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			"9223372036854775807 + 1",
+			"integer overflow: 9223372036854775807 + 1",
+		},
+		{
+			"-9223372036854775807 - 2",
+			"integer overflow: -9223372036854775807 - 2",
+		},
+		{
+			"4611686018427387904 * 2",
+			"integer overflow: 4611686018427387904 * 2",
+		},
+		{
+			"const min = -9223372036854775807 - 1; min / -1",
+			"integer overflow: -9223372036854775808 / -1",
+		},
+		{
+			"const min = -9223372036854775807 - 1; -min",
+			"integer overflow: --9223372036854775808",
+		},
+		{
+			"let x = 9223372036854775807; x += 1",
+			"integer overflow: 9223372036854775807 + 1",
+		},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEvalWithOptions(tc.input, &object.Options{Strict: true})
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tc.input, evaluated, evaluated)
+
+			continue
+		}
+
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedMessage, errObj.Message)
+		}
+	}
+
+	// Outside of the strict mode the integers wrap around.
+	testIntegerObject(t)(testEval("9223372036854775807 + 1"), -9223372036854775808)
+	testIntegerObject(t)(testEvalWithOptions("9223372036854775806 + 1", &object.Options{Strict: true}), 9223372036854775807)
+}
+
+func TestTryStatements(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`let r = 0; try { r = 1 } catch (e) { r = 2 }; r`, 1},
+		{`let r = ""; try { 1 / 0; r = "no" } catch (e) { r = e["message"] }; r`, "division by zero"},
+		{`let r = ""; try { 1 + true } catch (e) { r = e["kind"] }; r`, "RuntimeError"},
+		{`let r = ""; try { throw "boom" } catch (e) { r = e["kind"] + ": " + e["message"] }; r`, "Error: boom"},
+		{`let r = 0; try { throw 42 } catch (e) { r = e["value"] }; r`, 42},
+		{`let r = ""; try { throw {"message": "bad", "kind": "ValueError"} } catch (e) { r = e["kind"] }; r`, "ValueError"},
+		{`let r = ""; try { throw "x" } catch (e) { r = e["position"] }; r`, "1:19"},
+		// The finally block runs however the statement is left.
+		{`let r = 0; try { r = 1 } finally { r += 10 }; r`, 11},
+		{`let r = 0; try { throw "x" } catch (e) { r = 1 } finally { r += 10 }; r`, 11},
+		{`let r = 0; const f = fn() { try { return 1 } finally { r = 5 } }; f() + r`, 6},
+		{`const f = fn() { try { return 1 } finally { return 2 } }; f()`, 2},
+		{`const f = fn() { try { throw "x" } finally { return 3 } }; f()`, 3},
+		{`let r = 0; try { try { throw "x" } finally { r = 1 } } catch (e) { r += 10 }; r`, 11},
+		{`let r = 0; try { try { throw "x" } catch (e) { throw "y" } finally { r = 1 } } catch (e) { r += 10 }; r`, 11},
+		{`let r = ""; try { try { throw "x" } catch (e) { throw e } } catch (e) { r = e["message"] }; r`, "x"},
+		// Errors unwind through function calls, up to the innermost try statement.
+		{`const f = fn() { throw "deep" }; const g = fn() { f() + 1 }; let r = ""; try { g() } catch (e) { r = e["message"] }; r`, "deep"},
+		{`const f = fn(x) { try { x + true } catch (e) { return -1 } }; f(1)`, -1},
+		{`const f = fn() { let r = 0; try { r = 1 } catch (e) { r = 2 }; r }; f()`, 1},
+		{`const f = fn() { try { 1 } catch (e) { 2 } }; f()`, nil},
+		{`if (true) { try { 1 } catch (e) { 2 } }`, nil},
+		// Loops can be left from within try statements.
+		{`let r = 0; while (true) { try { r += 1; if (r > 20) { break } } finally { r += 10 } }; r`, 33},
+		{`let r = 0; for (x in [1, 2, 3]) { try { if (x == 2) { continue } r += x } finally { r += 100 } }; r`, 304},
+		{`let r = 0; for (x in [1, 2, 3]) { try { throw x } catch (e) { if (e["value"] == 2) { break } r += 1 } }; r`, 1},
+		{`let r = 0; while (r < 5) { try { throw "x" } finally { r += 1; continue } }; r`, 5},
+		{`let r = 0; for (x in [1, 2]) { try { try { continue } finally { r += 1 } } finally { r += 10 } }; r`, 22},
+		{`const f = fn() { for (x in [1, 2]) { try { return x } finally { break } }; 7 }; f()`, 7},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+
+		switch expected := tc.expected.(type) {
+		case int:
+			testIntegerObject(t)(evaluated, int64(expected))
+
+		case string:
+			str, ok := evaluated.(*object.String)
+			if !ok {
+				t.Errorf("object is not String for %q. got=%T (%+v)", tc.input, evaluated, evaluated)
+
+				continue
+			}
+
+			if str.Value != expected {
+				t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+			}
+
+		default:
+			if evaluated != nil && evaluated != NULL {
+				t.Errorf("expected no value for %q. got=%T (%+v)", tc.input, evaluated, evaluated)
+			}
+		}
+	}
+}
+
+func TestUncaughtErrors(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedMessage string
+		expectedPos     string
+		expectedStack   int
+	}{
+		{`throw "oops"`, "oops", "1:1", 0},
+		{`throw {"message": "bad", "kind": "ValueError"}`, "bad", "1:1", 0},
+		{`try { 1 } finally { throw "in finally" }`, "in finally", "1:21", 0},
+		{`try { throw "x" } catch (e) { 1 + true }`, "type mismatch: INTEGER + BOOLEAN", "1:31", 0},
+		// Errors passing through a finally block keep where they were raised.
+		{"const f = fn() { try { 1 / 0 } finally { 1 } };\nf()", "division by zero", "1:24", 1},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tc.input, evaluated, evaluated)
+
+			continue
+		}
+
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedMessage, errObj.Message)
+		}
+
+		if errObj.Pos.String() != tc.expectedPos {
+			t.Errorf("wrong error position for %q. expected=%s, got=%s", tc.input, tc.expectedPos, errObj.Pos)
+		}
+
+		if len(errObj.Stack) != tc.expectedStack {
+			t.Errorf("wrong stack trace for %q. got=%+v", tc.input, errObj.Stack)
+		}
+	}
+}
+
+func TestErrorPositions(t *testing.T) {
+	testCases := []struct {
+		input       string
+		expectedPos string
+	}{
+		{"foobar", "1:1"},
+		{"const a = 1;\nconst b = a + true;", "2:11"},
+		{"const f = fn(x) {\n    return -x;\n};\nf(true);", "2:12"},
+		{"length(1, 2)", "1:1"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+
+			continue
+		}
+
+		if errObj.Pos.String() != tc.expectedPos {
+			t.Errorf("wrong error position. expected=%s, got=%s", tc.expectedPos, errObj.Pos)
+		}
+	}
+}
+
+func TestErrorStackTrace(t *testing.T) {
+	input := `
+const inner = fn(x) { x + true };
+const outer = fn(x) { inner(x) };
+outer(1);`
+
+	evaluated := testEval(input)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	expected := []struct {
+		function string
+		pos      string
+	}{
+		{"inner", "3:23"},
+		{"outer", "4:1"},
+	}
+
+	if len(errObj.Stack) != len(expected) {
+		t.Fatalf("wrong number of stack frames. expected=%d, got=%d", len(expected), len(errObj.Stack))
+	}
+
+	for i, frame := range expected {
+		if errObj.Stack[i].Function != frame.function {
+			t.Errorf("frame %d has wrong function. expected=%q, got=%q", i, frame.function, errObj.Stack[i].Function)
+		}
+
+		if errObj.Stack[i].Pos.String() != frame.pos {
+			t.Errorf("frame %d has wrong position. expected=%s, got=%s", i, frame.pos, errObj.Stack[i].Pos)
+		}
+	}
+}
+
 func TestConstStatements(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -294,6 +775,160 @@ func TestConstStatements(t *testing.T) {
 	}
 }
 
+func TestLetStatements(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"let a = 5; a;", 5},
+		{"let a = 5; let a = a * 2; a;", 10},
+		{"let a = 5; const a = 6; a;", 6},
+		{"let t = 0; for (x in [1, 2]) { const y = x * 2; t += y; }; t", 6},
+	}
+
+	for _, tc := range testCases {
+		testIntegerObject(t)(testEval(tc.input), tc.expected)
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected int64
+	}{
+		{"let x = 1; x = 2; x", 2},
+		{"let x = 1; x = x + 1", 2},
+		{"let x = 1; x += 2", 3},
+		{"let x = 10; x -= 2; x *= 3; x /= 4; x", 6},
+		{"let a = 0; let b = 0; a = b = 3; a + b", 6},
+		{"let c = 0; const inc = fn() { c += 1 }; inc(); inc(); c", 2},
+		{"let x = 1; const f = fn(x) { x = 5; x }; f(0) + x", 6},
+		{"const counter = fn() { let c = 0; fn() { c += 1; c } }; const next = counter(); next(); next()", 2},
+		{"const a = [1, 2, 3]; a[1] = 5; a[1]", 5},
+		{"let a = [1, 2, 3]; a[2] += 10; a[2]", 13},
+		{"const a = [1]; const b = a; b[0] = 7; a[0]", 7},
+		{`let m = {}; m["a"] = 1; m["a"] += 2; m["a"]`, 3},
+		{`let m = {"a": 1}; m[true] = 2; m[true] + m["a"]`, 3},
+		{"const m = {}; m[1] = 4", 4},
+	}
+
+	for _, tc := range testCases {
+		testIntegerObject(t)(testEval(tc.input), tc.expected)
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	testCases := []struct {
+		input        string
+		expectedCode string
+	}{
+		{"1 + true", "type-mismatch"},
+		{"1 % 0", "division-by-zero"},
+		{"missing", "unknown-identifier"},
+		{"missing = 1", "unknown-identifier"},
+		{"const x = 1; x = 2", "constant-assigned"},
+		{"let a = [1]; a[5] = 1", "index-out-of-range"},
+		{"1()", "not-callable"},
+		{"length(1, 2)", "wrong-arguments"},
+		{"fn(x) { x }()", "wrong-arguments"},
+		{`import "lib.doggo" as lib;`, "misplaced-statement"},
+		{`throw "1 + true"`, "thrown"},
+	}
+
+	for _, tc := range testCases {
+		errObj, ok := testEval(tc.input).(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q", tc.input)
+
+			continue
+		}
+
+		if errObj.Code != tc.expectedCode {
+			t.Errorf("wrong code for %q. expected=%q, got=%q", tc.input, tc.expectedCode, errObj.Code)
+		}
+	}
+}
+
+func TestCyclicContainers(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"let a = [1]; a[0] = a; a", "[[...]]"},
+		{`let m = {"a": 1}; m["self"] = m; m`, "{a: 1, self: {...}}"},
+		{`let m = {}; m["self"] = m; "${m}"`, "{self: {...}}"},
+		// The containers held twice, but not by themselves, are shown twice.
+		{`let a = [1]; let m = {"a": a}; a[0] = m; [a, a]`, "[[{a: [...]}], [{a: [...]}]]"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+
+		var got string
+		if str, ok := evaluated.(*object.String); ok {
+			got = str.Value
+		} else {
+			got = evaluated.Inspect()
+		}
+
+		if got != tc.expected {
+			t.Errorf("wrong text for %q. expected=%q, got=%q", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestConstantBindings(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{"const x = 1; x = 2;", "cannot assign to constant: x"},
+		{"const x = 1; x += 2;", "cannot assign to constant: x"},
+		{"const x = 1; const f = fn() { x = 2 }; f();", "cannot assign to constant: x"},
+		{"const x = 1; const x = 2;", "cannot redeclare constant: x"},
+		{"const x = 1; let x = 2;", "cannot redeclare constant: x"},
+		{"const x = 1; for (x in [1]) { }", "cannot redeclare constant: x"},
+		{"const x = 1; try { throw 1 } catch (x) { }", "cannot redeclare constant: x"},
+		{"y = 2;", "identifier not found: y"},
+		{"length = 2;", "identifier not found: length"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tc.input, evaluated, evaluated)
+
+			continue
+		}
+
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedMessage, errObj.Message)
+		}
+	}
+
+	// The constants are checked as the code runs, so the code that doesn't run can't fail,
+	// and the errors can be caught like any other.
+	valid := []struct {
+		input    string
+		expected int64
+	}{
+		{"if (false) { const x = 1; x = 2 }; 5", 5},
+		{"if (false) { const x = 1 }; let x = 2; x", 2},
+		{"let r = 0; try { const x = 1; x = 2 } catch (e) { r = 3 }; r", 3},
+		{"const x = 1; try { 1 } catch (x) { }; x", 1},
+		{"let x = 1; x = 2; const x = 3; x", 3},
+		{"let t = 0; for (x in [1, 2]) { const y = x; t += y }; t", 3},
+		// A function can shadow a constant with its own bindings.
+		{"const x = 1; const f = fn() { let x = 2; x = 3; x }; f() + x", 4},
+	}
+
+	for _, tc := range valid {
+		testIntegerObject(t)(testEval(tc.input), tc.expected)
+	}
+}
+
 func TestFunctionObject(t *testing.T) {
 	input := "fn(x) { x + 2; };"
 
@@ -335,6 +970,101 @@ func TestFunctionApplication(t *testing.T) {
 	}
 }
 
+func TestFunctionParameters(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{"const add = fn(x, y = 2) { x + y }; add(1)", 3},
+		{"const add = fn(x, y = 2) { x + y }; add(1, 5)", 6},
+		{"const f = fn(x = 1, y = x * 10) { y }; f()", 10},
+		{"const f = fn(x = 1, y = x * 10) { y }; f(2)", 20},
+		{"const y = 7; const f = fn(x = y) { x }; f()", 7},
+		{"let calls = 0; const f = fn(x = fn() { calls += 1 }()) { x }; f(); f(); f(0); calls", 2},
+		{"const count = fn(...rest) { length(rest) }; count()", 0},
+		{"const count = fn(...rest) { length(rest) }; count(1, 2, 3)", 3},
+		{"const second = fn(first, ...rest) { rest[0] }; second(1, 2, 3)", 2},
+		{"const f = fn(a, b = 10, ...rest) { a + b + length(rest) }; f(1)", 11},
+		{"const f = fn(a, b = 10, ...rest) { a + b + length(rest) }; f(1, 2, 3, 4)", 5},
+		{"const outer = fn(x) { fn(y = x) { y } }; outer(4)()", 4},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		testIntegerObject(t)(evaluated, int64(tc.expected.(int)))
+	}
+}
+
+func TestFunctionArity(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedMessage string
+	}{
+		{
+			"const add = fn(x, y) { x + y }; add(1)",
+			"wrong number of arguments to add: want=2, got=1",
+		},
+		{
+			"const add = fn(x, y) { x + y }; add(1, 2, 3)",
+			"wrong number of arguments to add: want=2, got=3",
+		},
+		{
+			"fn() { 1 }(1)",
+			"wrong number of arguments to <anonymous>: want=0, got=1",
+		},
+		{
+			"const add = fn(x, y = 2) { x + y }; add()",
+			"wrong number of arguments to add: want=1 to 2, got=0",
+		},
+		{
+			"const f = fn(x, ...rest) { x }; f()",
+			"wrong number of arguments to f: want=at least 1, got=0",
+		},
+		{
+			"const f = fn(x = 1 + true) { x }; f()",
+			"type mismatch: INTEGER + BOOLEAN",
+		},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+
+		errObj, ok := evaluated.(*object.Error)
+		if !ok {
+			t.Errorf("no error object returned for %q. got=%T(%+v)", tc.input, evaluated, evaluated)
+
+			continue
+		}
+
+		if errObj.Message != tc.expectedMessage {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedMessage, errObj.Message)
+		}
+	}
+}
+
+func TestArityErrorPosition(t *testing.T) {
+	input := `
+const add = fn(x, y) { x + y };
+const call = fn() { add(1) };
+call();`
+
+	evaluated := testEval(input)
+
+	errObj, ok := evaluated.(*object.Error)
+	if !ok {
+		t.Fatalf("no error object returned. got=%T(%+v)", evaluated, evaluated)
+	}
+
+	// The error is raised at the call site, before entering the called function.
+	if errObj.Pos.String() != "3:21" {
+		t.Errorf("wrong error position. expected=3:21, got=%s", errObj.Pos)
+	}
+
+	if len(errObj.Stack) != 1 || errObj.Stack[0].Function != "call" {
+		t.Errorf("wrong stack trace. got=%+v", errObj.Stack)
+	}
+}
+
 func TestClosures(t *testing.T) {
 	input := `
 const newAdder = fn(x) {
@@ -375,6 +1105,40 @@ func TestStringConcatenation(t *testing.T) {
 	}
 }
 
+func TestStringEscapesAndInterpolation(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`"a\tb\n\"c\" \\ \u{1F436}"`, "a\tb\n\"c\" \\ 🐶"},
+		{"`raw \\n ${x}`", "raw \\n ${x}"},
+		{`const name = "doggo"; "hello ${name}!"`, "hello doggo!"},
+		{`"${1 + 2} ${[1, "a"]} ${true} ${1.5}"`, "3 [1, a] true 1.5"},
+		{`const m = {"k": "v"}; "${m["k"]}${ {"x": "y"}["x"] }"`, "vy"},
+		{`const greet = fn(who = "world") { "hi ${who}" }; "${greet()}, ${greet("you")}"`, "hi world, hi you"},
+		{`"\${not} ${"nested ${"deep"}"}"`, "${not} nested deep"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+
+			continue
+		}
+
+		if str.Value != tc.expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", tc.expected, str.Value)
+		}
+	}
+
+	evaluated := testEval(`"${1 + true}"`)
+	if errObj, ok := evaluated.(*object.Error); !ok || errObj.Message != "type mismatch: INTEGER + BOOLEAN" {
+		t.Errorf("wrong result for a failing interpolation. got=%T (%+v)", evaluated, evaluated)
+	}
+}
+
 func TestBuiltinFunctions(t *testing.T) {
 	testCases := []struct {
 		input    string
@@ -383,6 +1147,8 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`length("")`, 0},
 		{`length("four")`, 4},
 		{`length("hello world")`, 11},
+		{`length("héllo")`, 5},
+		{`length("🐶🐶")`, 2},
 		{`length([1, 2, 3])`, 3},
 		{`length([])`, 0},
 		{`length(1)`, "argument to 'length' is not supported, got INTEGER"},
@@ -401,6 +1167,14 @@ func TestBuiltinFunctions(t *testing.T) {
 		{`push("", 2)`, "first argument to 'push' must be of type ARRAY, got STRING"},
 		{`push([3, 4, 5])`, "wrong number of arguments. got=1, want=2"},
 		{`push([3, 4, 5], 5, 6)`, "wrong number of arguments. got=3, want=2"},
+		{`bytes("ab")`, []int{97, 98}},
+		{`bytes("é")`, []int{195, 169}},
+		{`length(bytes("héllo"))`, 6},
+		{`bytes(1)`, "argument to 'bytes' must be of type STRING, got INTEGER"},
+		{`assert(1 < 2)`, NULL},
+		{`assert(1 > 2)`, "assertion failed"},
+		{`assert({}["x"], "missing x")`, "assertion failed: missing x"},
+		{`assert()`, "wrong number of arguments. got=0, want=1 or 2"},
 	}
 
 	for _, tc := range testCases {
@@ -421,12 +1195,64 @@ func TestBuiltinFunctions(t *testing.T) {
 				t.Errorf("wrong error message. expected=%q, got=%q", expected, errObj.Message)
 			}
 
+		case []int:
+			array, ok := evaluated.(*object.Array)
+			if !ok {
+				t.Errorf("object is not Array. got=%T (%+v)", evaluated, evaluated)
+				continue
+			}
+
+			if len(array.Elements) != len(expected) {
+				t.Errorf("wrong number of elements. expected=%d, got=%d", len(expected), len(array.Elements))
+				continue
+			}
+
+			for i, el := range expected {
+				testIntegerObject(t)(array.Elements[i], int64(el))
+			}
+
 		case *object.Null:
 			testNullObject(t)(evaluated)
 		}
 	}
 }
 
+func TestStringIndexExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected interface{}
+	}{
+		{`"héllo"[1]`, "é"},
+		{`"héllo"[4]`, "o"},
+		{`const s = "🐶 doggo"; s[0] + s[length(s) - 1]`, "🐶o"},
+		{`"abc"[3]`, nil},
+		{`"abc"[-1]`, nil},
+		{`const größe = "groß"; größe[3]`, "ß"},
+	}
+
+	for _, tc := range testCases {
+		evaluated := testEval(tc.input)
+
+		expected, ok := tc.expected.(string)
+		if !ok {
+			testNullObject(t)(evaluated)
+
+			continue
+		}
+
+		str, ok := evaluated.(*object.String)
+		if !ok {
+			t.Errorf("object is not String. got=%T (%+v)", evaluated, evaluated)
+
+			continue
+		}
+
+		if str.Value != expected {
+			t.Errorf("String has wrong value. expected=%q, got=%q", expected, str.Value)
+		}
+	}
+}
+
 func TestArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -568,6 +1394,14 @@ func TestMapIndexExpressions(t *testing.T) {
 			`{5: 5}[5]`,
 			5,
 		},
+		{
+			`{5: 5}[5.0]`,
+			5,
+		},
+		{
+			`{2.5: 5}[2.5]`,
+			5,
+		},
 		{
 			`{true: 5}[true]`,
 			5,
@@ -576,6 +1410,18 @@ func TestMapIndexExpressions(t *testing.T) {
 			`{false: 5}[false]`,
 			5,
 		},
+		{
+			`{"foo": 5}.foo`,
+			5,
+		},
+		{
+			`{"foo": 5}.bar`,
+			nil,
+		},
+		{
+			`const m = {"inner": {}}; m.inner.foo = 5; m.inner.foo += 1; m["inner"]["foo"]`,
+			6,
+		},
 	}
 
 	for _, tc := range testCases {
@@ -0,0 +1,673 @@
+// Package format prints doggo code in its canonical style: four spaces of indentation, one statement
+// per line, and the arrays, maps and call arguments that don't fit on a line broken up, one member per line.
+// The comments of the code are kept, along with single blank lines between statements.
+package format
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/parser"
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+const (
+	// Width is the length the lines are kept within, as long as they can be broken up.
+	// The comma or the semicolon ending a line isn't counted.
+	Width = 80
+	// indentation is what a line is indented with, for every level of nesting.
+	indentation = "    "
+)
+
+// Source formats the code. Code with syntax errors can't be formatted, so its errors are returned instead.
+func Source(fileName, code string) (string, []string) {
+	l := lexer.NewWithFilename(fileName, code)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if errors := p.Errors(); len(errors) != 0 {
+		return "", errors
+	}
+
+	pr := &printer{src: code, comments: comments(code)}
+	pr.program(program)
+
+	return pr.buf.String(), nil
+}
+
+// comments returns the comments of the code, in order.
+func comments(code string) []token.Token {
+	l := lexer.New(code)
+	l.SetKeepComments(true)
+
+	var comments []token.Token
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		if tok.Type == token.COMMENT {
+			comments = append(comments, tok)
+		}
+	}
+
+	return comments
+}
+
+type printer struct {
+	src      string
+	comments []token.Token
+	buf      bytes.Buffer
+
+	// indent is the nesting level of the current line.
+	indent int
+	// next is the index of the first comment not printed yet.
+	next int
+	// lastLine is the line of the source the last statement or comment printed ended on, to keep the blank
+	// line after it, if any. It's 0 where blank lines are dropped, like at the start of a block.
+	lastLine int
+	// flat makes the lists print on a single line, without trying out the layouts breaking them up.
+	flat bool
+}
+
+// mark is the state of the printer at some point, to go back to when a layout turns out not to fit.
+type mark struct {
+	len      int
+	next     int
+	lastLine int
+}
+
+func (p *printer) mark() mark {
+	return mark{len: p.buf.Len(), next: p.next, lastLine: p.lastLine}
+}
+
+func (p *printer) reset(m mark) {
+	p.buf.Truncate(m.len)
+	p.next = m.next
+	p.lastLine = m.lastLine
+}
+
+func (p *printer) write(s string) {
+	p.buf.WriteString(s)
+}
+
+func (p *printer) newline() {
+	p.buf.WriteByte('\n')
+	p.write(strings.Repeat(indentation, p.indent))
+}
+
+// linebreak starts the line of a statement or a comment found on the given line of the source,
+// leaving a blank line before it if the source had one.
+func (p *printer) linebreak(line int) {
+	if p.buf.Len() == 0 {
+		return
+	}
+
+	if p.lastLine > 0 && line > p.lastLine+1 {
+		p.buf.WriteByte('\n')
+	}
+	p.newline()
+}
+
+// fits reports whether what was printed since the mark is on a single line, within the width.
+func (p *printer) fits(m mark) bool {
+	b := p.buf.Bytes()
+
+	return bytes.IndexByte(b[m.len:], '\n') < 0 && utf8.RuneCount(b[bytes.LastIndexByte(b, '\n')+1:]) <= Width
+}
+
+// firstLineFits reports whether the line the mark is on is within the width.
+func (p *printer) firstLineFits(m mark) bool {
+	b := p.buf.Bytes()
+
+	start := bytes.LastIndexByte(b[:m.len], '\n') + 1
+	end := len(b)
+	if i := bytes.IndexByte(b[m.len:], '\n'); i >= 0 {
+		end = m.len + i
+	}
+
+	return utf8.RuneCount(b[start:end]) <= Width
+}
+
+// hasComments reports whether there are comments left to print between the offsets.
+func (p *printer) hasComments(from, to int) bool {
+	for _, c := range p.comments[p.next:] {
+		if c.Pos.Offset >= to {
+			break
+		}
+
+		if c.Pos.Offset > from {
+			return true
+		}
+	}
+
+	return false
+}
+
+// leadingComments prints the comments found before the offset, each on its own line.
+func (p *printer) leadingComments(offset int) {
+	for ; p.next < len(p.comments) && p.comments[p.next].Pos.Offset < offset; p.next++ {
+		c := p.comments[p.next]
+
+		p.linebreak(c.Pos.Line)
+		p.write(c.Literal)
+		p.lastLine = c.End.Line
+	}
+}
+
+// trailingComments prints the comments following a node on the line it ends on, up to the offset.
+// The comments left over from within the node, like the ones between the operands of an expression, go there too.
+func (p *printer) trailingComments(end token.Position, limit int) {
+	afterLineComment := false
+
+	for ; p.next < len(p.comments); p.next++ {
+		c := p.comments[p.next]
+		if c.Pos.Offset >= limit || c.Pos.Offset >= end.Offset && c.Pos.Line != end.Line {
+			return
+		}
+
+		// Nothing can follow a line comment on its line.
+		if afterLineComment {
+			p.newline()
+		} else {
+			p.write(" ")
+		}
+
+		p.write(c.Literal)
+		afterLineComment = strings.HasPrefix(c.Literal, "//")
+		if c.End.Line > p.lastLine {
+			p.lastLine = c.End.Line
+		}
+	}
+}
+
+func (p *printer) program(program *ast.Program) {
+	end := len(p.src) + 1
+
+	p.statements(program.Statements, end)
+	p.leadingComments(end)
+
+	if p.buf.Len() > 0 {
+		p.buf.WriteByte('\n')
+	}
+}
+
+// statements prints the statements one per line, along with the comments around them, up to the offset.
+func (p *printer) statements(statements []ast.Statement, limit int) {
+	for i, s := range statements {
+		var next ast.Statement
+		nextOffset := limit
+		if i+1 < len(statements) {
+			next = statements[i+1]
+			nextOffset = next.Pos().Offset
+		}
+
+		p.leadingComments(s.Pos().Offset)
+		p.linebreak(s.Pos().Line)
+
+		p.statement(s)
+		if needsSemicolon(s, next) {
+			p.write(";")
+		}
+
+		p.lastLine = s.End().Line
+		p.trailingComments(s.End(), nextOffset)
+	}
+}
+
+func (p *printer) block(b *ast.BlockStatement) {
+	if len(b.Statements) == 0 && !p.hasComments(b.Token.Pos.Offset, b.Rbrace.Pos.Offset) {
+		p.write("{}")
+
+		return
+	}
+
+	p.write("{")
+	p.indent++
+	p.lastLine = 0
+
+	p.statements(b.Statements, b.Rbrace.Pos.Offset)
+	p.leadingComments(b.Rbrace.Pos.Offset)
+
+	p.indent--
+	p.newline()
+	p.write("}")
+}
+
+// functionBody prints the body of a function. Short bodies written on a single line stay that way,
+// like in 'fn(x) { x * 2 }' or 'fn(x) { return x * 2; }', as long as they fit.
+func (p *printer) functionBody(b *ast.BlockStatement) {
+	if isOneLiner(b) && !p.hasComments(b.Token.Pos.Offset, b.Rbrace.Pos.Offset) {
+		m := p.mark()
+
+		p.write("{ ")
+		p.statement(b.Statements[0])
+		// The value of the function is left without a semicolon.
+		if _, ok := b.Statements[0].(*ast.ExpressionStatement); !ok {
+			p.write(";")
+		}
+		p.write(" }")
+
+		if p.flat || p.fits(m) {
+			return
+		}
+		p.reset(m)
+	}
+
+	p.block(b)
+}
+
+// isOneLiner reports whether the block was written on a single line, holding a single statement without a block.
+func isOneLiner(b *ast.BlockStatement) bool {
+	if len(b.Statements) != 1 || b.Token.Pos.Line != b.Rbrace.Pos.Line {
+		return false
+	}
+
+	return needsSemicolon(b.Statements[0], nil)
+}
+
+// needsSemicolon reports whether the statement ends with a semicolon. The ones ending with a block don't,
+// unless the next statement would otherwise carry on with an if expression, like '[1, 2]' indexing it.
+func needsSemicolon(s, next ast.Statement) bool {
+	switch s := s.(type) {
+	case *ast.WhileStatement, *ast.ForStatement, *ast.TryStatement:
+		return false
+
+	case *ast.ExpressionStatement:
+		if _, ok := s.Expression.(*ast.IfExpression); ok {
+			return next != nil && continuesExpression(next)
+		}
+	}
+
+	return true
+}
+
+// continuesExpression reports whether the statement starts with a token that would carry on with
+// the expression before it, like an opening parenthesis calling it.
+func continuesExpression(s ast.Statement) bool {
+	es, ok := s.(*ast.ExpressionStatement)
+	if !ok {
+		return false
+	}
+
+	e := es.Expression
+	for {
+		switch node := e.(type) {
+		case *ast.ArrayLiteral:
+			return true
+
+		case *ast.PrefixExpression:
+			return node.Operator == token.MINUS
+
+		case *ast.InfixExpression:
+			if needsParens(node.Left, precedence(node), false) {
+				return true
+			}
+			e = node.Left
+
+		case *ast.CallExpression:
+			if needsParens(node.Function, parser.INDEX, false) {
+				return true
+			}
+			e = node.Function
+
+		case *ast.IndexExpression:
+			if needsParens(node.Left, parser.INDEX, false) {
+				return true
+			}
+			e = node.Left
+
+		case *ast.MemberExpression:
+			if needsParens(node.Object, parser.INDEX, false) {
+				return true
+			}
+			e = node.Object
+
+		case *ast.AssignExpression:
+			e = node.Target
+
+		default:
+			return false
+		}
+	}
+}
+
+func (p *printer) statement(s ast.Statement) {
+	switch s := s.(type) {
+	case *ast.ExpressionStatement:
+		p.expression(s.Expression)
+
+	case *ast.ConstStatement:
+		p.write("const " + s.Name.Value + " = ")
+		p.expression(s.Value)
+
+	case *ast.LetStatement:
+		p.write("let " + s.Name.Value + " = ")
+		p.expression(s.Value)
+
+	case *ast.ReturnStatement:
+		p.write("return ")
+		p.expression(s.ReturnValue)
+
+	case *ast.ThrowStatement:
+		p.write("throw ")
+		p.expression(s.Value)
+
+	case *ast.BreakStatement:
+		p.write("break")
+
+	case *ast.ContinueStatement:
+		p.write("continue")
+
+	case *ast.WhileStatement:
+		p.write("while (")
+		p.expression(s.Condition)
+		p.write(") ")
+		p.block(s.Body)
+
+	case *ast.ForStatement:
+		p.write("for (")
+		if s.Key != nil {
+			p.write(s.Key.Value + ", ")
+		}
+		p.write(s.Value.Value + " in ")
+		p.expression(s.Iterable)
+		p.write(") ")
+		p.block(s.Body)
+
+	case *ast.TryStatement:
+		p.write("try ")
+		p.block(s.Block)
+
+		if s.Catch != nil {
+			p.write(" catch (" + s.Param.Value + ") ")
+			p.block(s.Catch)
+		}
+
+		if s.Finally != nil {
+			p.write(" finally ")
+			p.block(s.Finally)
+		}
+
+	case *ast.ImportStatement:
+		p.write("import " + p.source(s.Path) + " as " + s.Name.Value)
+
+	case *ast.ExportStatement:
+		p.write("export ")
+		p.statement(s.Declaration)
+	}
+}
+
+// source returns the node as it is written in the source, like a string with its quotes and escape sequences.
+func (p *printer) source(node ast.Node) string {
+	return p.src[node.Pos().Offset:node.End().Offset]
+}
+
+func (p *printer) expression(e ast.Expression) {
+	switch e := e.(type) {
+	case *ast.Identifier:
+		p.write(e.Value)
+
+	case *ast.IntegerLiteral, *ast.FloatLiteral, *ast.Boolean:
+		p.write(e.TokenLiteral())
+
+	case *ast.StringLiteral:
+		p.write(p.source(e))
+
+	case *ast.TemplateLiteral:
+		p.template(e)
+
+	case *ast.PrefixExpression:
+		p.write(e.Operator)
+		p.operand(e.Right, parser.PREFIX, false)
+
+	case *ast.InfixExpression:
+		precedence := precedence(e)
+		p.operand(e.Left, precedence, false)
+		p.write(" " + e.Operator + " ")
+		p.operand(e.Right, precedence, true)
+
+	case *ast.AssignExpression:
+		p.expression(e.Target)
+		p.write(" " + e.Operator + " ")
+		p.expression(e.Value)
+
+	case *ast.IfExpression:
+		p.write("if (")
+		p.expression(e.Condition)
+		p.write(") ")
+		p.block(e.Consequence)
+
+		if e.Alternative != nil {
+			p.write(" else ")
+			p.block(e.Alternative)
+		}
+
+	case *ast.FunctionLiteral:
+		p.function(e)
+
+	case *ast.CallExpression:
+		p.operand(e.Function, parser.INDEX, false)
+		p.list(e.Token, e.Rparen, p.expressionItems(e.Arguments), false)
+
+	case *ast.ArrayLiteral:
+		p.list(e.Token, e.Rbracket, p.expressionItems(e.Elements), false)
+
+	case *ast.MapLiteral:
+		p.mapLiteral(e)
+
+	case *ast.IndexExpression:
+		p.operand(e.Left, parser.INDEX, false)
+		p.write("[")
+		p.expression(e.Index)
+		p.write("]")
+
+	case *ast.MemberExpression:
+		p.operand(e.Object, parser.INDEX, false)
+		p.write("." + e.Property.Value)
+	}
+}
+
+// operand prints the operand of an operator binding as tightly as the given precedence,
+// wrapping it in parentheses if it binds looser. Operators are left associative, so right operands
+// binding just as tightly are wrapped too.
+func (p *printer) operand(e ast.Expression, precedence int, right bool) {
+	if !needsParens(e, precedence, right) {
+		p.expression(e)
+
+		return
+	}
+
+	p.write("(")
+	p.expression(e)
+	p.write(")")
+}
+
+func needsParens(e ast.Expression, operatorPrecedence int, right bool) bool {
+	precedence := precedence(e)
+
+	return precedence < operatorPrecedence || right && precedence == operatorPrecedence
+}
+
+// precedence returns how tightly the expression binds its operands, if it has any.
+func precedence(e ast.Expression) int {
+	switch e := e.(type) {
+	case *ast.InfixExpression:
+		return parser.Precedence(e.Token.Type)
+
+	case *ast.AssignExpression:
+		return parser.ASSIGN
+
+	case *ast.PrefixExpression:
+		return parser.PREFIX
+	}
+
+	return parser.INDEX
+}
+
+// template prints a string with interpolations. Its text is printed as it is written in the source.
+func (p *printer) template(tl *ast.TemplateLiteral) {
+	p.write(`"`)
+
+	for _, part := range tl.Parts {
+		// Interpolated strings are expressions like any other, only the pieces of the template are its text.
+		if text, ok := part.(*ast.StringLiteral); ok && text.Token.Type != token.STRING {
+			// The text is surrounded by a quote, or by the brace closing an interpolation, and by
+			// a quote, or by the '${' opening the next interpolation.
+			source := p.source(text)[1:]
+			if text.Token.Type == token.TEMPLATE_END {
+				p.write(strings.TrimSuffix(source, `"`))
+			} else {
+				p.write(strings.TrimSuffix(source, "${"))
+			}
+
+			continue
+		}
+
+		p.write("${")
+		p.expression(part)
+		p.write("}")
+	}
+
+	p.write(`"`)
+}
+
+func (p *printer) function(fl *ast.FunctionLiteral) {
+	p.write("fn(")
+
+	for i, param := range fl.Parameters {
+		if i > 0 {
+			p.write(", ")
+		}
+		p.write(param.Value)
+
+		if i < len(fl.Defaults) && fl.Defaults[i] != nil {
+			p.write(" = ")
+			p.expression(fl.Defaults[i])
+		}
+	}
+
+	if fl.Rest != nil {
+		if len(fl.Parameters) > 0 {
+			p.write(", ")
+		}
+		p.write(token.ELLIPSIS + fl.Rest.Value)
+	}
+
+	p.write(") ")
+	p.functionBody(fl.Body)
+}
+
+func (p *printer) mapLiteral(ml *ast.MapLiteral) {
+	keys := ml.SortedKeys()
+
+	items := make([]item, len(keys))
+	for i, key := range keys {
+		key, value := key, ml.Pairs[key]
+		items[i] = item{pos: key.Pos(), end: value.End(), print: func() {
+			p.expression(key)
+			p.write(": ")
+			p.expression(value)
+		}}
+	}
+
+	// Maps written over multiple lines stay that way, even if they'd fit on one.
+	expand := len(keys) > 0 && keys[0].Pos().Line > ml.Token.Pos.Line
+
+	p.list(ml.Token, ml.Rbrace, items, expand)
+}
+
+// item is a member of a list, like an argument of a call.
+type item struct {
+	pos   token.Position
+	end   token.Position
+	print func()
+	// function is set for the functions, which can be left spanning multiple lines at the end of a list.
+	function bool
+}
+
+func (p *printer) expressionItems(expressions []ast.Expression) []item {
+	items := make([]item, len(expressions))
+	for i, e := range expressions {
+		e := e
+		_, function := e.(*ast.FunctionLiteral)
+		items[i] = item{pos: e.Pos(), end: e.End(), print: func() { p.expression(e) }, function: function}
+	}
+
+	return items
+}
+
+// list prints the items between the brackets. They stay on a single line if they fit. Otherwise, when the last
+// item is a function spanning multiple lines, the others stay on the line of the opening bracket.
+// Otherwise, every item goes on its own line.
+func (p *printer) list(open, close token.Token, items []item, expand bool) {
+	flat := p.flat
+
+	if !expand && !p.hasComments(open.Pos.Offset, close.Pos.Offset) {
+		m := p.mark()
+
+		p.flat = true
+		p.write(open.Literal)
+		for i, it := range items {
+			if i > 0 {
+				p.write(", ")
+			}
+			it.print()
+		}
+		p.write(close.Literal)
+		p.flat = flat
+
+		if flat || p.fits(m) {
+			return
+		}
+		p.reset(m)
+	}
+
+	if n := len(items); !expand && !flat && n > 0 && items[n-1].function &&
+		!p.hasComments(open.Pos.Offset, items[n-1].pos.Offset) && !p.hasComments(items[n-1].end.Offset, close.Pos.Offset) {
+		m := p.mark()
+
+		p.flat = true
+		p.write(open.Literal)
+		for _, it := range items[:n-1] {
+			it.print()
+			p.write(", ")
+		}
+		p.flat = flat
+
+		last := p.buf.Len()
+		items[n-1].print()
+		p.write(close.Literal)
+
+		if bytes.IndexByte(p.buf.Bytes()[m.len:last], '\n') < 0 && p.firstLineFits(m) {
+			return
+		}
+		p.reset(m)
+	}
+
+	p.write(open.Literal)
+	p.indent++
+
+	for i, it := range items {
+		limit := close.Pos.Offset
+		if i+1 < len(items) {
+			limit = items[i+1].pos.Offset
+		}
+
+		p.lastLine = 0
+		p.leadingComments(it.pos.Offset)
+		p.newline()
+
+		it.print()
+		if i+1 < len(items) {
+			p.write(",")
+		}
+		p.trailingComments(it.end, limit)
+	}
+
+	p.lastLine = 0
+	p.leadingComments(close.Pos.Offset)
+
+	p.indent--
+	p.newline()
+	p.write(close.Literal)
+}
@@ -0,0 +1,195 @@
+package format
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/parser"
+)
+
+func TestSource(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"", ""},
+		{"let x=1+2*3", "let x = 1 + 2 * 3;\n"},
+		{"const a = 1; let b = a\nb", "const a = 1;\nlet b = a;\nb;\n"},
+		{"(1 + 2) * 3; 1 - (2 - 3); (1 - 2) - 3; -(a + b); !(-a); (-a)[0]; -a[0]", "(1 + 2) * 3;\n1 - (2 - 3);\n1 - 2 - 3;\n-(a + b);\n!-a;\n(-a)[0];\n-a[0];\n"},
+		{"a = b = 1; (a = 1) + 2; x.y[0] += 2", "a = b = 1;\n(a = 1) + 2;\nx.y[0] += 2;\n"},
+		{`"a ${"b ${c}"} ${"d"}"`, `"a ${"b ${c}"} ${"d"}";` + "\n"},
+		{"1.50; true; `raw \\n ${x}`; \"esc\\t\\\"${x}\\${y}\\u{1F436}\"", "1.50;\ntrue;\n`raw \\n ${x}`;\n\"esc\\t\\\"${x}\\${y}\\u{1F436}\";\n"},
+		{"const a = 1;\n\n\n\nconst b = 2;\nconst c = 3;", "const a = 1;\n\nconst b = 2;\nconst c = 3;\n"},
+		{
+			"const f = fn(a, b = 2, ...rest) { return a + b; };",
+			"const f = fn(a, b = 2, ...rest) { return a + b; };\n",
+		},
+		{
+			"const f = fn(a) {\nreturn a;\n};",
+			"const f = fn(a) {\n    return a;\n};\n",
+		},
+		{"map(xs, fn(x) { x * 2 })", "map(xs, fn(x) { x * 2 });\n"},
+		{"const f = fn() {}; const g = fn() { if (x) { 1 } };", "const f = fn() {};\nconst g = fn() {\n    if (x) {\n        1;\n    }\n};\n"},
+		{
+			"if (x > 1) { print(x) } else { print(1) }",
+			"if (x > 1) {\n    print(x);\n} else {\n    print(1);\n}\n",
+		},
+		{
+			"if (x) { 1 }; [1, 2].length; if (x) { 1 }; -1; if (x) { 1 }; (a + b)(1); if (x) {}; a",
+			"if (x) {\n    1;\n};\n[1, 2].length;\nif (x) {\n    1;\n};\n-1;\nif (x) {\n    1;\n};\n(a + b)(1);\nif (x) {}\na;\n",
+		},
+		{
+			"while (i < 10) { i += 1; if (i == 5) { break; } }",
+			"while (i < 10) {\n    i += 1;\n    if (i == 5) {\n        break;\n    }\n}\n",
+		},
+		{
+			"for (k, v in m) { continue } for (x in xs) {}",
+			"for (k, v in m) {\n    continue;\n}\nfor (x in xs) {}\n",
+		},
+		{
+			"try { throw \"x\" } catch (e) { print(e) } finally { done() }; try { f() } finally {}",
+			"try {\n    throw \"x\";\n} catch (e) {\n    print(e);\n} finally {\n    done();\n}\ntry {\n    f();\n} finally {}\n",
+		},
+		{
+			"import \"lib/util.doggo\" as util; export const x = util.f(1); export let y = 2",
+			"import \"lib/util.doggo\" as util;\nexport const x = util.f(1);\nexport let y = 2;\n",
+		},
+		{"{\"a\": 1, \"b\": [1, 2], 3: {}}", "{\"a\": 1, \"b\": [1, 2], 3: {}};\n"},
+		{"const m = {\n\"a\": 1, \"b\": 2}", "const m = {\n    \"a\": 1,\n    \"b\": 2\n};\n"},
+		{
+			"const xs = [1111111111, 2222222222, 3333333333, 4444444444, 5555555555, 6666666666, 7777777777];",
+			"const xs = [\n    1111111111,\n    2222222222,\n    3333333333,\n    4444444444,\n    5555555555,\n    6666666666,\n    7777777777\n];\n",
+		},
+		{
+			"someFunction(firstArgument, secondArgument, thirdArgument, [1, 2, 3], [fourth, fifth, sixth])",
+			"someFunction(\n    firstArgument,\n    secondArgument,\n    thirdArgument,\n    [1, 2, 3],\n    [fourth, fifth, sixth]\n);\n",
+		},
+		{
+			"result = callSomething(argumentNumberOne, argumentNumberTwo, fn(value) { return value * 2; });",
+			"result = callSomething(argumentNumberOne, argumentNumberTwo, fn(value) {\n    return value * 2;\n});\n",
+		},
+		{
+			"const f = fn() { return someFunction(firstArgument, secondArgument, thirdArgument, fourthArgument); };",
+			"const f = fn() {\n    return someFunction(\n        firstArgument,\n        secondArgument,\n        thirdArgument,\n        fourthArgument\n    );\n};\n",
+		},
+		{
+			"// the header\n\n/* a block\n   comment */\nlet x = 1; // one\nlet y = 2; /* two */ // more\n\n// the end",
+			"// the header\n\n/* a block\n   comment */\nlet x = 1; // one\nlet y = 2; /* two */ // more\n\n// the end\n",
+		},
+		{
+			"const f = fn() {\n\n  // first\n  a; // a\n\n  b;\n  // last\n\n};",
+			"const f = fn() {\n    // first\n    a; // a\n\n    b;\n    // last\n};\n",
+		},
+		{"if (x) { // why\n}", "if (x) {\n    // why\n}\n"},
+		{
+			"f(a, // first\n  b /* second */, c);",
+			"f(\n    a, // first\n    b, /* second */\n    c\n);\n",
+		},
+		{"f(1 + /* one */ 2);", "f(\n    1 + 2 /* one */\n);\n"},
+		{"x = 1 + /* one */ 2;", "x = 1 + 2; /* one */\n"},
+		{"a + // one\nb // two\n", "a + b; // one\n// two\n"},
+	}
+
+	for _, tc := range testCases {
+		formatted, errors := Source("", tc.input)
+		if len(errors) != 0 {
+			t.Errorf("unexpected errors formatting %q: %v", tc.input, errors)
+
+			continue
+		}
+
+		if formatted != tc.expected {
+			t.Errorf("wrong formatting of %q.\nexpected=%q\ngot=     %q", tc.input, tc.expected, formatted)
+
+			continue
+		}
+
+		testFormatted(t, tc.input, formatted)
+	}
+}
+
+func TestSourceErrors(t *testing.T) {
+	formatted, errors := Source("main.doggo", "let = 1;")
+	if len(errors) == 0 {
+		t.Fatalf("expected errors, got the code formatted: %q", formatted)
+	}
+
+	expected := "main.doggo:1:5: expected a name, got '=' instead"
+	if errors[0] != expected {
+		t.Errorf("wrong error. expected=%q, got=%q", expected, errors[0])
+	}
+}
+
+func TestExamples(t *testing.T) {
+	fileNames, err := filepath.Glob(filepath.Join("..", "..", "examples", "*", "*.doggo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	more, _ := filepath.Glob(filepath.Join("..", "..", "examples", "*.doggo"))
+	fileNames = append(fileNames, more...)
+	if len(fileNames) == 0 {
+		t.Fatal("no examples found")
+	}
+
+	for _, fileName := range fileNames {
+		code, err := ioutil.ReadFile(fileName)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		formatted, errors := Source(fileName, string(code))
+		if len(errors) != 0 {
+			t.Errorf("unexpected errors formatting %s: %v", fileName, errors)
+
+			continue
+		}
+
+		if formatted != string(code) {
+			t.Errorf("%s is not formatted.\nexpected=%q\ngot=     %q", fileName, formatted, string(code))
+		}
+
+		testFormatted(t, string(code), formatted)
+	}
+}
+
+// testFormatted checks that the formatted code means the same as the original one, and that it's formatted already.
+func testFormatted(t *testing.T, input, formatted string) {
+	t.Helper()
+
+	if dump(input) != dump(formatted) {
+		t.Errorf("formatting %q changed its meaning.\nexpected=%s\ngot=%s", input, dump(input), dump(formatted))
+	}
+
+	if again, _ := Source("", formatted); again != formatted {
+		t.Errorf("formatting %q again changed it.\nexpected=%q\ngot=     %q", input, formatted, again)
+	}
+
+	if commentText(input) != commentText(formatted) {
+		t.Errorf("formatting %q changed its comments.\nexpected=%q\ngot=     %q", input, commentText(input), commentText(formatted))
+	}
+}
+
+// commentText joins the comments of the code.
+func commentText(code string) string {
+	var text []string
+	for _, c := range comments(code) {
+		text = append(text, c.Literal)
+	}
+
+	return strings.Join(text, "\n")
+}
+
+var positions = regexp.MustCompile(` \d+:\d+\n`)
+
+// dump renders the syntax tree of the code, without the positions of its nodes.
+func dump(code string) string {
+	program := parser.New(lexer.New(code)).ParseProgram()
+
+	return positions.ReplaceAllString(ast.Dump(program), "\n")
+}
@@ -1,32 +1,91 @@
 package lexer
 
 import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/axbarsan/doggo/internal/diag"
 	"github.com/axbarsan/doggo/internal/token"
 )
 
 type Lexer struct {
 	input string
-	// position is the last read position.
+	// filename is the name of the source file, used in token positions.
+	filename string
+	// position is the last read position, in bytes.
 	position int
-	// readPosition is the position that we're gonna read from next.
+	// readPosition is the position that we're gonna read from next, in bytes.
 	readPosition int
-	// ch is the current char under examination.
-	ch byte
+	// ch is the current char under examination, decoded from UTF-8.
+	ch rune
+	// line is the line of the current char, starting at 1.
+	line int
+	// column is the column of the current char, counted in chars and starting at 1.
+	column int
+	// keepComments makes the lexer return comments as tokens, instead of skipping them.
+	keepComments bool
+	// templates holds, for every string interpolation being read, the number of braces opened within it.
+	templates []int
+
+	diagnostics []*diag.Diagnostic
 }
 
 // The lexer will parse the source code and extract known tokens, which will be later turned into the AST of the program.
 func New(input string) *Lexer {
+	return NewWithFilename("", input)
+}
+
+// NewWithFilename works like New, but every token position will also carry the given file name.
+func NewWithFilename(filename, input string) *Lexer {
 	l := &Lexer{
-		input: input,
+		input:    input,
+		filename: filename,
+		line:     1,
 	}
 	l.readChar()
 
 	return l
 }
 
+// Errors returns the problems found in the source code, like unterminated strings, prefixed by their positions.
+func (l *Lexer) Errors() []string {
+	return diag.Errors(l.diagnostics)
+}
+
+// Diagnostics returns the problems found in the source code.
+func (l *Lexer) Diagnostics() []*diag.Diagnostic {
+	return l.diagnostics
+}
+
+// errorAt records an error about the span of the code.
+func (l *Lexer) errorAt(code string, span diag.Span, format string, a ...interface{}) {
+	l.diagnostics = append(l.diagnostics, diag.Errorf(code, span, format, a...))
+}
+
+// SetKeepComments makes the lexer return the comments as 'token.COMMENT' tokens,
+// for tools that need them, like formatters. They are skipped by default.
+func (l *Lexer) SetKeepComments(keep bool) {
+	l.keepComments = keep
+}
+
 func (l *Lexer) NextToken() token.Token {
-	l.skipWhitespace()
+	for {
+		l.skipWhitespace()
+
+		pos := l.currentPosition()
+		tok := l.readToken()
+		tok.Pos = pos
+		tok.End = l.currentPosition()
+
+		if tok.Type != token.COMMENT || l.keepComments {
+			return tok
+		}
+	}
+}
 
+func (l *Lexer) readToken() token.Token {
 	var tok token.Token
 
 	// TODO: After converting types to integers, use 'iota' to categorize token types and parse this easier.
@@ -38,9 +97,17 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.ASSIGN, string(l.ch))
 		}
 	case '+':
-		tok = newToken(token.PLUS, string(l.ch))
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.PLUS_ASSIGN)
+		} else {
+			tok = newToken(token.PLUS, string(l.ch))
+		}
 	case '-':
-		tok = newToken(token.MINUS, string(l.ch))
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.MINUS_ASSIGN)
+		} else {
+			tok = newToken(token.MINUS, string(l.ch))
+		}
 	case '!':
 		if l.peekChar() == '=' {
 			tok = l.makeTwoCharToken(token.NOT_EQ)
@@ -48,16 +115,41 @@ func (l *Lexer) NextToken() token.Token {
 			tok = newToken(token.BANG, string(l.ch))
 		}
 	case '*':
-		tok = newToken(token.ASTERISK, string(l.ch))
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.ASTERISK_ASSIGN)
+		} else {
+			tok = newToken(token.ASTERISK, string(l.ch))
+		}
 	case '/':
-		tok = newToken(token.SLASH, string(l.ch))
+		if l.peekChar() == '/' {
+			tok.Type = token.COMMENT
+			tok.Literal = l.readLineComment()
+
+			return tok
+		} else if l.peekChar() == '*' {
+			return l.readBlockComment()
+		} else if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.SLASH_ASSIGN)
+		} else {
+			tok = newToken(token.SLASH, string(l.ch))
+		}
+	case '%':
+		if l.peekChar() == '=' {
+			tok = l.makeTwoCharToken(token.PERCENT_ASSIGN)
+		} else {
+			tok = newToken(token.PERCENT, string(l.ch))
+		}
 	case '<':
 		tok = newToken(token.LT, string(l.ch))
 	case '>':
 		tok = newToken(token.GT, string(l.ch))
 	case '"':
+		return l.readStringPart(token.STRING, token.TEMPLATE_START)
+	case '`':
 		tok.Type = token.STRING
-		tok.Literal = l.readString()
+		tok.Literal = l.readRawString()
+
+		return tok
 	case ',':
 		tok = newToken(token.COMMA, string(l.ch))
 	case ';':
@@ -67,8 +159,20 @@ func (l *Lexer) NextToken() token.Token {
 	case ')':
 		tok = newToken(token.RPAREN, string(l.ch))
 	case '{':
+		if len(l.templates) > 0 {
+			l.templates[len(l.templates)-1]++
+		}
 		tok = newToken(token.LBRACE, string(l.ch))
 	case '}':
+		// A brace without an opening one in the interpolation resumes the string around it.
+		if depth := len(l.templates); depth > 0 {
+			if l.templates[depth-1] == 0 {
+				l.templates = l.templates[:depth-1]
+
+				return l.readStringPart(token.TEMPLATE_END, token.TEMPLATE_MIDDLE)
+			}
+			l.templates[depth-1]--
+		}
 		tok = newToken(token.RBRACE, string(l.ch))
 	case '[':
 		tok = newToken(token.LBRACKET, string(l.ch))
@@ -76,6 +180,14 @@ func (l *Lexer) NextToken() token.Token {
 		tok = newToken(token.RBRACKET, string(l.ch))
 	case ':':
 		tok = newToken(token.COLON, string(l.ch))
+	case '.':
+		if strings.HasPrefix(l.input[l.position:], token.ELLIPSIS) {
+			l.readChar()
+			l.readChar()
+			tok = newToken(token.ELLIPSIS, token.ELLIPSIS)
+		} else {
+			tok = newToken(token.DOT, string(l.ch))
+		}
 	case 0:
 		tok.Literal = ""
 		tok.Type = token.EOF
@@ -87,8 +199,7 @@ func (l *Lexer) NextToken() token.Token {
 			// Return early because we already read the next char in the 'readIdentifier' method.
 			return tok
 		} else if isDigit(l.ch) {
-			tok.Literal = l.readWithValidator(isDigit)
-			tok.Type = token.INT
+			tok.Type, tok.Literal = l.readNumber()
 
 			return tok
 		}
@@ -101,22 +212,53 @@ func (l *Lexer) NextToken() token.Token {
 	return tok
 }
 
-func (l *Lexer) peekChar() byte {
-	if l.readPosition < len(l.input) {
-		return l.input[l.readPosition] // Current character.
+func (l *Lexer) peekChar() rune {
+	ch, _ := l.decodeChar(l.readPosition)
+
+	return ch
+}
+
+// decodeChar returns the char at the given position, along with its size in bytes.
+// Bytes that are not valid UTF-8 are decoded as 'utf8.RuneError'.
+func (l *Lexer) decodeChar(position int) (rune, int) {
+	if position < len(l.input) {
+		return utf8.DecodeRuneInString(l.input[position:])
 	}
 
-	return 0 // ASCII code for the 'NUL' character, meaning: Nothing read yet, or in this case: EOF
+	return 0, 1 // The 'NUL' character, meaning: Nothing read yet, or in this case: EOF
 }
 
 func (l *Lexer) readChar() {
-	l.ch = l.peekChar()
+	// Stay on the EOF position, so it can be reported correctly.
+	if l.readPosition > len(l.input) {
+		return
+	}
+
+	if l.ch == '\n' {
+		l.line++
+		l.column = 0
+	}
+
+	ch, size := l.decodeChar(l.readPosition)
+	l.ch = ch
+	l.column++
 
 	l.position = l.readPosition
-	l.readPosition++
+	l.readPosition += size
 }
 
-func (l *Lexer) readWithValidator(v func(c byte) bool) string {
+func (l *Lexer) currentPosition() token.Position {
+	pos := token.Position{
+		Filename: l.filename,
+		Offset:   l.position,
+		Line:     l.line,
+		Column:   l.column,
+	}
+
+	return pos
+}
+
+func (l *Lexer) readWithValidator(v func(c rune) bool) string {
 	pos := l.position
 
 	for v(l.ch) {
@@ -126,6 +268,77 @@ func (l *Lexer) readWithValidator(v func(c byte) bool) string {
 	return l.input[pos:l.position]
 }
 
+// readNumber reads an integer, or a floating-point number if it has a fractional part or an exponent.
+func (l *Lexer) readNumber() (token.Type, string) {
+	pos := l.position
+	tokenType := token.Type(token.INT)
+
+	l.readWithValidator(isDigit)
+
+	if l.ch == '.' && isDigit(l.peekChar()) {
+		tokenType = token.FLOAT
+
+		l.readChar()
+		l.readWithValidator(isDigit)
+	}
+
+	if l.ch == 'e' || l.ch == 'E' {
+		next := l.peekChar()
+		if (next == '+' || next == '-') && l.readPosition+1 < len(l.input) {
+			next, _ = l.decodeChar(l.readPosition + 1)
+		}
+
+		if isDigit(next) {
+			tokenType = token.FLOAT
+
+			l.readChar()
+			if l.ch == '+' || l.ch == '-' {
+				l.readChar()
+			}
+			l.readWithValidator(isDigit)
+		}
+	}
+
+	return tokenType, l.input[pos:l.position]
+}
+
+// readLineComment reads a comment up to the end of the line, without the line break.
+func (l *Lexer) readLineComment() string {
+	return l.readWithValidator(func(c rune) bool {
+		return c != '\n' && c != 0
+	})
+}
+
+// readBlockComment reads a comment up to its closing '*/'. Block comments can be nested,
+// so that commenting out some code that already has a block comment works.
+func (l *Lexer) readBlockComment() token.Token {
+	start := l.currentPosition()
+	pos := l.position
+	depth := 0
+
+	for l.ch != 0 {
+		switch {
+		case l.ch == '/' && l.peekChar() == '*':
+			depth++
+			l.readChar()
+
+		case l.ch == '*' && l.peekChar() == '/':
+			depth--
+			l.readChar()
+		}
+
+		l.readChar()
+
+		if depth == 0 {
+			return newToken(token.COMMENT, l.input[pos:l.position])
+		}
+	}
+
+	l.errorAt("unterminated-comment", diag.Span{Start: start, End: l.currentPosition()}, "unterminated comment")
+
+	return newToken(token.COMMENT, l.input[pos:l.position])
+}
+
 func (l *Lexer) makeTwoCharToken(t token.Type) token.Token {
 	ch := l.ch
 	l.readChar()
@@ -151,23 +364,151 @@ func newToken(tokenType token.Type, ch string) token.Token {
 	return t
 }
 
-func (l *Lexer) readString() string {
-	position := l.position + 1
+// readStringPart reads the text of a string, starting from its opening quote or from
+// the brace closing an interpolation, up to the closing quote or the next interpolation.
+// The escape sequences are replaced with the characters they stand for.
+func (l *Lexer) readStringPart(closed, interpolated token.Type) token.Token {
+	start := l.currentPosition()
+	var out strings.Builder
+
+	l.readChar()
 
 	for {
+		switch {
+		case l.ch == 0:
+			l.errorAt("unterminated-string", diag.Span{Start: start, End: l.currentPosition()}, "unterminated string")
+
+			return newToken(closed, out.String())
+
+		case l.ch == '"':
+			l.readChar()
+
+			return newToken(closed, out.String())
+
+		case l.ch == '$' && l.peekChar() == '{':
+			l.readChar()
+			l.readChar()
+			l.templates = append(l.templates, 0)
+
+			return newToken(interpolated, out.String())
+
+		case l.ch == '\\':
+			l.readEscapeSequence(&out)
+
+		default:
+			out.WriteRune(l.ch)
+			l.readChar()
+		}
+	}
+}
+
+// readEscapeSequence reads a backslash along with the character(s) after it.
+func (l *Lexer) readEscapeSequence(out *strings.Builder) {
+	pos := l.currentPosition()
+	l.readChar()
+
+	escaped, ok := escapes[l.ch]
+	if ok {
+		out.WriteRune(escaped)
 		l.readChar()
-		if l.ch == '"' || l.ch == 0 {
-			break
+
+		return
+	}
+
+	if l.ch != 'u' {
+		if l.ch != 0 {
+			ch := l.ch
+			l.readChar()
+			l.errorAt("invalid-escape", diag.Span{Start: pos, End: l.currentPosition()}, "unknown escape sequence: \\%c", ch)
 		}
+
+		return
 	}
 
-	return l.input[position:l.position]
+	// A unicode code point, written in hexadecimal: \u{1F436}.
+	sequenceStart := l.position - 1
+	invalid := func() {
+		end := l.position
+		if l.ch != 0 {
+			end = l.readPosition
+		}
+		sequence := l.input[sequenceStart:end]
+
+		span := diag.Point(pos)
+		span.End.Offset = end
+		span.End.Column += utf8.RuneCountInString(sequence)
+		l.errorAt("invalid-escape", span, "invalid unicode escape sequence: %s", sequence)
+	}
+
+	l.readChar()
+	if l.ch != '{' {
+		invalid()
+
+		return
+	}
+
+	l.readChar()
+	digits := l.readWithValidator(isHexDigit)
+	if l.ch != '}' {
+		invalid()
+
+		return
+	}
+
+	codePoint, err := strconv.ParseUint(digits, 16, 32)
+	if err != nil || !utf8.ValidRune(rune(codePoint)) {
+		invalid()
+		l.readChar()
+
+		return
+	}
+	l.readChar()
+
+	out.WriteRune(rune(codePoint))
+}
+
+// readRawString reads a string between backticks. Its text is taken as it is,
+// without escape sequences or interpolations, and it can span multiple lines.
+func (l *Lexer) readRawString() string {
+	start := l.currentPosition()
+	pos := l.position + 1
+
+	l.readChar()
+	for l.ch != '`' {
+		if l.ch == 0 {
+			l.errorAt("unterminated-string", diag.Span{Start: start, End: l.currentPosition()}, "unterminated raw string")
+
+			return l.input[pos:l.position]
+		}
+
+		l.readChar()
+	}
+
+	text := l.input[pos:l.position]
+	l.readChar()
+
+	return text
+}
+
+// escapes maps the characters that can follow a backslash in a string to the characters they stand for.
+var escapes = map[rune]rune{
+	'n':  '\n',
+	't':  '\t',
+	'r':  '\r',
+	'\\': '\\',
+	'"':  '"',
+	'$':  '$',
+}
+
+func isHexDigit(ch rune) bool {
+	return isDigit(ch) || 'a' <= ch && ch <= 'f' || 'A' <= ch && ch <= 'F'
 }
 
-func isLetter(ch byte) bool {
-	return 'a' <= ch && ch <= 'z' || 'A' <= ch && ch <= 'Z' || ch == '_'
+// isLetter reports whether the char can be part of an identifier: a letter of any alphabet, or an underscore.
+func isLetter(ch rune) bool {
+	return unicode.IsLetter(ch) || ch == '_'
 }
 
-func isDigit(ch byte) bool {
+func isDigit(ch rune) bool {
 	return '0' <= ch && ch <= '9'
 }
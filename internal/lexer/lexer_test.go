@@ -15,7 +15,7 @@ const add = fn(x, y) {
 };
 
 const result = add(five, ten);
-!-/*5;
+!-/ *5;
 5 < 10 > 5;
 
 if (5 < 10) {
@@ -30,6 +30,13 @@ if (5 < 10) {
 "foo bar"
 [1, 2];
 {"foo": "bar"}
+while for in break continue
+let x = 1; x += 2; x -= 3; x *= 4; x /= 5;
+3.14 1e5 2.5E-3 7e+2 1e
+x %= 7 % 2;
+fn(...rest) .
+try catch finally throw
+import "util.doggo" as util; export util.x
 `
 
 	testCases := []struct {
@@ -122,6 +129,63 @@ if (5 < 10) {
 		{token.COLON, ":"},
 		{token.STRING, "bar"},
 		{token.RBRACE, "}"},
+		{token.WHILE, "while"},
+		{token.FOR, "for"},
+		{token.IN, "in"},
+		{token.BREAK, "break"},
+		{token.CONTINUE, "continue"},
+		{token.LET, "let"},
+		{token.IDENT, "x"},
+		{token.ASSIGN, "="},
+		{token.INT, "1"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.PLUS_ASSIGN, "+="},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.MINUS_ASSIGN, "-="},
+		{token.INT, "3"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.ASTERISK_ASSIGN, "*="},
+		{token.INT, "4"},
+		{token.SEMICOLON, ";"},
+		{token.IDENT, "x"},
+		{token.SLASH_ASSIGN, "/="},
+		{token.INT, "5"},
+		{token.SEMICOLON, ";"},
+		{token.FLOAT, "3.14"},
+		{token.FLOAT, "1e5"},
+		{token.FLOAT, "2.5E-3"},
+		{token.FLOAT, "7e+2"},
+		{token.INT, "1"},
+		{token.IDENT, "e"},
+		{token.IDENT, "x"},
+		{token.PERCENT_ASSIGN, "%="},
+		{token.INT, "7"},
+		{token.PERCENT, "%"},
+		{token.INT, "2"},
+		{token.SEMICOLON, ";"},
+		{token.FUNCTION, "fn"},
+		{token.LPAREN, "("},
+		{token.ELLIPSIS, "..."},
+		{token.IDENT, "rest"},
+		{token.RPAREN, ")"},
+		{token.DOT, "."},
+		{token.TRY, "try"},
+		{token.CATCH, "catch"},
+		{token.FINALLY, "finally"},
+		{token.THROW, "throw"},
+		{token.IMPORT, "import"},
+		{token.STRING, "util.doggo"},
+		{token.AS, "as"},
+		{token.IDENT, "util"},
+		{token.SEMICOLON, ";"},
+		{token.EXPORT, "export"},
+		{token.IDENT, "util"},
+		{token.DOT, "."},
+		{token.IDENT, "x"},
 		{token.EOF, ""},
 	}
 
@@ -139,3 +203,226 @@ if (5 < 10) {
 		}
 	}
 }
+
+func TestNextTokenPositions(t *testing.T) {
+	input := `const x = "ab";
+  x +
+10`
+
+	testCases := []struct {
+		expectedType   token.Type
+		expectedPos    token.Position
+		expectedEndCol int
+	}{
+		{token.CONST, token.Position{Filename: "test.doggo", Offset: 0, Line: 1, Column: 1}, 6},
+		{token.IDENT, token.Position{Filename: "test.doggo", Offset: 6, Line: 1, Column: 7}, 8},
+		{token.ASSIGN, token.Position{Filename: "test.doggo", Offset: 8, Line: 1, Column: 9}, 10},
+		{token.STRING, token.Position{Filename: "test.doggo", Offset: 10, Line: 1, Column: 11}, 15},
+		{token.SEMICOLON, token.Position{Filename: "test.doggo", Offset: 14, Line: 1, Column: 15}, 16},
+		{token.IDENT, token.Position{Filename: "test.doggo", Offset: 18, Line: 2, Column: 3}, 4},
+		{token.PLUS, token.Position{Filename: "test.doggo", Offset: 20, Line: 2, Column: 5}, 6},
+		{token.INT, token.Position{Filename: "test.doggo", Offset: 22, Line: 3, Column: 1}, 3},
+		{token.EOF, token.Position{Filename: "test.doggo", Offset: 24, Line: 3, Column: 3}, 3},
+		{token.EOF, token.Position{Filename: "test.doggo", Offset: 24, Line: 3, Column: 3}, 3},
+	}
+
+	l := NewWithFilename("test.doggo", input)
+
+	for i, tc := range testCases {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType {
+			t.Fatalf("Case %d: Token type incorrect. expected=%q, got=%q", i, tc.expectedType, tok.Type)
+		}
+
+		if tok.Pos != tc.expectedPos {
+			t.Fatalf("Case %d: Token position incorrect. expected=%+v, got=%+v", i, tc.expectedPos, tok.Pos)
+		}
+
+		if tok.End.Column != tc.expectedEndCol {
+			t.Fatalf("Case %d: Token end column incorrect. expected=%d, got=%d", i, tc.expectedEndCol, tok.End.Column)
+		}
+	}
+}
+
+func TestComments(t *testing.T) {
+	input := `// leading
+x / y; // trailing
+/* block /* nested */ still */ z
+/* open`
+
+	testCases := []struct {
+		keepComments bool
+		expected     []token.Token
+	}{
+		{
+			keepComments: false,
+			expected: []token.Token{
+				{Type: token.IDENT, Literal: "x"},
+				{Type: token.SLASH, Literal: "/"},
+				{Type: token.IDENT, Literal: "y"},
+				{Type: token.SEMICOLON, Literal: ";"},
+				{Type: token.IDENT, Literal: "z"},
+				{Type: token.EOF, Literal: ""},
+			},
+		},
+		{
+			keepComments: true,
+			expected: []token.Token{
+				{Type: token.COMMENT, Literal: "// leading"},
+				{Type: token.IDENT, Literal: "x"},
+				{Type: token.SLASH, Literal: "/"},
+				{Type: token.IDENT, Literal: "y"},
+				{Type: token.SEMICOLON, Literal: ";"},
+				{Type: token.COMMENT, Literal: "// trailing"},
+				{Type: token.COMMENT, Literal: "/* block /* nested */ still */"},
+				{Type: token.IDENT, Literal: "z"},
+				{Type: token.COMMENT, Literal: "/* open"},
+				{Type: token.EOF, Literal: ""},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		l := New(input)
+		l.SetKeepComments(tc.keepComments)
+
+		for i, expected := range tc.expected {
+			tok := l.NextToken()
+
+			if tok.Type != expected.Type || tok.Literal != expected.Literal {
+				t.Fatalf("keepComments=%t, case %d: wrong token. expected=%s %q, got=%s %q",
+					tc.keepComments, i, expected.Type, expected.Literal, tok.Type, tok.Literal)
+			}
+		}
+
+		errors := l.Errors()
+		if len(errors) != 1 || errors[0] != "4:1: unterminated comment" {
+			t.Errorf("wrong lexer errors. got=%q", errors)
+		}
+	}
+
+	// The position of a comment spans all of its lines.
+	l := New("1 /* a\nb */ 2")
+	l.SetKeepComments(true)
+	l.NextToken()
+
+	comment := l.NextToken()
+	if comment.Pos.Column != 3 || comment.End.Line != 2 || comment.End.Column != 5 {
+		t.Errorf("wrong comment position. got=%s to %s", comment.Pos, comment.End)
+	}
+}
+
+func TestStrings(t *testing.T) {
+	input := `"a\n\t\\\"\$b" "\u{1F436}\u{e9}" ` + "`raw\\n ${x}\n`" + ` "hi ${name}!" "${a}${ {"k": "${b}"}["k"] }"`
+
+	expected := []token.Token{
+		{Type: token.STRING, Literal: "a\n\t\\\"$b"},
+		{Type: token.STRING, Literal: "🐶é"},
+		{Type: token.STRING, Literal: "raw\\n ${x}\n"},
+		{Type: token.TEMPLATE_START, Literal: "hi "},
+		{Type: token.IDENT, Literal: "name"},
+		{Type: token.TEMPLATE_END, Literal: "!"},
+		{Type: token.TEMPLATE_START, Literal: ""},
+		{Type: token.IDENT, Literal: "a"},
+		{Type: token.TEMPLATE_MIDDLE, Literal: ""},
+		{Type: token.LBRACE, Literal: "{"},
+		{Type: token.STRING, Literal: "k"},
+		{Type: token.COLON, Literal: ":"},
+		{Type: token.TEMPLATE_START, Literal: ""},
+		{Type: token.IDENT, Literal: "b"},
+		{Type: token.TEMPLATE_END, Literal: ""},
+		{Type: token.RBRACE, Literal: "}"},
+		{Type: token.LBRACKET, Literal: "["},
+		{Type: token.STRING, Literal: "k"},
+		{Type: token.RBRACKET, Literal: "]"},
+		{Type: token.TEMPLATE_END, Literal: ""},
+		{Type: token.EOF, Literal: ""},
+	}
+
+	l := New(input)
+
+	for i, tc := range expected {
+		tok := l.NextToken()
+
+		if tok.Type != tc.Type || tok.Literal != tc.Literal {
+			t.Fatalf("Case %d: wrong token. expected=%s %q, got=%s %q", i, tc.Type, tc.Literal, tok.Type, tok.Literal)
+		}
+	}
+
+	if len(l.Errors()) != 0 {
+		t.Errorf("unexpected lexer errors: %q", l.Errors())
+	}
+}
+
+func TestStringErrors(t *testing.T) {
+	testCases := []struct {
+		input          string
+		expectedErrors []string
+	}{
+		{`"abc`, []string{"1:1: unterminated string"}},
+		{"x = `abc", []string{"1:5: unterminated raw string"}},
+		{`"${x} abc`, []string{"1:5: unterminated string"}},
+		{`"a\qb"`, []string{"1:3: unknown escape sequence: \\q"}},
+		{`"\u{zz}" "\u1234" "\u{110000}"`, []string{
+			"1:2: invalid unicode escape sequence: \\u{z",
+			"1:11: invalid unicode escape sequence: \\u1",
+			"1:20: invalid unicode escape sequence: \\u{110000}",
+		}},
+	}
+
+	for _, tc := range testCases {
+		l := New(tc.input)
+		for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		}
+
+		errors := l.Errors()
+		if len(errors) != len(tc.expectedErrors) {
+			t.Errorf("wrong lexer errors for %q. expected=%q, got=%q", tc.input, tc.expectedErrors, errors)
+
+			continue
+		}
+
+		for i, msg := range tc.expectedErrors {
+			if errors[i] != msg {
+				t.Errorf("wrong lexer error. expected=%q, got=%q", msg, errors[i])
+			}
+		}
+	}
+}
+
+func TestUnicodeIdentifiers(t *testing.T) {
+	input := `const größe = "ü"; 名前 + _x ¿`
+
+	testCases := []struct {
+		expectedType    token.Type
+		expectedLiteral string
+		expectedColumn  int
+	}{
+		{token.CONST, "const", 1},
+		{token.IDENT, "größe", 7},
+		{token.ASSIGN, "=", 13},
+		{token.STRING, "ü", 15},
+		{token.SEMICOLON, ";", 18},
+		{token.IDENT, "名前", 20},
+		{token.PLUS, "+", 23},
+		{token.IDENT, "_x", 25},
+		{token.ILLEGAL, "¿", 28},
+		{token.EOF, "", 29},
+	}
+
+	l := New(input)
+
+	for i, tc := range testCases {
+		tok := l.NextToken()
+
+		if tok.Type != tc.expectedType || tok.Literal != tc.expectedLiteral {
+			t.Fatalf("Case %d: wrong token. expected=%s %q, got=%s %q", i, tc.expectedType, tc.expectedLiteral, tok.Type, tok.Literal)
+		}
+
+		// Columns are counted in chars, while offsets are counted in bytes.
+		if tok.Pos.Column != tc.expectedColumn {
+			t.Fatalf("Case %d: wrong column. expected=%d, got=%d", i, tc.expectedColumn, tok.Pos.Column)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+)
+
+// CONFIG_FILE is the name of the config file looked up in the current directory by 'doggo lint'.
+const CONFIG_FILE = ".doggolint.json"
+
+// Config selects the rules to run, and tells about the names the code gets from outside, like:
+//
+//	{
+//	    "rules": {"shadowing": false},
+//	    "globals": ["request", "response"]
+//	}
+type Config struct {
+	// Rules turns the rules on or off, by their IDs. The rules left out are on.
+	Rules map[string]bool `json:"rules"`
+	// Globals holds the names defined by whatever runs the code, like the 'args' of the doggo command line.
+	Globals []string `json:"globals"`
+}
+
+// LoadConfig reads the config from the file.
+func LoadConfig(fileName string) (*Config, error) {
+	contents, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read config: %s", err)
+	}
+
+	config := &Config{}
+	if err := json.Unmarshal(contents, config); err != nil {
+		return nil, fmt.Errorf("invalid config %s: %s", fileName, err)
+	}
+
+	for id := range config.Rules {
+		if FindRule(id) == nil {
+			return nil, fmt.Errorf("invalid config %s: unknown rule: %s", fileName, id)
+		}
+	}
+
+	return config, nil
+}
+
+// Enabled reports whether the rule is turned on.
+func (c *Config) Enabled(id string) bool {
+	enabled, ok := c.Rules[id]
+
+	return !ok || enabled
+}
+
+// IsGlobal reports whether the name is defined by whatever runs the code.
+func (c *Config) IsGlobal(name string) bool {
+	for _, global := range c.Globals {
+		if global == name {
+			return true
+		}
+	}
+
+	return false
+}
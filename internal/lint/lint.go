@@ -0,0 +1,88 @@
+// Package lint finds the problems of doggo code that can be told without running it, like names used
+// without being declared. Each kind of problem is found by a rule, which can be turned off by its ID.
+package lint
+
+import (
+	"sort"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/diag"
+)
+
+// Rule checks the code for a kind of problem.
+type Rule struct {
+	// ID names the rule in the diagnostics and in the config.
+	ID          string
+	Description string
+	Check       func(p *Pass)
+}
+
+// Rules holds the rules run by Lint, unless the config turns them off.
+var Rules []*Rule
+
+func init() {
+	Rules = []*Rule{
+		{"unused-const", "constants and imports that are never used", checkUnusedConstants},
+		{"shadowing", "declarations hiding a name declared around them, or a builtin function", checkShadowing},
+		{"unreachable", "statements that can never run, like the ones after a return", checkUnreachable},
+		{"unknown-identifier", "names that are declared nowhere", checkUnknownIdentifiers},
+		{"builtin-arity", "calls to builtin functions with the wrong number of arguments", checkBuiltinArity},
+	}
+}
+
+// FindRule returns the rule with the given ID, or nil if there's none.
+func FindRule(id string) *Rule {
+	for _, rule := range Rules {
+		if rule.ID == id {
+			return rule
+		}
+	}
+
+	return nil
+}
+
+// Pass is a run of a rule over a program.
+type Pass struct {
+	FileName string
+	Program  *ast.Program
+	*Resolution
+	Config *Config
+
+	rule        *Rule
+	diagnostics []*diag.Diagnostic
+}
+
+// Report records a problem found by the rule in the node, which the diagnostic spans.
+func (p *Pass) Report(node ast.Node, format string, a ...interface{}) {
+	span := diag.Span{Start: node.Pos(), End: node.End()}
+	p.diagnostics = append(p.diagnostics, diag.Warningf(p.rule.ID, span, format, a...))
+}
+
+// Lint runs the rules turned on by the config over the program, which comes from the file.
+// The problems found are warnings, coded by the IDs of the rules that found them, in the order they appear in the code.
+func Lint(fileName string, program *ast.Program, config *Config) []*diag.Diagnostic {
+	if config == nil {
+		config = &Config{}
+	}
+
+	pass := &Pass{
+		FileName:   fileName,
+		Program:    program,
+		Resolution: Resolve(program),
+		Config:     config,
+	}
+
+	for _, rule := range Rules {
+		if config.Enabled(rule.ID) {
+			pass.rule = rule
+			rule.Check(pass)
+		}
+	}
+
+	diagnostics := pass.diagnostics
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Span.Start.Offset < diagnostics[j].Span.Start.Offset
+	})
+
+	return diagnostics
+}
@@ -0,0 +1,247 @@
+package lint
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/parser"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected []string
+	}{
+		{"const x = 1; print(x);", nil},
+		{
+			"const x = 1; import \"a.doggo\" as a; export const y = 2; let z = 3;",
+			[]string{"1:7: constant x is never used (unused-const)", "1:34: import a is never used (unused-const)"},
+		},
+		{"const f = fn() { const g = fn() { f() }; g() }; f();", nil},
+		{"const f = fn() { g() }; const g = fn() { 1 }; f();", nil},
+		{
+			"let x = 1; const f = fn(x) { let y = 2; fn(y) { y } }; f(x);",
+			[]string{"1:25: x shadows the variable declared on line 1 (shadowing)", "1:44: y shadows the variable declared on line 1 (shadowing)"},
+		},
+		{"let x = 1; let x = 2; const f = fn(a) { let a = 3; a }; f(x);", nil},
+		{
+			"let print = 1; const f = fn(length) { for (i, tail in [length]) { tail } }; f(print);",
+			[]string{
+				"1:5: print shadows the builtin function (shadowing)",
+				"1:29: length shadows the builtin function (shadowing)",
+				"1:47: tail shadows the builtin function (shadowing)",
+			},
+		},
+		{
+			"const f = fn() { return 1; print(2); print(3) }; f();",
+			[]string{"1:28: unreachable code (unreachable)"},
+		},
+		{
+			"while (true) { if (true) { break } else { continue }; print(1) } throw \"x\"; print(2)",
+			[]string{"1:55: unreachable code (unreachable)", "1:77: unreachable code (unreachable)"},
+		},
+		{"const f = fn() { if (true) { return 1 }; 2 }; f();", nil},
+		{
+			"print(x, args); x = 2; y.z; a[b];",
+			[]string{
+				"1:7: identifier not found: x (unknown-identifier)",
+				"1:10: identifier not found: args (unknown-identifier)",
+				"1:17: identifier not found: x (unknown-identifier)",
+				"1:24: identifier not found: y (unknown-identifier)",
+				"1:29: identifier not found: a (unknown-identifier)",
+				"1:31: identifier not found: b (unknown-identifier)",
+			},
+		},
+		{"try { 1 } catch (e) { e }; for (k, v in {}) { k + v }; const f = fn(a, b = a, ...c) { c }; f(1);", nil},
+		{"const f = fn(a = b) { a }; f();", []string{"1:18: identifier not found: b (unknown-identifier)"}},
+		{
+			"push([1]); print(); input(1, 2); assert(); readLine(1); length(1, 2, 3);",
+			[]string{
+				"1:1: wrong number of arguments to push. got=1, want=2 (builtin-arity)",
+				"1:21: wrong number of arguments to input. got=2, want=0 or 1 (builtin-arity)",
+				"1:34: wrong number of arguments to assert. got=0, want=1 or 2 (builtin-arity)",
+				"1:44: wrong number of arguments to readLine. got=1, want=0 (builtin-arity)",
+				"1:57: wrong number of arguments to length. got=3, want=1 (builtin-arity)",
+			},
+		},
+		{
+			"const push = fn() {}; push(); print(push);",
+			[]string{"1:7: push shadows the builtin function (shadowing)"},
+		},
+	}
+
+	for _, tc := range testCases {
+		var got []string
+		for _, d := range Lint("", parse(t, tc.input), nil) {
+			got = append(got, fmt.Sprintf("%s (%s)", d, d.Code))
+		}
+
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("wrong diagnostics for %q.\nexpected=%q\ngot=     %q", tc.input, tc.expected, got)
+		}
+	}
+}
+
+func TestLintConfig(t *testing.T) {
+	input := "let length = 1; print(request, length); const unused = 2; push();"
+	config := &Config{
+		Rules:   map[string]bool{"shadowing": false, "unused-const": true},
+		Globals: []string{"request"},
+	}
+
+	var got []string
+	for _, d := range Lint("", parse(t, input), config) {
+		got = append(got, fmt.Sprintf("%s (%s)", d, d.Code))
+	}
+
+	expected := []string{
+		"1:47: constant unused is never used (unused-const)",
+		"1:59: wrong number of arguments to push. got=0, want=2 (builtin-arity)",
+	}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong diagnostics.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestLintSpans(t *testing.T) {
+	input := "const unused = 1; push();"
+
+	var got []string
+	for _, d := range Lint("", parse(t, input), nil) {
+		got = append(got, fmt.Sprintf("%d-%d", d.Span.Start.Column, d.Span.End.Column))
+	}
+
+	// The spans cover the identifier or the call the problem is about.
+	expected := []string{"7-13", "19-25"}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong spans.\nexpected=%q\ngot=     %q", expected, got)
+	}
+}
+
+func TestLintTestFiles(t *testing.T) {
+	input := "const testSomething = fn() {}; const helper = fn() {};"
+
+	for fileName, expected := range map[string]int{"main.doggo": 2, "main_test.doggo": 1} {
+		if got := len(Lint(fileName, parse(t, input), nil)); got != expected {
+			t.Errorf("wrong number of diagnostics for %s. expected=%d, got=%d", fileName, expected, got)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	program := parse(t, "let x = 1; const f = fn(a) { x = a; let b = x; b }; f(y);")
+	r := Resolve(program)
+
+	if len(r.Scopes) != 2 || r.Scopes[0] != r.Global || r.Scopes[1].Outer != r.Global {
+		t.Fatalf("wrong scopes: %+v", r.Scopes)
+	}
+
+	testCases := []struct {
+		scope *Scope
+		name  string
+		kind  Kind
+		uses  int
+	}{
+		{r.Global, "x", VARIABLE, 2},
+		{r.Global, "f", CONSTANT, 1},
+		{r.Scopes[1], "a", PARAMETER, 1},
+		{r.Scopes[1], "b", VARIABLE, 1},
+	}
+
+	for _, tc := range testCases {
+		b, ok := tc.scope.Bindings[tc.name]
+		if !ok {
+			t.Errorf("binding %s not found", tc.name)
+
+			continue
+		}
+
+		if b.Kind != tc.kind || len(b.Uses) != tc.uses || b.Scope != tc.scope {
+			t.Errorf("wrong binding %s. expected kind=%s, uses=%d, got kind=%s, uses=%d", tc.name, tc.kind, tc.uses, b.Kind, len(b.Uses))
+		}
+	}
+
+	if len(r.Global.Bindings)+len(r.Scopes[1].Bindings) != 4 {
+		t.Errorf("wrong number of bindings. got=%d", len(r.Global.Bindings)+len(r.Scopes[1].Bindings))
+	}
+
+	if len(r.Unresolved) != 1 || r.Unresolved[0].Value != "y" {
+		t.Errorf("wrong unresolved identifiers. got=%v", r.Unresolved)
+	}
+
+	x := r.Global.Bindings["x"]
+	if r.Binding(x.Decl) != x || r.Binding(x.Uses[1]) != x || r.Binding(r.Unresolved[0]) != nil {
+		t.Errorf("wrong bindings of the identifiers")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "lint")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	testCases := []struct {
+		contents string
+		expected *Config
+		err      string
+	}{
+		{
+			contents: `{"rules": {"shadowing": false}, "globals": ["request"]}`,
+			expected: &Config{Rules: map[string]bool{"shadowing": false}, Globals: []string{"request"}},
+		},
+		{contents: `{}`, expected: &Config{}},
+		{contents: `{"rules": {"nope": true}}`, err: "unknown rule: nope"},
+		{contents: `{"rules": [}`, err: "invalid config"},
+	}
+
+	for i, tc := range testCases {
+		fileName := filepath.Join(dir, CONFIG_FILE)
+		if err := ioutil.WriteFile(fileName, []byte(tc.contents), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		config, err := LoadConfig(fileName)
+		if tc.err != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("test %d: expected an error containing %q, got=%v", i, tc.err, err)
+			}
+
+			continue
+		}
+
+		if err != nil {
+			t.Errorf("test %d: unexpected error: %s", i, err)
+
+			continue
+		}
+
+		if !reflect.DeepEqual(config, tc.expected) {
+			t.Errorf("test %d: wrong config. expected=%+v, got=%+v", i, tc.expected, config)
+		}
+	}
+
+	if _, err := LoadConfig(filepath.Join(dir, "missing.json")); err == nil || !strings.Contains(err.Error(), "cannot read config") {
+		t.Errorf("expected an error reading a missing config, got=%v", err)
+	}
+}
+
+func parse(t *testing.T, input string) *ast.Program {
+	t.Helper()
+
+	p := parser.New(lexer.New(input))
+	program := p.ParseProgram()
+	if len(p.Errors()) != 0 {
+		t.Fatalf("unexpected parser errors for %q: %v", input, p.Errors())
+	}
+
+	return program
+}
@@ -0,0 +1,155 @@
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+const (
+	// testFileSuffix and testPrefix tell the tests run by 'doggo test' apart, which are used without being called.
+	testFileSuffix = "_test.doggo"
+	testPrefix     = "test"
+)
+
+func checkUnusedConstants(p *Pass) {
+	for _, scope := range p.Scopes {
+		for _, b := range scope.Bindings {
+			if len(b.Uses) > 0 || b.Exported || p.isTest(b) {
+				continue
+			}
+
+			switch b.Kind {
+			case CONSTANT:
+				p.Report(b.Decl, "constant %s is never used", b.Name)
+
+			case IMPORT:
+				p.Report(b.Decl, "import %s is never used", b.Name)
+			}
+		}
+	}
+}
+
+// isTest reports whether the binding is a test of a test file.
+func (p *Pass) isTest(b *Binding) bool {
+	return b.Scope == p.Global && strings.HasSuffix(p.FileName, testFileSuffix) && strings.HasPrefix(b.Name, testPrefix)
+}
+
+func checkShadowing(p *Pass) {
+	for _, scope := range p.Scopes {
+		for _, b := range scope.Bindings {
+			if object.GetBuiltinByName(b.Name) != nil {
+				p.Report(b.Decl, "%s shadows the builtin function", b.Name)
+
+				continue
+			}
+
+			if scope.Outer == nil {
+				continue
+			}
+
+			if outer, ok := scope.Outer.Lookup(b.Name); ok {
+				p.Report(b.Decl, "%s shadows the %s declared on line %d", b.Name, outer.Kind, outer.Decl.Pos().Line)
+			}
+		}
+	}
+}
+
+func checkUnreachable(p *Pass) {
+	check := func(statements []ast.Statement) {
+		for i := 0; i+1 < len(statements); i++ {
+			if terminates(statements[i]) {
+				p.Report(statements[i+1], "unreachable code")
+
+				return
+			}
+		}
+	}
+
+	check(p.Program.Statements)
+	ast.Inspect(p.Program, func(n ast.Node) bool {
+		if block, ok := n.(*ast.BlockStatement); ok {
+			check(block.Statements)
+		}
+
+		return true
+	})
+}
+
+// terminates reports whether the statement always leaves the block it's in,
+// like a return statement, or an if expression whose branches both do.
+func terminates(s ast.Statement) bool {
+	switch s := s.(type) {
+	case *ast.ReturnStatement, *ast.ThrowStatement, *ast.BreakStatement, *ast.ContinueStatement:
+		return true
+
+	case *ast.ExpressionStatement:
+		if ie, ok := s.Expression.(*ast.IfExpression); ok && ie.Alternative != nil {
+			return blockTerminates(ie.Consequence) && blockTerminates(ie.Alternative)
+		}
+	}
+
+	return false
+}
+
+func blockTerminates(block *ast.BlockStatement) bool {
+	for _, s := range block.Statements {
+		if terminates(s) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func checkUnknownIdentifiers(p *Pass) {
+	for _, ident := range p.Unresolved {
+		if object.GetBuiltinByName(ident.Value) == nil && !p.Config.IsGlobal(ident.Value) {
+			p.Report(ident, "identifier not found: %s", ident.Value)
+		}
+	}
+}
+
+func checkBuiltinArity(p *Pass) {
+	ast.Inspect(p.Program, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpression)
+		if !ok {
+			return true
+		}
+
+		// Calls to a name declared in the code don't call the builtin function, even if it has the same name.
+		ident, ok := call.Function.(*ast.Identifier)
+		if !ok || p.Refs[ident] != nil {
+			return true
+		}
+
+		b := object.GetBuiltinByName(ident.Value)
+		if b == nil {
+			return true
+		}
+
+		if n := len(call.Arguments); n < b.MinArgs || b.MaxArgs != object.VARIADIC && n > b.MaxArgs {
+			p.Report(call, "wrong number of arguments to %s. got=%d, want=%s", ident.Value, n, Arity(b))
+		}
+
+		return true
+	})
+}
+
+// Arity describes the number of arguments the builtin function takes, like '1 or 2'.
+func Arity(b *object.Builtin) string {
+	switch {
+	case b.MaxArgs == object.VARIADIC:
+		return fmt.Sprintf("at least %d", b.MinArgs)
+
+	case b.MaxArgs == b.MinArgs:
+		return fmt.Sprintf("%d", b.MinArgs)
+
+	case b.MaxArgs == b.MinArgs+1:
+		return fmt.Sprintf("%d or %d", b.MinArgs, b.MaxArgs)
+	}
+
+	return fmt.Sprintf("%d to %d", b.MinArgs, b.MaxArgs)
+}
@@ -0,0 +1,195 @@
+package lint
+
+import (
+	"github.com/axbarsan/doggo/internal/ast"
+)
+
+// Kind tells how a binding was declared.
+type Kind string
+
+const (
+	CONSTANT  Kind = "constant"
+	VARIABLE  Kind = "variable"
+	PARAMETER Kind = "parameter"
+	IMPORT    Kind = "import"
+)
+
+// Binding is a name declared in a scope.
+type Binding struct {
+	Name string
+	Kind Kind
+	// Decl is the identifier of the first declaration of the name in its scope.
+	Decl  *ast.Identifier
+	Scope *Scope
+	// Uses holds the identifiers referring to the binding, assignments included.
+	Uses     []*ast.Identifier
+	Exported bool
+}
+
+// Scope holds the bindings declared by a program, or by a function. Blocks don't have scopes of their own,
+// so a name declared anywhere in a function, outside of the functions within it, belongs to the function.
+type Scope struct {
+	// Node is either the *ast.Program or the *ast.FunctionLiteral the scope belongs to.
+	Node     ast.Node
+	Outer    *Scope
+	Bindings map[string]*Binding
+	// Children holds the scopes of the functions declared within this one, in order.
+	Children []*Scope
+}
+
+// Lookup finds the binding of the name, in the scope or in the ones around it.
+func (s *Scope) Lookup(name string) (*Binding, bool) {
+	for scope := s; scope != nil; scope = scope.Outer {
+		if b, ok := scope.Bindings[name]; ok {
+			return b, true
+		}
+	}
+
+	return nil, false
+}
+
+// Resolution is what the resolver found out about the names of a program.
+type Resolution struct {
+	// Global is the scope of the program.
+	Global *Scope
+	// Scopes holds the scope of the program, followed by the ones of its functions, in order.
+	Scopes []*Scope
+	// Refs maps the identifiers using a name to the binding they refer to.
+	Refs map[*ast.Identifier]*Binding
+	// Unresolved holds the identifiers using a name declared nowhere in the code, like the builtin functions.
+	Unresolved []*ast.Identifier
+}
+
+// Binding returns the binding the identifier refers to, or the one it declares, or nil if it's neither.
+func (r *Resolution) Binding(ident *ast.Identifier) *Binding {
+	if b, ok := r.Refs[ident]; ok {
+		return b
+	}
+
+	for _, scope := range r.Scopes {
+		if b, ok := scope.Bindings[ident.Value]; ok && b.Decl == ident {
+			return b
+		}
+	}
+
+	return nil
+}
+
+// Resolve works out the scopes of the program, and the binding each of its identifiers refers to.
+// The declarations are hoisted, like the runner does, so a function can refer to a name declared after it.
+func Resolve(program *ast.Program) *Resolution {
+	r := &Resolution{Refs: make(map[*ast.Identifier]*Binding)}
+	r.Global = r.resolve(program, nil, program.Statements)
+
+	return r
+}
+
+func (r *Resolution) resolve(node ast.Node, outer *Scope, body []ast.Statement) *Scope {
+	scope := &Scope{Node: node, Outer: outer, Bindings: make(map[string]*Binding)}
+	if outer != nil {
+		outer.Children = append(outer.Children, scope)
+	}
+	r.Scopes = append(r.Scopes, scope)
+
+	// The identifiers declaring a name, and the ones naming a member, aren't uses of a name.
+	skip := make(map[*ast.Identifier]bool)
+	declare := func(ident *ast.Identifier, kind Kind, exported bool) {
+		skip[ident] = true
+		if _, ok := scope.Bindings[ident.Value]; !ok {
+			scope.Bindings[ident.Value] = &Binding{Name: ident.Value, Kind: kind, Decl: ident, Scope: scope, Exported: exported}
+		}
+	}
+
+	var defaults []ast.Expression
+	if fl, ok := node.(*ast.FunctionLiteral); ok {
+		for _, param := range fl.Parameters {
+			declare(param, PARAMETER, false)
+		}
+
+		if fl.Rest != nil {
+			declare(fl.Rest, PARAMETER, false)
+		}
+
+		for _, d := range fl.Defaults {
+			if d != nil {
+				defaults = append(defaults, d)
+			}
+		}
+	}
+
+	for _, s := range body {
+		ast.Inspect(s, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FunctionLiteral:
+				return false
+
+			case *ast.ExportStatement:
+				if name := n.Name(); name != nil {
+					kind := VARIABLE
+					if _, ok := n.Declaration.(*ast.ConstStatement); ok {
+						kind = CONSTANT
+					}
+					declare(name, kind, true)
+				}
+
+			case *ast.ConstStatement:
+				declare(n.Name, CONSTANT, false)
+
+			case *ast.LetStatement:
+				declare(n.Name, VARIABLE, false)
+
+			case *ast.ForStatement:
+				if n.Key != nil {
+					declare(n.Key, VARIABLE, false)
+				}
+				declare(n.Value, VARIABLE, false)
+
+			case *ast.TryStatement:
+				if n.Param != nil {
+					declare(n.Param, VARIABLE, false)
+				}
+
+			case *ast.ImportStatement:
+				declare(n.Name, IMPORT, false)
+			}
+
+			return true
+		})
+	}
+
+	use := func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FunctionLiteral:
+			r.resolve(n, scope, n.Body.Statements)
+
+			return false
+
+		case *ast.MemberExpression:
+			skip[n.Property] = true
+
+		case *ast.Identifier:
+			if skip[n] {
+				return true
+			}
+
+			if b, ok := scope.Lookup(n.Value); ok {
+				b.Uses = append(b.Uses, n)
+				r.Refs[n] = b
+			} else {
+				r.Unresolved = append(r.Unresolved, n)
+			}
+		}
+
+		return true
+	}
+
+	for _, d := range defaults {
+		ast.Inspect(d, use)
+	}
+
+	for _, s := range body {
+		ast.Inspect(s, use)
+	}
+
+	return scope
+}
@@ -0,0 +1,229 @@
+package lsp
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/format"
+	"github.com/axbarsan/doggo/internal/lint"
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+// The requests about a position of a document with syntax errors get an empty result,
+// since the syntax tree of its previous text doesn't match the positions anymore.
+
+func (s *Server) definition(params json.RawMessage) (interface{}, error) {
+	doc, offset, err := s.position(params)
+	if err != nil || doc.program == nil {
+		return nil, err
+	}
+
+	ident := identifierAt(doc.program, offset)
+	if ident == nil {
+		return nil, nil
+	}
+
+	b := doc.resolution.Binding(ident)
+	if b == nil {
+		return nil, nil
+	}
+
+	return &Location{URI: doc.uri, Range: doc.rangeOf(b.Decl)}, nil
+}
+
+func (s *Server) hover(params json.RawMessage) (interface{}, error) {
+	doc, offset, err := s.position(params)
+	if err != nil || doc.program == nil {
+		return nil, err
+	}
+
+	ident := identifierAt(doc.program, offset)
+	if ident == nil {
+		return nil, nil
+	}
+
+	var signature, help string
+	if b := doc.resolution.Binding(ident); b != nil {
+		signature = fmt.Sprintf("%s %s", keywords[b.Kind], b.Name)
+		if t := newInference(doc).bindingType(b); t != "" {
+			signature += ": " + t
+		}
+	} else if builtin := object.GetBuiltinByName(ident.Value); builtin != nil && !isMember(doc.program, ident) {
+		signature = fmt.Sprintf("builtin %s: %s", ident.Value, object.BUILTIN_OBJ)
+		arguments := "arguments"
+		if builtin.MinArgs == 1 && builtin.MaxArgs == 1 {
+			arguments = "argument"
+		}
+
+		help = fmt.Sprintf("Takes %s %s.", lint.Arity(builtin), arguments)
+		if t := builtinResults[ident.Value]; t != "" {
+			help = fmt.Sprintf("Takes %s %s, and returns %s.", lint.Arity(builtin), arguments, t)
+		}
+	} else {
+		return nil, nil
+	}
+
+	value := "```doggo\n" + signature + "\n```"
+	if help != "" {
+		value += "\n\n" + help
+	}
+
+	r := doc.rangeOf(ident)
+
+	return &Hover{Contents: MarkupContent{Kind: "markdown", Value: value}, Range: &r}, nil
+}
+
+// keywords maps the kinds of the bindings to the keywords declaring them, for the hover.
+var keywords = map[lint.Kind]string{
+	lint.CONSTANT:  "const",
+	lint.VARIABLE:  "let",
+	lint.PARAMETER: "param",
+	lint.IMPORT:    "import",
+}
+
+// completion offers the names in scope at the position, along with the builtin functions. While the
+// document has syntax errors, which it usually has while typing, the names come from its last text without
+// them: from the function around the position if the document is fine, and from the whole program otherwise.
+func (s *Server) completion(params json.RawMessage) (interface{}, error) {
+	doc, offset, err := s.position(params)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []CompletionItem{}
+	seen := make(map[string]bool)
+
+	if r := doc.lastResolution; r != nil {
+		scope := r.Global
+		if doc.resolution != nil {
+			scope = scopeAt(r, offset)
+		}
+
+		var inference *inference
+		if doc.program != nil {
+			inference = newInference(doc)
+		}
+
+		for ; scope != nil; scope = scope.Outer {
+			var names []string
+			for name := range scope.Bindings {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+
+			for _, name := range names {
+				if seen[name] {
+					continue
+				}
+				seen[name] = true
+
+				b := scope.Bindings[name]
+				item := CompletionItem{Label: name, Kind: completionKinds[b.Kind], Detail: keywords[b.Kind]}
+				if inference != nil {
+					if t := inference.bindingType(b); t != "" {
+						item.Detail += " " + t
+					}
+				}
+				items = append(items, item)
+			}
+		}
+	}
+
+	for _, builtin := range object.Builtins {
+		if !seen[builtin.Name] {
+			items = append(items, CompletionItem{Label: builtin.Name, Kind: CompletionFunction, Detail: "builtin"})
+		}
+	}
+
+	return items, nil
+}
+
+var completionKinds = map[lint.Kind]int{
+	lint.CONSTANT:  CompletionConstant,
+	lint.VARIABLE:  CompletionVariable,
+	lint.PARAMETER: CompletionVariable,
+	lint.IMPORT:    CompletionModule,
+}
+
+// formatting replaces the whole text of the document with its formatted version.
+// Documents with syntax errors can't be formatted, so they're left alone.
+func (s *Server) formatting(params json.RawMessage) (interface{}, error) {
+	var p DocumentFormattingParams
+	if err := decode(params, &p); err != nil {
+		return nil, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	formatted, errors := format.Source("", doc.text)
+	if len(errors) != 0 || formatted == doc.text {
+		return []TextEdit{}, nil
+	}
+
+	return []TextEdit{{Range: rangeOf(doc.text, 0, len(doc.text)), NewText: formatted}}, nil
+}
+
+// position reads the params of a request about a position, returning the document and the byte offset of the position.
+func (s *Server) position(params json.RawMessage) (*document, int, error) {
+	var p TextDocumentPositionParams
+	if err := decode(params, &p); err != nil {
+		return nil, 0, err
+	}
+
+	doc, err := s.document(p.TextDocument.URI)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return doc, offsetAt(doc.text, p.Position), nil
+}
+
+func (doc *document) rangeOf(node ast.Node) Range {
+	return rangeOf(doc.text, node.Pos().Offset, node.End().Offset)
+}
+
+// identifierAt returns the identifier at the byte offset of the program, if there's one.
+// The offset right after an identifier counts too, since that's where the cursor is once it's typed.
+func identifierAt(program *ast.Program, offset int) *ast.Identifier {
+	var found *ast.Identifier
+	ast.Inspect(program, func(n ast.Node) bool {
+		if ident, ok := n.(*ast.Identifier); ok && ident.Pos().Offset <= offset && offset <= ident.End().Offset {
+			found = ident
+		}
+
+		return found == nil
+	})
+
+	return found
+}
+
+// isMember reports whether the identifier names a member, like the 'map' of 'util.map'.
+func isMember(program *ast.Program, ident *ast.Identifier) bool {
+	member := false
+	ast.Inspect(program, func(n ast.Node) bool {
+		if me, ok := n.(*ast.MemberExpression); ok && me.Property == ident {
+			member = true
+		}
+
+		return !member
+	})
+
+	return member
+}
+
+// scopeAt returns the innermost scope of the resolution holding the byte offset.
+func scopeAt(r *lint.Resolution, offset int) *lint.Scope {
+	scope := r.Global
+	for _, s := range r.Scopes[1:] {
+		if s.Node.Pos().Offset <= offset && offset <= s.Node.End().Offset {
+			scope = s
+		}
+	}
+
+	return scope
+}
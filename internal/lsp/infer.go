@@ -0,0 +1,173 @@
+package lsp
+
+import (
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/lint"
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+// builtinResults holds the types of the values returned by the builtin functions, for the ones which always return the same one.
+var builtinResults = map[string]object.Type{
+	"length":    object.INTEGER_OBJ,
+	"lastIndex": object.INTEGER_OBJ,
+	"tail":      object.ARRAY_OBJ,
+	"push":      object.ARRAY_OBJ,
+	"print":     object.NULL_OBJ,
+	"bytes":     object.ARRAY_OBJ,
+	"input":     object.STRING_OBJ,
+	"eprint":    object.NULL_OBJ,
+	"assert":    object.NULL_OBJ,
+}
+
+// inference tells the types of the values of the bindings of a document, as far as they can be told without running it.
+// The types are the ones the runtime names the values with, like INTEGER, except for the functions,
+// which are described by their parameters, like 'fn(x, y)'.
+type inference struct {
+	resolution *lint.Resolution
+	// values maps the identifiers declaring a name to the values they're declared with.
+	values map[*ast.Identifier]ast.Expression
+	// assigned maps the identifiers a variable is assigned through to the values assigned to it.
+	assigned map[*ast.Identifier]ast.Expression
+	// known maps the identifiers declaring a name, whose type is known from the way they're declared, to it.
+	known map[*ast.Identifier]string
+	// inferring holds the bindings whose types are being inferred, so that the ones
+	// defined in terms of themselves don't take the inference around in circles.
+	inferring map[*lint.Binding]bool
+}
+
+func newInference(doc *document) *inference {
+	inf := &inference{
+		resolution: doc.resolution,
+		values:     make(map[*ast.Identifier]ast.Expression),
+		assigned:   make(map[*ast.Identifier]ast.Expression),
+		known:      make(map[*ast.Identifier]string),
+		inferring:  make(map[*lint.Binding]bool),
+	}
+
+	ast.Inspect(doc.program, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.ConstStatement:
+			inf.values[n.Name] = n.Value
+
+		case *ast.LetStatement:
+			inf.values[n.Name] = n.Value
+
+		case *ast.AssignExpression:
+			if ident, ok := n.Target.(*ast.Identifier); ok {
+				inf.assigned[ident] = n.Value
+			}
+
+		case *ast.FunctionLiteral:
+			if n.Rest != nil {
+				inf.known[n.Rest] = object.ARRAY_OBJ
+			}
+
+		case *ast.TryStatement:
+			if n.Param != nil {
+				inf.known[n.Param] = object.MAP_OBJ
+			}
+
+		case *ast.ImportStatement:
+			inf.known[n.Name] = object.MODULE_OBJ
+		}
+
+		return true
+	})
+
+	return inf
+}
+
+// bindingType returns the type of the value of the binding, or an empty string if it can't be told.
+// The variables only have a type if every value assigned to them has the same one.
+func (inf *inference) bindingType(b *lint.Binding) string {
+	if t, ok := inf.known[b.Decl]; ok {
+		return t
+	}
+
+	value, ok := inf.values[b.Decl]
+	if !ok || inf.inferring[b] {
+		return ""
+	}
+
+	inf.inferring[b] = true
+	defer delete(inf.inferring, b)
+
+	t := inf.typeOf(value)
+	for _, use := range b.Uses {
+		if value, ok := inf.assigned[use]; ok && inf.typeOf(value) != t {
+			return ""
+		}
+	}
+
+	return t
+}
+
+// typeOf returns the type of the value of the expression, or an empty string if it can't be told.
+func (inf *inference) typeOf(e ast.Expression) string {
+	switch e := e.(type) {
+	case *ast.IntegerLiteral:
+		return object.INTEGER_OBJ
+
+	case *ast.FloatLiteral:
+		return object.FLOAT_OBJ
+
+	case *ast.StringLiteral, *ast.TemplateLiteral:
+		return object.STRING_OBJ
+
+	case *ast.Boolean:
+		return object.BOOLEAN_OBJ
+
+	case *ast.ArrayLiteral:
+		return object.ARRAY_OBJ
+
+	case *ast.MapLiteral:
+		return object.MAP_OBJ
+
+	case *ast.FunctionLiteral:
+		return "fn(" + ast.FormatParameters(e.Parameters, e.Defaults, e.Rest) + ")"
+
+	case *ast.Identifier:
+		if b := inf.resolution.Refs[e]; b != nil {
+			return inf.bindingType(b)
+		}
+
+	case *ast.CallExpression:
+		// Calls to a name declared in the code don't call the builtin function, even if it has the same name.
+		if ident, ok := e.Function.(*ast.Identifier); ok && inf.resolution.Refs[ident] == nil {
+			return string(builtinResults[ident.Value])
+		}
+
+	case *ast.PrefixExpression:
+		switch t := inf.typeOf(e.Right); {
+		case e.Operator == "!":
+			return object.BOOLEAN_OBJ
+
+		case e.Operator == "-" && isNumber(t):
+			return t
+		}
+
+	case *ast.InfixExpression:
+		switch e.Operator {
+		case "==", "!=", "<", ">":
+			return object.BOOLEAN_OBJ
+		}
+
+		switch left, right := inf.typeOf(e.Left), inf.typeOf(e.Right); {
+		case left == object.INTEGER_OBJ && right == object.INTEGER_OBJ:
+			return object.INTEGER_OBJ
+
+		// Mixing integers and floats promotes the integers to floats.
+		case isNumber(left) && isNumber(right):
+			return object.FLOAT_OBJ
+
+		case left == object.STRING_OBJ && right == object.STRING_OBJ && e.Operator == "+":
+			return object.STRING_OBJ
+		}
+	}
+
+	return ""
+}
+
+func isNumber(t string) bool {
+	return t == object.INTEGER_OBJ || t == object.FLOAT_OBJ
+}
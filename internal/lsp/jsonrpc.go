@@ -0,0 +1,133 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// The codes of the errors sent back to the editor.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+	// codeServerNotInitialized answers the requests sent before the initialize one.
+	codeServerNotInitialized = -32002
+)
+
+// maxMessageSize is the largest content the server reads, so a wrong header can't make it run out of memory.
+const maxMessageSize = 64 << 20
+
+// Error is a JSON-RPC error, sent back in the response to a request.
+type Error struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// message is a request or a notification sent by the editor. Notifications don't have an ID.
+type message struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type response struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Result  interface{}      `json:"result"`
+}
+
+type errorResponse struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id"`
+	Error   *Error           `json:"error"`
+}
+
+type notification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+}
+
+// readMessage reads the content of the next message of the stream, which comes after a header like:
+//
+//	Content-Length: 42\r\n
+//	\r\n
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	length := -1
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF && line == "" && length == -1 {
+				return nil, io.EOF
+			}
+
+			return nil, fmt.Errorf("cannot read header: %s", err)
+		}
+
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			break
+		}
+
+		name, value, ok := cut(line, ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid header: %q", line)
+		}
+
+		if strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			length, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || length < 0 {
+				return nil, fmt.Errorf("invalid Content-Length: %q", value)
+			}
+			if length > maxMessageSize {
+				return nil, fmt.Errorf("message too large: %d bytes, the limit is %d", length, maxMessageSize)
+			}
+		}
+	}
+
+	if length == -1 {
+		return nil, fmt.Errorf("missing Content-Length header")
+	}
+
+	content := make([]byte, length)
+	if _, err := io.ReadFull(r, content); err != nil {
+		return nil, fmt.Errorf("cannot read message: %s", err)
+	}
+
+	return content, nil
+}
+
+// writeMessage writes the value to the stream as a JSON message, preceded by its header.
+func writeMessage(w io.Writer, v interface{}) error {
+	content, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintf(w, "Content-Length: %d\r\n\r\n", len(content)); err != nil {
+		return err
+	}
+
+	_, err = w.Write(content)
+
+	return err
+}
+
+// cut slices the string around the first instance of the separator.
+func cut(s, sep string) (before, after string, found bool) {
+	if i := strings.Index(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+
+	return s, "", false
+}
@@ -0,0 +1,590 @@
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axbarsan/doggo/internal/lint"
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+const testURI = "file:///home/doggo/main.doggo"
+
+// client plays the part of an editor, talking to a server over a pair of pipes.
+type client struct {
+	t      *testing.T
+	in     io.WriteCloser
+	nextID int
+	// messages receives the messages sent by the server, and done its result once it stops.
+	messages chan *incoming
+	done     chan error
+}
+
+// incoming is a message sent by the server.
+type incoming struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+func newClient(t *testing.T, config *lint.Config) *client {
+	t.Helper()
+
+	serverIn, clientOut := io.Pipe()
+	clientIn, serverOut := io.Pipe()
+
+	c := &client{t: t, in: clientOut, messages: make(chan *incoming, 100), done: make(chan error, 1)}
+
+	go func() {
+		err := NewServer(serverIn, serverOut, config).Serve()
+		serverOut.Close()
+		c.done <- err
+	}()
+
+	go func() {
+		r := bufio.NewReader(clientIn)
+		for {
+			content, err := readMessage(r)
+			if err != nil {
+				close(c.messages)
+
+				return
+			}
+
+			msg := &incoming{}
+			if err := json.Unmarshal(content, msg); err != nil {
+				t.Errorf("invalid message from the server: %s", content)
+			}
+			c.messages <- msg
+		}
+	}()
+
+	return c
+}
+
+func (c *client) send(v interface{}) {
+	c.t.Helper()
+
+	if err := writeMessage(c.in, v); err != nil {
+		c.t.Fatalf("cannot send message: %s", err)
+	}
+}
+
+// next returns the next message sent by the server.
+func (c *client) next() *incoming {
+	c.t.Helper()
+
+	select {
+	case msg, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the server stopped sending messages")
+		}
+
+		return msg
+
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for a message from the server")
+	}
+
+	return nil
+}
+
+// call sends a request, and decodes the result of its response into the value, unless it's an error, which is returned.
+func (c *client) call(method string, params, result interface{}) *Error {
+	c.t.Helper()
+
+	c.nextID++
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+
+	for {
+		msg := c.next()
+		if msg.ID == nil || *msg.ID != c.nextID {
+			continue
+		}
+
+		if msg.Error != nil {
+			return msg.Error
+		}
+
+		if result != nil {
+			if err := json.Unmarshal(msg.Result, result); err != nil {
+				c.t.Fatalf("invalid result of %s: %s", method, msg.Result)
+			}
+		}
+
+		return nil
+	}
+}
+
+func (c *client) notify(method string, params interface{}) {
+	c.t.Helper()
+
+	c.send(map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// diagnostics waits for the next diagnostics published by the server.
+func (c *client) diagnostics() *PublishDiagnosticsParams {
+	c.t.Helper()
+
+	msg := c.next()
+	if msg.Method != "textDocument/publishDiagnostics" {
+		c.t.Fatalf("expected diagnostics, got=%+v", msg)
+	}
+
+	params := &PublishDiagnosticsParams{}
+	if err := json.Unmarshal(msg.Params, params); err != nil {
+		c.t.Fatal(err)
+	}
+
+	return params
+}
+
+// open initializes the server, and opens a document with the text, returning its diagnostics.
+func (c *client) open(text string) []Diagnostic {
+	c.t.Helper()
+
+	if err := c.call("initialize", map[string]interface{}{"capabilities": map[string]interface{}{}}, nil); err != nil {
+		c.t.Fatalf("cannot initialize: %s", err)
+	}
+	c.notify("initialized", map[string]interface{}{})
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: testURI, LanguageID: "doggo", Version: 1, Text: text},
+	})
+
+	return c.diagnostics().Diagnostics
+}
+
+func (c *client) change(version int, text string) []Diagnostic {
+	c.t.Helper()
+
+	c.notify("textDocument/didChange", DidChangeTextDocumentParams{
+		TextDocument:   VersionedTextDocumentIdentifier{URI: testURI, Version: version},
+		ContentChanges: []TextDocumentContentChangeEvent{{Text: text}},
+	})
+
+	return c.diagnostics().Diagnostics
+}
+
+// exit shuts the server down, and checks that it stopped fine.
+func (c *client) exit() {
+	c.t.Helper()
+
+	if err := c.call("shutdown", nil, nil); err != nil {
+		c.t.Fatalf("cannot shut down: %s", err)
+	}
+	c.notify("exit", nil)
+
+	select {
+	case err := <-c.done:
+		if err != nil {
+			c.t.Errorf("the server stopped with an error: %s", err)
+		}
+
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("timed out waiting for the server to stop")
+	}
+}
+
+func at(line, character int) TextDocumentPositionParams {
+	return TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: testURI}, Position: Position{line, character}}
+}
+
+func span(startLine, startCharacter, endLine, endCharacter int) Range {
+	return Range{Position{startLine, startCharacter}, Position{endLine, endCharacter}}
+}
+
+func TestLifecycle(t *testing.T) {
+	c := newClient(t, nil)
+
+	if err := c.call("textDocument/hover", at(0, 0), nil); err == nil || err.Code != codeServerNotInitialized {
+		t.Errorf("expected a request before initialize to fail, got=%v", err)
+	}
+
+	var result InitializeResult
+	if err := c.call("initialize", map[string]interface{}{"processId": nil, "capabilities": map[string]interface{}{}}, &result); err != nil {
+		t.Fatalf("cannot initialize: %s", err)
+	}
+
+	capabilities := result.Capabilities
+	if capabilities.TextDocumentSync.Change != TextDocumentSyncFull || !capabilities.DefinitionProvider ||
+		!capabilities.HoverProvider || !capabilities.DocumentFormattingProvider {
+		t.Errorf("wrong capabilities: %+v", capabilities)
+	}
+
+	if err := c.call("initialize", map[string]interface{}{}, nil); err == nil || err.Code != codeInvalidRequest {
+		t.Errorf("expected a second initialize to fail, got=%v", err)
+	}
+
+	// Unknown notifications are ignored, while unknown requests fail.
+	c.notify("$/cancelRequest", map[string]interface{}{"id": 1})
+	if err := c.call("workspace/symbol", map[string]interface{}{}, nil); err == nil || err.Code != codeMethodNotFound {
+		t.Errorf("expected an unknown method to fail, got=%v", err)
+	}
+
+	if err := c.call("textDocument/hover", at(0, 0), nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("expected a request about an unknown document to fail, got=%v", err)
+	}
+
+	if err := c.call("textDocument/hover", "nope", nil); err == nil || err.Code != codeInvalidParams {
+		t.Errorf("expected a request with invalid params to fail, got=%v", err)
+	}
+
+	c.in.Write([]byte("Content-Length: 5\r\n\r\n{nope"))
+	if msg := c.next(); msg.Error == nil || msg.Error.Code != codeParseError {
+		t.Errorf("expected an invalid message to fail, got=%+v", msg)
+	}
+
+	if err := c.call("shutdown", nil, nil); err != nil {
+		t.Fatalf("cannot shut down: %s", err)
+	}
+
+	if err := c.call("textDocument/hover", at(0, 0), nil); err == nil || err.Code != codeInvalidRequest {
+		t.Errorf("expected a request after shutdown to fail, got=%v", err)
+	}
+
+	c.notify("exit", nil)
+	if err := <-c.done; err != nil {
+		t.Errorf("the server stopped with an error: %s", err)
+	}
+}
+
+func TestExitWithoutShutdown(t *testing.T) {
+	c := newClient(t, nil)
+	c.open("1")
+	c.in.Close()
+
+	if err := <-c.done; err == nil || !strings.Contains(err.Error(), "without shutting the server down") {
+		t.Errorf("expected an error, got=%v", err)
+	}
+}
+
+func TestOversizedMessage(t *testing.T) {
+	c := newClient(t, nil)
+	if _, err := io.WriteString(c.in, "Content-Length: 99999999999\r\n\r\n"); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if err := <-c.done; err == nil || !strings.HasPrefix(err.Error(), "message too large") {
+		t.Errorf("expected an error, got=%v", err)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	c := newClient(t, &lint.Config{Globals: []string{"args"}})
+
+	testCases := []struct {
+		text     string
+		expected []Diagnostic
+	}{
+		{"print(args);", []Diagnostic{}},
+		{
+			"let x = 1;\nlet = 2;",
+			[]Diagnostic{{Range: span(1, 4, 1, 5), Severity: SeverityError, Code: "unexpected-token", Source: source, Message: "expected a name, got '=' instead"}},
+		},
+		{
+			"print(\"🐶\" +);",
+			[]Diagnostic{{Range: span(0, 12, 0, 13), Severity: SeverityError, Code: "unexpected-token", Source: source, Message: "expected an expression, got ')' instead"}},
+		},
+		{
+			"const dog = \"🐶\"; const unused = dog;\nprint(cat);",
+			[]Diagnostic{
+				{Range: span(0, 24, 0, 30), Severity: SeverityWarning, Code: "unused-const", Source: source, Message: "constant unused is never used"},
+				{Range: span(1, 6, 1, 9), Severity: SeverityWarning, Code: "unknown-identifier", Source: source, Message: "identifier not found: cat"},
+			},
+		},
+		{
+			"length(\"🐶\", 2);",
+			[]Diagnostic{{Range: span(0, 0, 0, 15), Severity: SeverityWarning, Code: "builtin-arity", Source: source, Message: "wrong number of arguments to length. got=2, want=1"}},
+		},
+	}
+
+	c.open("")
+	for i, tc := range testCases {
+		got := c.change(i+2, tc.text)
+		if !reflect.DeepEqual(got, tc.expected) {
+			t.Errorf("wrong diagnostics for %q.\nexpected=%+v\ngot=     %+v", tc.text, tc.expected, got)
+		}
+	}
+
+	c.notify("textDocument/didClose", DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: testURI}})
+	if got := c.diagnostics(); got.URI != testURI || len(got.Diagnostics) != 0 {
+		t.Errorf("expected the diagnostics to be cleared, got=%+v", got)
+	}
+
+	c.exit()
+}
+
+func TestLintConfig(t *testing.T) {
+	c := newClient(t, &lint.Config{Rules: map[string]bool{"unused-const": false}})
+
+	if got := c.open("const unused = 1;\nprint(args);"); len(got) != 1 || got[0].Code != "unknown-identifier" {
+		t.Errorf("wrong diagnostics: %+v", got)
+	}
+
+	c.exit()
+}
+
+func TestTestFiles(t *testing.T) {
+	c := newClient(t, nil)
+	c.call("initialize", map[string]interface{}{}, nil)
+
+	c.notify("textDocument/didOpen", DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: "file:///home/doggo/sum_test.doggo", Version: 1, Text: "const testSum = fn() {};"},
+	})
+
+	if got := c.diagnostics(); len(got.Diagnostics) != 0 {
+		t.Errorf("expected no diagnostics for a test file, got=%+v", got.Diagnostics)
+	}
+
+	c.exit()
+}
+
+const testProgram = `import "functional.doggo" as functional;
+let count = 0;
+const double = fn(x, ...rest) {
+    count += 1;
+    let label = "double";
+    x * 2
+};
+try { double(length([1])) } catch (e) { print(e) };
+const ratio = -count / 2.5;
+const half = fn(n) { n / 2 }(4);
+let changed = 1;
+changed = "one";
+print(functional.map, ratio, half, changed, label);`
+
+func TestDefinition(t *testing.T) {
+	c := newClient(t, nil)
+	c.open(testProgram)
+
+	testCases := []struct {
+		pos      TextDocumentPositionParams
+		expected *Range
+	}{
+		{at(3, 5), &Range{Position{1, 4}, Position{1, 9}}},    // count += 1
+		{at(3, 4), &Range{Position{1, 4}, Position{1, 9}}},    // The start of the name.
+		{at(3, 9), &Range{Position{1, 4}, Position{1, 9}}},    // The end of the name.
+		{at(5, 4), &Range{Position{2, 18}, Position{2, 19}}},  // x, a parameter.
+		{at(7, 8), &Range{Position{2, 6}, Position{2, 12}}},   // double
+		{at(2, 8), &Range{Position{2, 6}, Position{2, 12}}},   // The declaration itself.
+		{at(7, 47), &Range{Position{7, 35}, Position{7, 36}}}, // The caught error.
+		{at(12, 8), &Range{Position{0, 29}, Position{0, 39}}}, // The import.
+		{at(12, 20), nil}, // The member of the module.
+		{at(7, 16), nil},  // The length builtin.
+		{at(12, 47), nil}, // label, declared in another function.
+		{at(3, 12), nil},  // Not an identifier.
+	}
+
+	for _, tc := range testCases {
+		var got *Location
+		if err := c.call("textDocument/definition", tc.pos, &got); err != nil {
+			t.Fatalf("definition failed: %s", err)
+		}
+
+		if tc.expected == nil {
+			if got != nil {
+				t.Errorf("expected no definition at %+v, got=%+v", tc.pos.Position, got)
+			}
+
+			continue
+		}
+
+		if got == nil || got.URI != testURI || got.Range != *tc.expected {
+			t.Errorf("wrong definition at %+v. expected=%+v, got=%+v", tc.pos.Position, tc.expected, got)
+		}
+	}
+
+	// Positions can't be trusted while the document has syntax errors.
+	c.change(2, testProgram+" let")
+
+	var got *Location
+	if err := c.call("textDocument/definition", at(3, 5), &got); err != nil || got != nil {
+		t.Errorf("expected no definition in a broken document, got=%+v, err=%v", got, err)
+	}
+
+	c.exit()
+}
+
+func TestHover(t *testing.T) {
+	c := newClient(t, nil)
+	c.open(testProgram)
+
+	testCases := []struct {
+		pos      TextDocumentPositionParams
+		expected string
+	}{
+		{at(1, 5), "```doggo\nlet count: INTEGER\n```"},
+		{at(2, 8), "```doggo\nconst double: fn(x, ...rest)\n```"},
+		{at(2, 18), "```doggo\nparam x\n```"},
+		{at(2, 25), "```doggo\nparam rest: ARRAY\n```"},
+		{at(4, 9), "```doggo\nlet label: STRING\n```"},
+		{at(7, 35), "```doggo\nlet e: MAP\n```"},
+		{at(8, 8), "```doggo\nconst ratio: FLOAT\n```"},
+		{at(9, 8), "```doggo\nconst half\n```"},
+		{at(10, 6), "```doggo\nlet changed\n```"},
+		{at(12, 2), "```doggo\nbuiltin print: BUILTIN\n```\n\nTakes at least 0 arguments, and returns NULL."},
+		{at(7, 15), "```doggo\nbuiltin length: BUILTIN\n```\n\nTakes 1 argument, and returns INTEGER."},
+		{at(12, 10), "```doggo\nimport functional: MODULE\n```"},
+		{at(12, 47), ""},
+		{at(12, 19), ""},
+	}
+
+	for _, tc := range testCases {
+		var got *Hover
+		if err := c.call("textDocument/hover", tc.pos, &got); err != nil {
+			t.Fatalf("hover failed: %s", err)
+		}
+
+		if tc.expected == "" {
+			if got != nil {
+				t.Errorf("expected no hover at %+v, got=%+v", tc.pos.Position, got)
+			}
+
+			continue
+		}
+
+		if got == nil || got.Contents.Kind != "markdown" || got.Contents.Value != tc.expected {
+			t.Errorf("wrong hover at %+v.\nexpected=%q\ngot=     %+v", tc.pos.Position, tc.expected, got)
+		}
+	}
+
+	c.exit()
+}
+
+func TestCompletion(t *testing.T) {
+	c := newClient(t, nil)
+	c.open("let count = 0;\nconst f = fn(x, tail) {\n    let y = x;\n    \n};\nconst g = fn(z) { z };")
+
+	complete := func(line, character int) map[string]CompletionItem {
+		t.Helper()
+
+		var items []CompletionItem
+		if err := c.call("textDocument/completion", at(line, character), &items); err != nil {
+			t.Fatalf("completion failed: %s", err)
+		}
+
+		labels := make(map[string]CompletionItem)
+		for _, item := range items {
+			labels[item.Label] = item
+		}
+
+		return labels
+	}
+
+	items := complete(3, 4)
+
+	expected := map[string]CompletionItem{
+		"count":  {"count", CompletionVariable, "let INTEGER"},
+		"f":      {"f", CompletionConstant, "const fn(x, tail)"},
+		"x":      {"x", CompletionVariable, "param"},
+		"y":      {"y", CompletionVariable, "let"},
+		"tail":   {"tail", CompletionVariable, "param"},
+		"length": {"length", CompletionFunction, "builtin"},
+		"push":   {"push", CompletionFunction, "builtin"},
+	}
+	for label, item := range expected {
+		if items[label] != item {
+			t.Errorf("wrong completion item %s. expected=%+v, got=%+v", label, item, items[label])
+		}
+	}
+
+	if _, ok := items["z"]; ok {
+		t.Errorf("the parameter of another function was offered")
+	}
+
+	// The tail parameter hides the builtin function.
+	if len(items) != 6+len(object.Builtins)-1 {
+		t.Errorf("wrong number of completion items. got=%d", len(items))
+	}
+
+	// While typing, the names come from the last text without syntax errors.
+	c.change(2, "let count = 0;\nconst f = fn(x, tail) {\n    let y = x;\n    co\n};\nconst g = fn(z) { z };\nlet")
+
+	items = complete(3, 6)
+	if _, ok := items["count"]; !ok {
+		t.Errorf("expected count to be offered while typing, got=%v", items)
+	}
+	if _, ok := items["x"]; ok {
+		t.Errorf("expected only the global names to be offered while typing, got=%v", items)
+	}
+
+	c.exit()
+}
+
+func TestFormatting(t *testing.T) {
+	c := newClient(t, nil)
+	c.open("let x=[1,2]\nprint( x )")
+
+	format := func() []TextEdit {
+		t.Helper()
+
+		var edits []TextEdit
+		if err := c.call("textDocument/formatting", DocumentFormattingParams{TextDocument: TextDocumentIdentifier{URI: testURI}}, &edits); err != nil {
+			t.Fatalf("formatting failed: %s", err)
+		}
+
+		return edits
+	}
+
+	expected := []TextEdit{{Range: span(0, 0, 1, 10), NewText: "let x = [1, 2];\nprint(x);\n"}}
+	if got := format(); !reflect.DeepEqual(got, expected) {
+		t.Errorf("wrong edits.\nexpected=%+v\ngot=     %+v", expected, got)
+	}
+
+	c.change(2, "let x = [1, 2];\nprint(x);\n")
+	if got := format(); len(got) != 0 {
+		t.Errorf("expected no edits for a formatted document, got=%+v", got)
+	}
+
+	c.change(3, "let x = ;")
+	if got := format(); len(got) != 0 {
+		t.Errorf("expected no edits for a broken document, got=%+v", got)
+	}
+
+	c.exit()
+}
+
+func TestPositions(t *testing.T) {
+	text := "ab\n🐶 é x\n\nlast"
+
+	testCases := []struct {
+		pos    Position
+		offset int
+	}{
+		{Position{0, 0}, 0},
+		{Position{0, 2}, 2},
+		{Position{1, 0}, 3},
+		{Position{1, 2}, 7},  // After the dog, which takes 2 UTF-16 code units and 4 bytes.
+		{Position{1, 3}, 8},  // é takes 2 bytes.
+		{Position{1, 5}, 11}, // x
+		{Position{2, 0}, 13}, // The empty line.
+		{Position{3, 4}, 18}, // The end of the text.
+	}
+
+	for _, tc := range testCases {
+		if got := offsetAt(text, tc.pos); got != tc.offset {
+			t.Errorf("wrong offset of %+v. expected=%d, got=%d", tc.pos, tc.offset, got)
+		}
+
+		if got := positionAt(text, tc.offset); got != tc.pos {
+			t.Errorf("wrong position of %d. expected=%+v, got=%+v", tc.offset, tc.pos, got)
+		}
+	}
+
+	// Positions past the end of their line stop at the end of it.
+	if got := offsetAt(text, Position{0, 10}); got != 2 {
+		t.Errorf("wrong offset past the end of a line. got=%d", got)
+	}
+
+	if got := offsetAt(text, Position{10, 0}); got != len(text) {
+		t.Errorf("wrong offset past the end of the text. got=%d", got)
+	}
+}
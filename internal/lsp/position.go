@@ -0,0 +1,86 @@
+package lsp
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// The positions of the editor count the characters of a line in UTF-16 code units, while the
+// ones of the lexer count them in runes, and come with byte offsets. These convert between them.
+
+// offsetAt returns the byte offset of the position in the text. Positions past the end of their
+// line stop at the end of it, and the ones past the end of the text stop at the end of the text.
+func offsetAt(text string, pos Position) int {
+	offset := 0
+	for line := 0; line < pos.Line; line++ {
+		i := strings.IndexByte(text[offset:], '\n')
+		if i < 0 {
+			return len(text)
+		}
+		offset += i + 1
+	}
+
+	units := 0
+	for i, ch := range text[offset:] {
+		if units >= pos.Character || ch == '\n' {
+			return offset + i
+		}
+		units += utf16Len(ch)
+	}
+
+	return len(text)
+}
+
+// positionAt returns the position of the byte offset in the text.
+func positionAt(text string, offset int) Position {
+	if offset > len(text) {
+		offset = len(text)
+	}
+
+	lineStart := strings.LastIndexByte(text[:offset], '\n') + 1
+
+	character := 0
+	for _, ch := range text[lineStart:offset] {
+		character += utf16Len(ch)
+	}
+
+	return Position{Line: strings.Count(text[:lineStart], "\n"), Character: character}
+}
+
+// rangeOf returns the range between the byte offsets of the text.
+func rangeOf(text string, start, end int) Range {
+	return Range{Start: positionAt(text, start), End: positionAt(text, end)}
+}
+
+// wordRange returns the range of the word starting at the byte offset, or of the character
+// there if it's not a word, so that the problems found there can be underlined.
+func wordRange(text string, offset int) Range {
+	end := offset
+	for end < len(text) {
+		ch, size := utf8.DecodeRuneInString(text[end:])
+		if !isWordChar(ch) {
+			break
+		}
+		end += size
+	}
+
+	if end == offset && offset < len(text) && text[offset] != '\n' {
+		_, size := utf8.DecodeRuneInString(text[offset:])
+		end += size
+	}
+
+	return rangeOf(text, offset, end)
+}
+
+func isWordChar(ch rune) bool {
+	return ch == '_' || unicode.IsLetter(ch) || unicode.IsDigit(ch)
+}
+
+func utf16Len(ch rune) int {
+	if ch >= 0x10000 {
+		return 2
+	}
+
+	return 1
+}
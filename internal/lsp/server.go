@@ -0,0 +1,364 @@
+// Package lsp implements a language server for doggo, which gives the editors speaking the
+// Language Server Protocol the problems of the code as it's typed, along with go to definition,
+// completion, hover and formatting. The server talks to one editor, over a stream.
+package lsp
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/lint"
+	"github.com/axbarsan/doggo/internal/runner"
+)
+
+// source names the server in the diagnostics it publishes.
+const source = "doggo"
+
+// Server is a language server, reading the messages of the editor from a stream and writing its own to another.
+type Server struct {
+	in     *bufio.Reader
+	out    io.Writer
+	config *lint.Config
+
+	// docs maps the URIs of the documents opened in the editor to them.
+	docs        map[string]*document
+	initialized bool
+	shutdown    bool
+}
+
+// document is a file opened in the editor, whose text is kept by the server while it's edited.
+type document struct {
+	uri      string
+	fileName string
+	version  int
+	text     string
+
+	// program is the syntax tree of the text, and resolution tells what its names refer to.
+	// They are both nil while the text has syntax errors.
+	program    *ast.Program
+	resolution *lint.Resolution
+	// lastResolution is the resolution of the last text without syntax errors, for the completion of the code being typed.
+	lastResolution *lint.Resolution
+
+	diagnostics []Diagnostic
+}
+
+// NewServer creates a server talking over the streams. The diagnostics of the documents come from
+// their syntax errors, and from the lint rules turned on by the config, which can be nil.
+func NewServer(in io.Reader, out io.Writer, config *lint.Config) *Server {
+	if config == nil {
+		config = &lint.Config{}
+	}
+
+	return &Server{
+		in:     bufio.NewReader(in),
+		out:    out,
+		config: config,
+		docs:   make(map[string]*document),
+	}
+}
+
+// Serve handles the messages of the editor, until it sends the exit notification or closes the stream.
+// An error is returned if the stream broke, or if the editor didn't shut the server down before leaving.
+func (s *Server) Serve() error {
+	for {
+		content, err := readMessage(s.in)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		var msg message
+		if err := json.Unmarshal(content, &msg); err != nil {
+			if err := s.replyError(nil, &Error{codeParseError, fmt.Sprintf("invalid message: %s", err)}); err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		if msg.Method == "exit" {
+			break
+		}
+
+		if err := s.handle(&msg); err != nil {
+			return err
+		}
+	}
+
+	if !s.shutdown {
+		return errors.New("the editor left without shutting the server down")
+	}
+
+	return nil
+}
+
+// handle answers a request, or acts on a notification, returning the errors of the stream.
+func (s *Server) handle(msg *message) error {
+	// Requests have an ID, and get a response. The responses to the requests of the server,
+	// which has none, are ignored along with the other messages without a method.
+	if msg.ID == nil {
+		if msg.Method != "" {
+			return s.notify(msg)
+		}
+
+		return nil
+	}
+
+	result, err := s.call(msg)
+	if err != nil {
+		rpcErr, ok := err.(*Error)
+		if !ok {
+			rpcErr = &Error{codeInternalError, err.Error()}
+		}
+
+		return s.replyError(msg.ID, rpcErr)
+	}
+
+	return writeMessage(s.out, &response{JSONRPC: "2.0", ID: msg.ID, Result: result})
+}
+
+type requestHandler func(s *Server, params json.RawMessage) (interface{}, error)
+
+type notificationHandler func(s *Server, params json.RawMessage) error
+
+var (
+	requestHandlers      map[string]requestHandler
+	notificationHandlers map[string]notificationHandler
+)
+
+func init() {
+	requestHandlers = map[string]requestHandler{
+		"initialize":              (*Server).initialize,
+		"shutdown":                (*Server).shutdownRequest,
+		"textDocument/definition": (*Server).definition,
+		"textDocument/completion": (*Server).completion,
+		"textDocument/hover":      (*Server).hover,
+		"textDocument/formatting": (*Server).formatting,
+	}
+
+	notificationHandlers = map[string]notificationHandler{
+		"textDocument/didOpen":   (*Server).didOpen,
+		"textDocument/didChange": (*Server).didChange,
+		"textDocument/didClose":  (*Server).didClose,
+	}
+}
+
+// call runs the handler of the request. The code of the server panicking on a document it didn't
+// expect makes the request fail, instead of taking the editor's server down with it.
+func (s *Server) call(msg *message) (result interface{}, err error) {
+	handler, ok := requestHandlers[msg.Method]
+	switch {
+	case !ok:
+		return nil, &Error{codeMethodNotFound, fmt.Sprintf("unknown method: %s", msg.Method)}
+
+	case s.shutdown:
+		return nil, &Error{codeInvalidRequest, "the server was shut down"}
+
+	case !s.initialized && msg.Method != "initialize":
+		return nil, &Error{codeServerNotInitialized, "the server is not initialized"}
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("internal error: %v", r)
+		}
+	}()
+
+	return handler(s, msg.Params)
+}
+
+// notify runs the handler of the notification. Unknown notifications, like the optional
+// ones starting with '$/', are ignored, as are the ones the server can't make sense of.
+func (s *Server) notify(msg *message) (err error) {
+	handler, ok := notificationHandlers[msg.Method]
+	if !ok || !s.initialized || s.shutdown {
+		return nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = nil
+		}
+	}()
+
+	return handler(s, msg.Params)
+}
+
+func (s *Server) replyError(id *json.RawMessage, err *Error) error {
+	return writeMessage(s.out, &errorResponse{JSONRPC: "2.0", ID: id, Error: err})
+}
+
+// decode reads the params of a message, which fail the request if they're not what the method expects.
+func decode(params json.RawMessage, v interface{}) error {
+	if err := json.Unmarshal(params, v); err != nil {
+		return &Error{codeInvalidParams, fmt.Sprintf("invalid params: %s", err)}
+	}
+
+	return nil
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, error) {
+	if s.initialized {
+		return nil, &Error{codeInvalidRequest, "the server is already initialized"}
+	}
+	s.initialized = true
+
+	result := &InitializeResult{ServerInfo: ServerInfo{Name: "doggo"}}
+	result.Capabilities.TextDocumentSync = TextDocumentSyncOptions{OpenClose: true, Change: TextDocumentSyncFull}
+	result.Capabilities.DefinitionProvider = true
+	result.Capabilities.HoverProvider = true
+	result.Capabilities.DocumentFormattingProvider = true
+
+	return result, nil
+}
+
+func (s *Server) shutdownRequest(params json.RawMessage) (interface{}, error) {
+	s.shutdown = true
+
+	return nil, nil
+}
+
+func (s *Server) didOpen(params json.RawMessage) error {
+	var p DidOpenTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil
+	}
+
+	doc := &document{uri: p.TextDocument.URI, fileName: fileName(p.TextDocument.URI)}
+	s.docs[doc.uri] = doc
+
+	return s.update(doc, p.TextDocument.Version, p.TextDocument.Text)
+}
+
+func (s *Server) didChange(params json.RawMessage) error {
+	var p DidChangeTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil
+	}
+
+	doc, ok := s.docs[p.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	// The server asks for the whole text on every change, but the editors may send ranges anyway.
+	text := doc.text
+	for _, change := range p.ContentChanges {
+		if change.Range == nil {
+			text = change.Text
+		} else {
+			start, end := offsetAt(text, change.Range.Start), offsetAt(text, change.Range.End)
+			text = text[:start] + change.Text + text[end:]
+		}
+	}
+
+	return s.update(doc, p.TextDocument.Version, text)
+}
+
+func (s *Server) didClose(params json.RawMessage) error {
+	var p DidCloseTextDocumentParams
+	if err := decode(params, &p); err != nil {
+		return nil
+	}
+
+	if _, ok := s.docs[p.TextDocument.URI]; !ok {
+		return nil
+	}
+	delete(s.docs, p.TextDocument.URI)
+
+	// The problems of a closed document go away with it.
+	return s.publish(&document{uri: p.TextDocument.URI})
+}
+
+// update parses the new text of the document, and publishes its problems.
+func (s *Server) update(doc *document, version int, text string) error {
+	doc.version = version
+	doc.text = text
+	doc.program, doc.resolution = nil, nil
+	doc.diagnostics = nil
+
+	program, errors := runner.Parse("", text)
+	if len(errors) != 0 {
+		for _, d := range errors {
+			doc.diagnostics = append(doc.diagnostics, doc.syntaxError(d))
+		}
+
+		return s.publish(doc)
+	}
+
+	doc.program = program
+	doc.resolution = lint.Resolve(program)
+	doc.lastResolution = doc.resolution
+
+	for _, d := range lint.Lint(doc.fileName, program, s.config) {
+		doc.diagnostics = append(doc.diagnostics, Diagnostic{
+			Range:    rangeOf(text, d.Span.Start.Offset, d.Span.End.Offset),
+			Severity: SeverityWarning,
+			Code:     d.Code,
+			Source:   source,
+			Message:  d.Message,
+		})
+	}
+
+	return s.publish(doc)
+}
+
+func (s *Server) publish(doc *document) error {
+	diagnostics := doc.diagnostics
+	if diagnostics == nil {
+		diagnostics = []Diagnostic{}
+	}
+
+	return writeMessage(s.out, &notification{
+		JSONRPC: "2.0",
+		Method:  "textDocument/publishDiagnostics",
+		Params:  &PublishDiagnosticsParams{URI: doc.uri, Version: doc.version, Diagnostics: diagnostics},
+	})
+}
+
+// syntaxError turns an error of the parser into a diagnostic of the protocol. The errors
+// without a span, like the one telling there are too many of them, are put at the start of the document.
+func (doc *document) syntaxError(d *diag.Diagnostic) Diagnostic {
+	start, end := d.Span.Start.Offset, d.Span.End.Offset
+	r := rangeOf(doc.text, start, end)
+	if end <= start {
+		r = wordRange(doc.text, start)
+	}
+
+	severity := SeverityError
+	if d.Severity != diag.Error {
+		severity = SeverityInformation
+	}
+
+	return Diagnostic{Range: r, Severity: severity, Code: d.Code, Source: source, Message: d.Message}
+}
+
+// document returns the open document with the URI.
+func (s *Server) document(uri string) (*document, error) {
+	doc, ok := s.docs[uri]
+	if !ok {
+		return nil, &Error{codeInvalidParams, fmt.Sprintf("unknown document: %s", uri)}
+	}
+
+	return doc, nil
+}
+
+// fileName returns the path of the file behind the URI, or the URI itself if it isn't a file,
+// so that the lint rules can tell the test files apart.
+func fileName(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return uri
+	}
+
+	return u.Path
+}
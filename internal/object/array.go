@@ -1,10 +1,5 @@
 package object
 
-import (
-	"bytes"
-	"strings"
-)
-
 const (
 	ARRAY_OBJ = "ARRAY"
 )
@@ -18,16 +13,5 @@ func (ao *Array) Type() Type {
 }
 
 func (ao *Array) Inspect() string {
-	var out bytes.Buffer
-
-	var elements []string
-	for _, e := range ao.Elements {
-		elements = append(elements, e.Inspect())
-	}
-
-	out.WriteString("[")
-	out.WriteString(strings.Join(elements, ", "))
-	out.WriteString("]")
-
-	return out.String()
+	return inspect(ao, make(map[Object]bool))
 }
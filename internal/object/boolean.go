@@ -8,6 +8,12 @@ const (
 	BOOLEAN_OBJ = "BOOLEAN"
 )
 
+// TRUE and FALSE are the only instances of Boolean, so they can be compared by identity.
+var (
+	TRUE  = &Boolean{Value: true}
+	FALSE = &Boolean{Value: false}
+)
+
 type Boolean struct {
 	Value bool
 }
@@ -6,8 +6,34 @@ const (
 
 type BuiltinFunction func(args ...Object) Object
 
+// IOFunction is a builtin function reading or writing through the streams of the running code.
+type IOFunction func(streams *IO, args ...Object) Object
+
+// VARIADIC is the MaxArgs of the builtin functions taking any number of arguments.
+const VARIADIC = -1
+
 type Builtin struct {
 	Fn BuiltinFunction
+	// IOFn takes the place of Fn for the builtin functions using the streams of the running code.
+	IOFn IOFunction
+	// MinArgs and MaxArgs bound the number of arguments the function takes,
+	// so the calls can be checked without running them.
+	MinArgs int
+	MaxArgs int
+}
+
+// Call calls the builtin function, handing it the streams given by the options, if it needs them.
+func (b *Builtin) Call(options *Options, args ...Object) Object {
+	if b.IOFn == nil {
+		return b.Fn(args...)
+	}
+
+	streams := StdIO
+	if options != nil && options.IO != nil {
+		streams = options.IO
+	}
+
+	return b.IOFn(streams, args...)
 }
 
 func (b *Builtin) Type() Type {
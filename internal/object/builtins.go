@@ -0,0 +1,203 @@
+package object
+
+import (
+	"fmt"
+	"unicode/utf8"
+)
+
+// Builtins holds the functions available in every doggo program.
+// The order matters, since compiled code refers to them by their index.
+var Builtins = []struct {
+	Name    string
+	Builtin *Builtin
+}{
+	{"length", &Builtin{Fn: lengthFn, MinArgs: 1, MaxArgs: 1}},
+	{"lastIndex", &Builtin{Fn: lastIndexFn, MinArgs: 1, MaxArgs: 1}},
+	{"tail", &Builtin{Fn: tailFn, MinArgs: 1, MaxArgs: 1}},
+	{"push", &Builtin{Fn: pushFn, MinArgs: 2, MaxArgs: 2}},
+	{"print", &Builtin{IOFn: printFn, MaxArgs: VARIADIC}},
+	{"bytes", &Builtin{Fn: bytesFn, MinArgs: 1, MaxArgs: 1}},
+	{"input", &Builtin{IOFn: inputFn, MaxArgs: 1}},
+	{"readLine", &Builtin{IOFn: readLineFn}},
+	{"eprint", &Builtin{IOFn: eprintFn, MaxArgs: VARIADIC}},
+	{"assert", &Builtin{Fn: assertFn, MinArgs: 1, MaxArgs: 2}},
+}
+
+// GetBuiltinByName returns the builtin function with the given name, or nil if there's none.
+func GetBuiltinByName(name string) *Builtin {
+	for _, b := range Builtins {
+		if b.Name == name {
+			return b.Builtin
+		}
+	}
+
+	return nil
+}
+
+func newError(code, format string, a ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+func lengthFn(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=1", len(args))
+	}
+
+	switch arg := args[0].(type) {
+	case *Array:
+		return &Integer{Value: int64(len(arg.Elements))}
+
+	case *String:
+		// Strings are measured in chars, not in the bytes of their UTF-8 encoding.
+		return &Integer{Value: int64(utf8.RuneCountInString(arg.Value))}
+
+	default:
+		return newError("wrong-arguments", "argument to 'length' is not supported, got %s", args[0].Type())
+	}
+}
+
+func lastIndexFn(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=1", len(args))
+	}
+	if args[0].Type() != ARRAY_OBJ {
+		return newError("wrong-arguments", "argument to 'lastIndex' must be of type ARRAY, got %s", args[0].Type())
+	}
+
+	arr := args[0].(*Array)
+	if len(arr.Elements) > 0 {
+		return &Integer{Value: int64(len(arr.Elements) - 1)}
+	}
+
+	return NULL
+}
+
+func tailFn(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=1", len(args))
+	}
+	if args[0].Type() != ARRAY_OBJ {
+		return newError("wrong-arguments", "argument to 'tail' must be of type ARRAY, got %s", args[0].Type())
+	}
+
+	arr := args[0].(*Array)
+	length := len(arr.Elements)
+	var newElements []Object
+
+	if length > 0 {
+		newElements = make([]Object, length-1, length-1)
+		copy(newElements, arr.Elements[1:length])
+	}
+
+	return &Array{Elements: newElements}
+}
+
+func pushFn(args ...Object) Object {
+	if len(args) != 2 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=2", len(args))
+	}
+	if args[0].Type() != ARRAY_OBJ {
+		return newError("wrong-arguments", "first argument to 'push' must be of type ARRAY, got %s", args[0].Type())
+	}
+
+	arr := args[0].(*Array)
+	length := len(arr.Elements)
+
+	// Leaving one extra empty element in the capacity,
+	// so we could append the pushed element later.
+	newElements := make([]Object, length, length+1)
+	copy(newElements, arr.Elements)
+	newElements = append(newElements, args[1])
+
+	return &Array{Elements: newElements}
+}
+
+func printFn(streams *IO, args ...Object) Object {
+	for _, arg := range args {
+		fmt.Fprintln(streams.Out, arg.Inspect())
+	}
+
+	return NULL
+}
+
+// eprintFn works like print, but writes to the error stream.
+func eprintFn(streams *IO, args ...Object) Object {
+	for _, arg := range args {
+		fmt.Fprintln(streams.Err, arg.Inspect())
+	}
+
+	return NULL
+}
+
+// readLineFn reads the next line of the input, or returns null once there's nothing left to read.
+func readLineFn(streams *IO, args ...Object) Object {
+	if len(args) != 0 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=0", len(args))
+	}
+
+	return readLine(streams)
+}
+
+// inputFn prints the prompt, if given one, on the same line, and reads the next line of the input.
+func inputFn(streams *IO, args ...Object) Object {
+	if len(args) > 1 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=0 or 1", len(args))
+	}
+
+	if len(args) == 1 {
+		fmt.Fprint(streams.Out, args[0].Inspect())
+	}
+
+	return readLine(streams)
+}
+
+// assertFn raises an error when the condition doesn't hold, with the message, if given one.
+func assertFn(args ...Object) Object {
+	if len(args) != 1 && len(args) != 2 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=1 or 2", len(args))
+	}
+
+	if args[0] != NULL && args[0] != FALSE {
+		return NULL
+	}
+
+	if len(args) == 2 {
+		return newError("assertion-failed", "assertion failed: %s", args[1].Inspect())
+	}
+
+	return newError("assertion-failed", "assertion failed")
+}
+
+func readLine(streams *IO) Object {
+	line, ok, err := streams.ReadLine()
+	if err != nil {
+		return newError("input-error", "cannot read the input: %s", err)
+	}
+
+	if !ok {
+		return NULL
+	}
+
+	return &String{Value: line}
+}
+
+// bytesFn returns the bytes of the UTF-8 encoding of a string, as integers.
+func bytesFn(args ...Object) Object {
+	if len(args) != 1 {
+		return newError("wrong-arguments", "wrong number of arguments. got=%d, want=1", len(args))
+	}
+	if args[0].Type() != STRING_OBJ {
+		return newError("wrong-arguments", "argument to 'bytes' must be of type STRING, got %s", args[0].Type())
+	}
+
+	str := args[0].(*String).Value
+	elements := make([]Object, len(str))
+	for i := 0; i < len(str); i++ {
+		elements[i] = &Integer{Value: int64(str[i])}
+	}
+
+	return &Array{Elements: elements}
+}
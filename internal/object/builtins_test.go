@@ -0,0 +1,31 @@
+package object
+
+import (
+	"bufio"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+// TestBuiltinArity checks that the builtin functions take the number of arguments they claim to.
+func TestBuiltinArity(t *testing.T) {
+	streams := &Options{IO: &IO{In: bufio.NewReader(strings.NewReader("")), Out: ioutil.Discard, Err: ioutil.Discard}}
+
+	for _, b := range Builtins {
+		for n := 0; n <= 3; n++ {
+			args := make([]Object, n)
+			for i := range args {
+				args[i] = &Array{}
+			}
+
+			result := b.Builtin.Call(streams, args...)
+			err, ok := result.(*Error)
+			wrongArity := ok && strings.HasPrefix(err.Message, "wrong number of arguments")
+
+			expected := n < b.Builtin.MinArgs || b.Builtin.MaxArgs != VARIADIC && n > b.Builtin.MaxArgs
+			if wrongArity != expected {
+				t.Errorf("%s with %d arguments: expected a wrong number of arguments: %t, got=%v", b.Name, n, expected, result.Inspect())
+			}
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/code"
+)
+
+const (
+	COMPILED_FUNCTION_OBJ = "COMPILED_FUNCTION"
+)
+
+// CompiledFunction is the bytecode of a function literal. It only lives in the constant pool,
+// the virtual machine turns it into a Function whenever the literal is evaluated.
+type CompiledFunction struct {
+	Name         string
+	Parameters   []*ast.Identifier
+	Defaults     []ast.Expression
+	Rest         *ast.Identifier
+	Body         *ast.BlockStatement
+	Instructions code.Instructions
+	SourceMap    code.SourceMap
+	// Declarations holds the statements declaring constants, by the offset of the instruction declaring them.
+	Declarations map[int]ast.Node
+	// LocalNames holds the names of the local bindings, by index.
+	LocalNames []string
+}
+
+func (cf *CompiledFunction) Type() Type {
+	return COMPILED_FUNCTION_OBJ
+}
+
+func (cf *CompiledFunction) Inspect() string {
+	return fmt.Sprintf("CompiledFunction[%p]", cf)
+}
+
+// Globals holds the state shared by the functions compiled together, like the ones of a module.
+type Globals struct {
+	Constants []Object
+	Values    []Object
+	// Names holds the names of the global bindings, by index.
+	Names []string
+	Decls Decls
+}
+
+// Scope holds the local bindings of a single function call on the virtual machine.
+type Scope struct {
+	Locals []Object
+	// Names holds the names of the local bindings, by index.
+	Names []string
+	Decls Decls
+	// Outer is the scope the called function was created in.
+	Outer *Scope
+}
+
+// Decls holds the statements that declared the bindings that are constants, by index. The other bindings aren't in it.
+// Like the constants of an Environment, they're the syntax tree nodes, so compiling the same program again
+// doesn't change them.
+type Decls map[int]ast.Node
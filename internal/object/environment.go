@@ -1,27 +1,60 @@
 package object
 
+import (
+	"sort"
+
+	"github.com/axbarsan/doggo/internal/ast"
+)
+
+// Options configure how the code runs. They are shared by an environment and all the ones it encloses.
+type Options struct {
+	// Strict turns integer overflows into errors, instead of letting the values wrap around.
+	Strict bool
+	// Modules loads the imported modules. Without it, the code can't import any.
+	Modules ModuleLoader
+	// Meter bounds the resources used by the code. Without it, the code runs unbounded.
+	Meter *Meter
+	// IO holds the streams used by the builtin functions reading and printing. Without it, they use StdIO.
+	IO *IO
+}
+
 type Environment struct {
 	store map[string]Object
 	outer *Environment
+
+	options *Options
+
+	// constants maps the names bound to constants to the statements that declared them.
+	constants map[string]ast.Node
 }
 
 func NewEnvironment() *Environment {
+	return NewEnvironmentWithOptions(&Options{})
+}
+
+func NewEnvironmentWithOptions(options *Options) *Environment {
 	s := make(map[string]Object)
 	env := &Environment{
-		store: s,
-		outer: nil,
+		store:     s,
+		outer:     nil,
+		options:   options,
+		constants: make(map[string]ast.Node),
 	}
 
 	return env
 }
 
 func NewEnclosedEnvironment(outer *Environment) *Environment {
-	env := NewEnvironment()
+	env := NewEnvironmentWithOptions(outer.options)
 	env.outer = outer
 
 	return env
 }
 
+func (e *Environment) Options() *Options {
+	return e.options
+}
+
 func (e *Environment) Get(name string) (Object, bool) {
 	obj, ok := e.store[name]
 	if !ok && e.outer != nil {
@@ -31,8 +64,47 @@ func (e *Environment) Get(name string) (Object, bool) {
 	return obj, ok
 }
 
+// Set binds the name to a mutable value in this environment.
 func (e *Environment) Set(name string, val Object) Object {
 	e.store[name] = val
+	delete(e.constants, name)
+
+	return val
+}
+
+// SetConstant binds the name to a constant in this environment, remembering the statement that declared it.
+func (e *Environment) SetConstant(name string, val Object, decl ast.Node) Object {
+	e.store[name] = val
+	e.constants[name] = decl
 
 	return val
 }
+
+// Constant returns the statement that declared the name as a constant in this environment, if it is one.
+func (e *Environment) Constant(name string) (ast.Node, bool) {
+	decl, ok := e.constants[name]
+
+	return decl, ok
+}
+
+// Names returns the names bound in this environment, sorted.
+func (e *Environment) Names() []string {
+	names := make([]string, 0, len(e.store))
+	for name := range e.store {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Scope returns the innermost environment in which the name is bound.
+func (e *Environment) Scope(name string) (*Environment, bool) {
+	for env := e; env != nil; env = env.outer {
+		if _, ok := env.store[name]; ok {
+			return env, true
+		}
+	}
+
+	return nil, false
+}
@@ -2,20 +2,130 @@ package object
 
 import (
 	"fmt"
+
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/token"
 )
 
 const (
 	ERROR_OBJ = "ERROR"
 )
 
+// The kinds of errors, as seen by the scripts catching them.
+const (
+	// RuntimeError is the kind of the errors raised by the interpreter itself, like type mismatches.
+	RuntimeError = "RuntimeError"
+	// ThrownError is the kind of the errors raised by throw statements, unless they give one of their own.
+	ThrownError = "Error"
+)
+
 type Error struct {
+	// Code names the kind of the error, like "type-mismatch", for the tools telling the errors apart.
+	// The errors thrown by the scripts have the "thrown" code, and the ones without a code the "runtime-error" one.
+	Code    string
 	Message string
+	// Kind is the kind of the error. It's empty for runtime errors.
+	Kind string
+	// Value is what a throw statement threw, if the error was raised by one.
+	Value Object
+	// Pos is the position of the node that raised the error, if known.
+	Pos token.Position
+	// Stack holds the function calls the error unwound through, innermost call first.
+	Stack []Frame
+}
+
+// Frame is a single function call in the stack trace of an error.
+type Frame struct {
+	// Function is the name of the called function.
+	Function string
+	// Pos is the position of the call site.
+	Pos token.Position
 }
 
 func (e *Error) Type() Type {
 	return ERROR_OBJ
 }
 
+// NewThrownError creates the error raised by throwing a value. The message of a string is the string itself.
+// A map with a "message" key describes the error: its message, its kind under the "kind" key,
+// and the thrown value under the "value" key, which is how a caught error can be thrown again.
+func NewThrownError(value Object) *Error {
+	err := &Error{Code: "thrown", Message: value.Inspect(), Kind: ThrownError, Value: value}
+
+	m, ok := value.(*Map)
+	if !ok {
+		return err
+	}
+
+	message, ok := m.Get(&String{Value: "message"})
+	if !ok {
+		return err
+	}
+	err.Message = message.Inspect()
+	err.Value = nil
+
+	if kind, ok := m.Get(&String{Value: "kind"}); ok {
+		err.Kind = kind.Inspect()
+	}
+
+	if value, ok := m.Get(&String{Value: "value"}); ok {
+		err.Value = value
+	}
+
+	return err
+}
+
+// ToMap returns the error as a catch clause gives it to the script: a map holding
+// its message, its kind, its position and, for the errors thrown by the script, the thrown value.
+func (e *Error) ToMap() *Map {
+	kind := e.Kind
+	if kind == "" {
+		kind = RuntimeError
+	}
+
+	m := NewMap()
+	m.Set(&String{Value: "message"}, &String{Value: e.Message})
+	m.Set(&String{Value: "kind"}, &String{Value: kind})
+	m.Set(&String{Value: "position"}, &String{Value: e.Pos.String()})
+	if e.Value != nil {
+		m.Set(&String{Value: "value"}, e.Value)
+	}
+
+	return m
+}
+
 func (e *Error) Inspect() string {
+	if e.Pos.IsValid() {
+		return fmt.Sprintf("ERROR: %s: %s", e.Pos, e.Message)
+	}
+
 	return fmt.Sprintf("ERROR: %s", e.Message)
 }
+
+// Diagnostic returns the error as a diagnostic, pointing at the position of the error. Its notes tell
+// the function calls it unwound through, innermost call first, with the ones of deep recursions collapsed.
+func (e *Error) Diagnostic() *diag.Diagnostic {
+	code := e.Code
+	if code == "" {
+		code = "runtime-error"
+	}
+
+	d := diag.Errorf(code, diag.Point(e.Pos), "%s", e.Message)
+
+	for i := 0; i < len(e.Stack); {
+		repeated := 0
+		for i+repeated+1 < len(e.Stack) && e.Stack[i+repeated+1] == e.Stack[i] {
+			repeated++
+		}
+
+		note := fmt.Sprintf("in %s, called at %s", e.Stack[i].Function, e.Stack[i].Pos)
+		if repeated > 0 {
+			note += fmt.Sprintf(" (%d more times)", repeated)
+		}
+		d.Notes = append(d.Notes, note)
+
+		i += repeated + 1
+	}
+
+	return d
+}
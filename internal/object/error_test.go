@@ -0,0 +1,72 @@
+package object
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+func TestErrorCode(t *testing.T) {
+	_, notExported := (&Module{Path: "lib.doggo"}).Member(&String{Value: "x"})
+	meter := NewMeter(context.Background(), Limits{MaxSteps: 1})
+	meter.Step()
+
+	testCases := []struct {
+		err      Object
+		expected string
+	}{
+		{&Error{Code: "type-mismatch", Message: "type mismatch: INTEGER + BOOLEAN"}, "type-mismatch"},
+		{&Error{Message: "something unheard of"}, "runtime-error"},
+		{NewThrownError(&String{Value: "type mismatch"}), "thrown"},
+		{notExported, "not-exported"},
+		{GetBuiltinByName("length").Fn(), "wrong-arguments"},
+		{meter.Step(), "step-limit"},
+	}
+
+	for _, tc := range testCases {
+		err, ok := tc.err.(*Error)
+		if !ok {
+			t.Errorf("not an error. got=%T (%+v)", tc.err, tc.err)
+
+			continue
+		}
+
+		if got := err.Diagnostic().Code; got != tc.expected {
+			t.Errorf("wrong code for %q. expected=%q, got=%q", err.Message, tc.expected, got)
+		}
+	}
+}
+
+func TestErrorDiagnostic(t *testing.T) {
+	pos := func(line, column int) token.Position {
+		return token.Position{Filename: "main.doggo", Line: line, Column: column}
+	}
+
+	err := &Error{
+		Code:    "division-by-zero",
+		Message: "division by zero",
+		Pos:     pos(1, 5),
+		Stack: []Frame{
+			{Function: "f", Pos: pos(1, 2)},
+			{Function: "f", Pos: pos(1, 2)},
+			{Function: "f", Pos: pos(1, 2)},
+			{Function: "g", Pos: pos(3, 1)},
+		},
+	}
+
+	d := err.Diagnostic()
+	if d.Code != "division-by-zero" || d.Span.Start != err.Pos || d.Span.End != err.Pos {
+		t.Errorf("wrong diagnostic. got=%+v", d)
+	}
+
+	expected := []string{"in f, called at main.doggo:1:2 (2 more times)", "in g, called at main.doggo:3:1"}
+	if !reflect.DeepEqual(d.Notes, expected) {
+		t.Errorf("wrong notes.\nexpected=%q\ngot=     %q", expected, d.Notes)
+	}
+
+	if got := d.Error(); got != "main.doggo:1:5: division by zero" {
+		t.Errorf("wrong error. got=%q", got)
+	}
+}
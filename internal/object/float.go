@@ -0,0 +1,57 @@
+package object
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	FLOAT_OBJ = "FLOAT"
+)
+
+type Float struct {
+	Value float64
+}
+
+func (f *Float) Type() Type {
+	return FLOAT_OBJ
+}
+
+// Inspect prints the shortest representation of the number, always telling it apart from an integer.
+func (f *Float) Inspect() string {
+	s := strconv.FormatFloat(f.Value, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eIN") {
+		s += ".0"
+	}
+
+	return s
+}
+
+// MapKey hashes whole numbers like the equal integers, since they compare as equal.
+func (f *Float) MapKey() MapKey {
+	if f.Value == math.Trunc(f.Value) && f.Value >= math.MinInt64 && f.Value < math.MaxInt64 {
+		return (&Integer{Value: int64(f.Value)}).MapKey()
+	}
+
+	mk := MapKey{
+		Type:  f.Type(),
+		Value: math.Float64bits(f.Value),
+	}
+
+	return mk
+}
+
+// ToFloat returns the value of an integer or a float as a float64, reporting false for any other object.
+func ToFloat(obj Object) (float64, bool) {
+	switch obj := obj.(type) {
+	case *Integer:
+		return float64(obj.Value), true
+
+	case *Float:
+		return obj.Value, true
+
+	default:
+		return 0, false
+	}
+}
@@ -2,7 +2,7 @@ package object
 
 import (
 	"bytes"
-	"strings"
+	"fmt"
 
 	"github.com/axbarsan/doggo/internal/ast"
 )
@@ -12,25 +12,73 @@ const (
 )
 
 type Function struct {
+	Name       string
 	Parameters []*ast.Identifier
+	Defaults   []ast.Expression
+	Rest       *ast.Identifier
 	Body       *ast.BlockStatement
 	Env        *Environment
+
+	// Compiled, Scope and Globals are only set when the function was created by the virtual machine.
+	Compiled *CompiledFunction
+	Scope    *Scope
+	Globals  *Globals
 }
 
 func (f *Function) Type() Type {
 	return FUNCTION_OBJ
 }
 
-func (f *Function) Inspect() string {
-	var params []string
-	for _, p := range f.Parameters {
-		params = append(params, p.String())
+// DisplayName returns the name of the function, as it should appear in stack traces.
+func (f *Function) DisplayName() string {
+	if f.Name == "" {
+		return "<anonymous>"
+	}
+
+	return f.Name
+}
+
+// RequiredParameters returns the number of parameters without a default value.
+func (f *Function) RequiredParameters() int {
+	required := 0
+	for i := range f.Parameters {
+		if i < len(f.Defaults) && f.Defaults[i] != nil {
+			break
+		}
+		required++
+	}
+
+	return required
+}
+
+// CheckArity returns an error if the function can't be called with the given number of arguments.
+func (f *Function) CheckArity(numArgs int) *Error {
+	required := f.RequiredParameters()
+	if numArgs >= required && (numArgs <= len(f.Parameters) || f.Rest != nil) {
+		return nil
+	}
+
+	var want string
+	switch {
+	case f.Rest != nil:
+		want = fmt.Sprintf("at least %d", required)
+	case required < len(f.Parameters):
+		want = fmt.Sprintf("%d to %d", required, len(f.Parameters))
+	default:
+		want = fmt.Sprintf("%d", required)
 	}
 
+	return &Error{
+		Code:    "wrong-arguments",
+		Message: fmt.Sprintf("wrong number of arguments to %s: want=%s, got=%d", f.DisplayName(), want, numArgs),
+	}
+}
+
+func (f *Function) Inspect() string {
 	var out bytes.Buffer
 	out.WriteString("fn")
 	out.WriteString("(")
-	out.WriteString(strings.Join(params, ", "))
+	out.WriteString(ast.FormatParameters(f.Parameters, f.Defaults, f.Rest))
 	out.WriteString(") {\n")
 	out.WriteString(f.Body.String())
 	out.WriteString("\n}")
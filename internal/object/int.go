@@ -2,6 +2,7 @@ package object
 
 import (
 	"fmt"
+	"math"
 )
 
 const (
@@ -28,3 +29,31 @@ func (i *Integer) MapKey() MapKey {
 
 	return mk
 }
+
+// IntegerOverflows reports whether applying the arithmetic operator to the integers overflows.
+func IntegerOverflows(operator string, left, right int64) bool {
+	switch operator {
+	case "+":
+		return right > 0 && left > math.MaxInt64-right || right < 0 && left < math.MinInt64-right
+
+	case "-":
+		return right < 0 && left > math.MaxInt64+right || right > 0 && left < math.MinInt64+right
+
+	case "*":
+		if left == 0 || right == 0 {
+			return false
+		}
+
+		if left == -1 || right == -1 {
+			return left == math.MinInt64 || right == math.MinInt64
+		}
+
+		return left*right/right != left
+
+	case "/":
+		return left == math.MinInt64 && right == -1
+
+	default:
+		return false
+	}
+}
@@ -0,0 +1,91 @@
+package object
+
+const (
+	ITERATOR_OBJ = "ITERATOR"
+)
+
+// Iterator walks over the members of an array, a string or a map, for the loops.
+type Iterator struct {
+	next func() (key, value Object, ok bool)
+	// keyed iterators bind the key, rather than the value, to the single variable of a loop.
+	keyed bool
+}
+
+// NewIterator returns an iterator over the given object, or false if it can't be looped over.
+// Strings are walked character by character, and maps in the order of their keys.
+func NewIterator(obj Object) (*Iterator, bool) {
+	switch obj := obj.(type) {
+	case *Array:
+		elements := obj.Elements
+		i := 0
+
+		it := &Iterator{next: func() (Object, Object, bool) {
+			if i >= len(elements) {
+				return nil, nil, false
+			}
+			i++
+
+			return &Integer{Value: int64(i - 1)}, elements[i-1], true
+		}}
+
+		return it, true
+
+	case *String:
+		chars := []rune(obj.Value)
+		i := 0
+
+		it := &Iterator{next: func() (Object, Object, bool) {
+			if i >= len(chars) {
+				return nil, nil, false
+			}
+			i++
+
+			return &Integer{Value: int64(i - 1)}, &String{Value: string(chars[i-1])}, true
+		}}
+
+		return it, true
+
+	case *Map:
+		pairs := obj.SortedPairs()
+		i := 0
+
+		it := &Iterator{keyed: true, next: func() (Object, Object, bool) {
+			if i >= len(pairs) {
+				return nil, nil, false
+			}
+			i++
+
+			return pairs[i-1].Key, pairs[i-1].Value, true
+		}}
+
+		return it, true
+
+	default:
+		return nil, false
+	}
+}
+
+func (it *Iterator) Type() Type {
+	return ITERATOR_OBJ
+}
+
+func (it *Iterator) Inspect() string {
+	return "iterator"
+}
+
+// Next returns the index, or the key for maps, and the value of the next member.
+// It returns false once all the members have been walked over.
+func (it *Iterator) Next() (Object, Object, bool) {
+	return it.next()
+}
+
+// NextMember returns what a loop with a single variable binds:
+// the next key of a map, or the next value of anything else.
+func (it *Iterator) NextMember() (Object, bool) {
+	key, value, ok := it.next()
+	if it.keyed {
+		return key, ok
+	}
+
+	return value, ok
+}
@@ -0,0 +1,28 @@
+package object
+
+const (
+	BREAK_OBJ    = "BREAK"
+	CONTINUE_OBJ = "CONTINUE"
+)
+
+// Break unwinds the statements of a loop body, up to the loop it stops.
+type Break struct{}
+
+func (b *Break) Type() Type {
+	return BREAK_OBJ
+}
+
+func (b *Break) Inspect() string {
+	return "break"
+}
+
+// Continue unwinds the statements of a loop body, up to the loop it moves on to the next iteration.
+type Continue struct{}
+
+func (c *Continue) Type() Type {
+	return CONTINUE_OBJ
+}
+
+func (c *Continue) Inspect() string {
+	return "continue"
+}
@@ -1,9 +1,7 @@
 package object
 
 import (
-	"bytes"
-	"fmt"
-	"strings"
+	"sort"
 )
 
 type MapKey struct {
@@ -29,21 +27,67 @@ type Map struct {
 	Pairs map[MapKey]MapPair
 }
 
+func NewMap() *Map {
+	return &Map{Pairs: make(map[MapKey]MapPair)}
+}
+
 func (m *Map) Type() Type {
 	return MAP_OBJ
 }
 
+// Get returns the value stored under the given key.
+func (m *Map) Get(key Mappable) (Object, bool) {
+	pair, ok := m.Pairs[key.MapKey()]
+
+	return pair.Value, ok
+}
+
+// Set stores the value under the given key.
+func (m *Map) Set(key Mappable, value Object) {
+	m.Pairs[key.MapKey()] = MapPair{Key: key, Value: value}
+}
+
 func (m *Map) Inspect() string {
-	var out bytes.Buffer
+	return inspect(m, make(map[Object]bool))
+}
 
-	var pairs []string
+// SortedPairs returns the pairs of the map, ordered by their keys.
+// Keys of different types are grouped by type, with integers and floats sorted together.
+func (m *Map) SortedPairs() []MapPair {
+	pairs := make([]MapPair, 0, len(m.Pairs))
 	for _, pair := range m.Pairs {
-		pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), pair.Value.Inspect()))
+		pairs = append(pairs, pair)
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return lessKey(pairs[i].Key, pairs[j].Key)
+	})
+
+	return pairs
+}
+
+func lessKey(a, b Mappable) bool {
+	if x, ok := ToFloat(a); ok {
+		if y, ok := ToFloat(b); ok {
+			return x < y
+		}
 	}
 
-	out.WriteString("{")
-	out.WriteString(strings.Join(pairs, ", "))
-	out.WriteString("}")
+	if a.Type() != b.Type() {
+		return a.Type() < b.Type()
+	}
 
-	return out.String()
+	switch a := a.(type) {
+	case *Integer:
+		return a.Value < b.(*Integer).Value
+
+	case *String:
+		return a.Value < b.(*String).Value
+
+	case *Boolean:
+		return !a.Value && b.(*Boolean).Value
+
+	default:
+		return a.MapKey().Value < b.MapKey().Value
+	}
 }
@@ -48,3 +48,36 @@ func TestStringMapKey(t *testing.T) {
 		})
 	}
 }
+
+func TestFloatMapKey(t *testing.T) {
+	if (&Float{Value: 2}).MapKey() != (&Integer{Value: 2}).MapKey() {
+		t.Errorf("Whole floats and the equal integers have different hash keys")
+	}
+
+	if (&Float{Value: 2.5}).MapKey() != (&Float{Value: 2.5}).MapKey() {
+		t.Errorf("Variables with same content have different hash keys")
+	}
+
+	if (&Float{Value: 2.5}).MapKey() == (&Float{Value: 3.5}).MapKey() {
+		t.Errorf("Variables with different content have same hash keys")
+	}
+}
+
+func TestFloatInspect(t *testing.T) {
+	testCases := []struct {
+		value    float64
+		expected string
+	}{
+		{2, "2.0"},
+		{-0.5, "-0.5"},
+		{3.14, "3.14"},
+		{1e21, "1e+21"},
+		{0.30000000000000004, "0.30000000000000004"},
+	}
+
+	for _, tc := range testCases {
+		if actual := (&Float{Value: tc.value}).Inspect(); actual != tc.expected {
+			t.Errorf("wrong Inspect() for %g. expected=%q, got=%q", tc.value, tc.expected, actual)
+		}
+	}
+}
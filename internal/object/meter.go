@@ -0,0 +1,155 @@
+package object
+
+import (
+	"context"
+	"fmt"
+)
+
+// LimitError is the kind of the errors raised when a run is cancelled, or goes over one of its limits.
+// Unlike the other errors, the code can't catch them.
+const LimitError = "LimitError"
+
+// DefaultMaxCallDepth is the call depth limit used when none is given.
+// Deeper recursion would run the interpreter out of stack, crashing the whole process.
+const DefaultMaxCallDepth = 10000
+
+const (
+	// cancelCheckInterval is the number of steps between two checks of the context.
+	cancelCheckInterval = 1024
+	// stringChunkSize is the number of bytes of a string counted as a single allocated object.
+	stringChunkSize = 64
+)
+
+// Limits bound the resources a run can use. Zero means no limit, except for the call depth.
+type Limits struct {
+	// MaxSteps bounds the number of steps, which are the nodes evaluated, or the instructions executed.
+	MaxSteps int64
+	// MaxCallDepth bounds the number of nested function calls. Zero means DefaultMaxCallDepth.
+	MaxCallDepth int
+	// MaxAllocations bounds the number of objects created. Arrays and maps count their members
+	// too, and strings count an object for every 64 bytes they take.
+	MaxAllocations int64
+}
+
+// Meter keeps track of the resources used by a run, stopping it once it's cancelled or goes over its limits.
+// A nil meter puts no bounds on the run. Once a limit trips, every later check fails too, so the run is over.
+type Meter struct {
+	ctx    context.Context
+	limits Limits
+
+	steps       int64
+	depth       int
+	allocations int64
+
+	tripped *Error
+}
+
+func NewMeter(ctx context.Context, limits Limits) *Meter {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if limits.MaxCallDepth == 0 {
+		limits.MaxCallDepth = DefaultMaxCallDepth
+	}
+
+	return &Meter{ctx: ctx, limits: limits}
+}
+
+// Step counts a step of the run. It fails once the run takes too many, or once its context is done.
+func (m *Meter) Step() *Error {
+	if m == nil {
+		return nil
+	}
+
+	if m.tripped != nil {
+		return m.err()
+	}
+
+	m.steps++
+	if m.limits.MaxSteps > 0 && m.steps > m.limits.MaxSteps {
+		return m.trip("step-limit", "step limit exceeded: %d", m.limits.MaxSteps)
+	}
+
+	if m.steps%cancelCheckInterval == 0 {
+		if err := m.ctx.Err(); err != nil {
+			return m.trip("execution-stopped", "execution stopped: %s", err)
+		}
+	}
+
+	return nil
+}
+
+// Enter counts a function call, which fails if it goes too deep. Every successful Enter is paired with a Leave.
+func (m *Meter) Enter() *Error {
+	if m == nil {
+		return nil
+	}
+
+	if m.tripped != nil {
+		return m.err()
+	}
+
+	if m.depth >= m.limits.MaxCallDepth {
+		return m.trip("call-depth-limit", "call depth limit exceeded: %d", m.limits.MaxCallDepth)
+	}
+	m.depth++
+
+	return nil
+}
+
+// Leave counts the return from a function call.
+func (m *Meter) Leave() {
+	if m == nil {
+		return
+	}
+
+	m.depth--
+}
+
+// Allocate counts the objects making up a newly created value. Numbers and booleans are free,
+// since they never take more than the steps creating them.
+func (m *Meter) Allocate(obj Object) *Error {
+	if m == nil {
+		return nil
+	}
+
+	if m.tripped != nil {
+		return m.err()
+	}
+
+	switch obj := obj.(type) {
+	case *Array:
+		m.allocations += 1 + int64(len(obj.Elements))
+	case *Map:
+		m.allocations += 1 + int64(len(obj.Pairs))
+	case *String:
+		m.allocations += 1 + int64(len(obj.Value)/stringChunkSize)
+	case *Function:
+		m.allocations++
+	default:
+		return nil
+	}
+
+	if m.limits.MaxAllocations > 0 && m.allocations > m.limits.MaxAllocations {
+		return m.trip("allocation-limit", "allocation limit exceeded: %d", m.limits.MaxAllocations)
+	}
+
+	return nil
+}
+
+// Tripped reports whether the run was stopped, in which case its errors can't be caught anymore.
+func (m *Meter) Tripped() bool {
+	return m != nil && m.tripped != nil
+}
+
+func (m *Meter) trip(code, format string, a ...interface{}) *Error {
+	m.tripped = &Error{Code: code, Message: fmt.Sprintf(format, a...), Kind: LimitError}
+
+	return m.err()
+}
+
+// err returns a fresh copy of the error that stopped the run, as errors get positions attached along the way.
+func (m *Meter) err() *Error {
+	return &Error{Code: m.tripped.Code, Message: m.tripped.Message, Kind: m.tripped.Kind}
+}
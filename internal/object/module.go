@@ -0,0 +1,49 @@
+package object
+
+import (
+	"fmt"
+
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+const (
+	MODULE_OBJ = "MODULE"
+)
+
+// ModuleLoader finds the modules imported by the code, runs them and hands their exports over.
+type ModuleLoader interface {
+	// Load returns the module at the path, as imported by the code at the given position.
+	Load(path string, from token.Position) (*Module, *Error)
+}
+
+// Module holds the bindings exported by a module.
+type Module struct {
+	// Path is where the module was found.
+	Path string
+	// Exports returns the current value of each exported binding, by name. The exports are live:
+	// a variable assigned to by the functions of the module is read with its new value.
+	Exports map[string]func() Object
+}
+
+func (m *Module) Type() Type {
+	return MODULE_OBJ
+}
+
+func (m *Module) Inspect() string {
+	return fmt.Sprintf("<module %s>", m.Path)
+}
+
+// Member returns the exported binding whose name is the given string.
+func (m *Module) Member(name Object) (Object, *Error) {
+	str, ok := name.(*String)
+	if !ok {
+		return nil, &Error{Code: "unusable-key", Message: fmt.Sprintf("unusable as module member: %s", name.Type())}
+	}
+
+	member, ok := m.Exports[str.Value]
+	if !ok {
+		return nil, &Error{Code: "not-exported", Message: fmt.Sprintf("module %s does not export %s", m.Path, str.Value)}
+	}
+
+	return member(), nil
+}
@@ -4,6 +4,9 @@ const (
 	NULL_OBJ = "NULL"
 )
 
+// NULL is the only instance of Null, so it can be compared by identity.
+var NULL = &Null{}
+
 type Null struct {
 }
 
@@ -1,5 +1,10 @@
 package object
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Type string
 
 // Object is the internal representation of any type in the doggo language.
@@ -7,3 +12,40 @@ type Object interface {
 	Type() Type
 	Inspect() string
 }
+
+// inspect returns the text of the value. Arrays and maps can hold themselves, directly or through other ones,
+// so the ones already being inspected are shown as [...] and {...} where they're held again, instead of going on forever.
+func inspect(obj Object, visiting map[Object]bool) string {
+	switch obj := obj.(type) {
+	case *Array:
+		if visiting[obj] {
+			return "[...]"
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+
+		elements := make([]string, len(obj.Elements))
+		for i, e := range obj.Elements {
+			elements[i] = inspect(e, visiting)
+		}
+
+		return "[" + strings.Join(elements, ", ") + "]"
+
+	case *Map:
+		if visiting[obj] {
+			return "{...}"
+		}
+		visiting[obj] = true
+		defer delete(visiting, obj)
+
+		pairs := make([]string, 0, len(obj.Pairs))
+		for _, pair := range obj.SortedPairs() {
+			pairs = append(pairs, fmt.Sprintf("%s: %s", pair.Key.Inspect(), inspect(pair.Value, visiting)))
+		}
+
+		return "{" + strings.Join(pairs, ", ") + "}"
+
+	default:
+		return obj.Inspect()
+	}
+}
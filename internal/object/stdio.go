@@ -0,0 +1,44 @@
+package object
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+)
+
+// IO holds the streams the code reads its input from, and writes its output and errors to.
+type IO struct {
+	In  *bufio.Reader
+	Out io.Writer
+	Err io.Writer
+}
+
+// StdIO connects the code to the standard streams of the process. It's used when the options don't give any.
+var StdIO = NewIO(os.Stdin, os.Stdout, os.Stderr)
+
+func NewIO(in io.Reader, out, err io.Writer) *IO {
+	reader, ok := in.(*bufio.Reader)
+	if !ok {
+		reader = bufio.NewReader(in)
+	}
+
+	return &IO{In: reader, Out: out, Err: err}
+}
+
+// ReadLine reads the next line of the input, without its line ending.
+// It reports false once there's nothing left to read.
+func (s *IO) ReadLine() (string, bool, error) {
+	line, err := s.In.ReadString('\n')
+	if err == io.EOF {
+		return line, line != "", nil
+	}
+	if err != nil {
+		return "", false, err
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+
+	return line, true, nil
+}
@@ -5,6 +5,7 @@ import (
 	"strconv"
 
 	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/diag"
 	"github.com/axbarsan/doggo/internal/lexer"
 	"github.com/axbarsan/doggo/internal/token"
 )
@@ -16,6 +17,9 @@ import (
 // 'Abstract' in AST comes from the fact that certain details from the source code
 // are omitted in the AST
 
+// MaxErrors is the number of errors the parser reports, past which it only tells there are too many of them.
+const MaxErrors = 10
+
 type (
 	prefixParseFn func() ast.Expression
 	infixParseFn  func(ast.Expression) ast.Expression
@@ -27,7 +31,20 @@ type Parser struct {
 	curToken  token.Token
 	peekToken token.Token
 
-	errors []string
+	diagnostics []*diag.Diagnostic
+	// lexerErrors is the number of lexer errors already added to the diagnostics.
+	lexerErrors int
+	// recovering is set once the parser got lost in a statement, until it skips to the end of it.
+	// The errors found meanwhile are most likely caused by the first one, so they're dropped.
+	recovering bool
+
+	// braceDepth is the number of braces opened up to the current token, and not closed yet.
+	braceDepth int
+
+	// loopDepth is the number of loops around the current statement, within the current function.
+	loopDepth int
+	// blockDepth is the number of blocks around the current statement.
+	blockDepth int
 
 	prefixParseFns map[token.Type]prefixParseFn
 	infixParseFns  map[token.Type]infixParseFn
@@ -35,8 +52,8 @@ type Parser struct {
 
 func New(l *lexer.Lexer) *Parser {
 	p := &Parser{
-		l:      l,
-		errors: []string{},
+		l:           l,
+		diagnostics: []*diag.Diagnostic{},
 	}
 
 	// Read 2 tokens, so curToken and peekToken are both set.
@@ -46,7 +63,9 @@ func New(l *lexer.Lexer) *Parser {
 	p.prefixParseFns = make(map[token.Type]prefixParseFn)
 	p.registerPrefix(token.IDENT, p.parseIdentifier)
 	p.registerPrefix(token.INT, p.parseIntegerLiteral)
+	p.registerPrefix(token.FLOAT, p.parseFloatLiteral)
 	p.registerPrefix(token.STRING, p.parseStringLiteral)
+	p.registerPrefix(token.TEMPLATE_START, p.parseTemplateLiteral)
 	p.registerPrefix(token.BANG, p.parsePrefixExpression)
 	p.registerPrefix(token.MINUS, p.parsePrefixExpression)
 	p.registerPrefix(token.TRUE, p.parseBoolean)
@@ -62,23 +81,133 @@ func New(l *lexer.Lexer) *Parser {
 	p.registerInfix(token.MINUS, p.parseInfixExpression)
 	p.registerInfix(token.SLASH, p.parseInfixExpression)
 	p.registerInfix(token.ASTERISK, p.parseInfixExpression)
+	p.registerInfix(token.PERCENT, p.parseInfixExpression)
 	p.registerInfix(token.EQ, p.parseInfixExpression)
 	p.registerInfix(token.NOT_EQ, p.parseInfixExpression)
 	p.registerInfix(token.LT, p.parseInfixExpression)
 	p.registerInfix(token.GT, p.parseInfixExpression)
 	p.registerInfix(token.LPAREN, p.parseCallExpression)
 	p.registerInfix(token.LBRACKET, p.parseIndexExpression)
+	p.registerInfix(token.DOT, p.parseMemberExpression)
+	p.registerInfix(token.ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PLUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.MINUS_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.ASTERISK_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.SLASH_ASSIGN, p.parseAssignExpression)
+	p.registerInfix(token.PERCENT_ASSIGN, p.parseAssignExpression)
 
 	return p
 }
 
+// Errors returns the syntax errors found in the code, prefixed by their positions.
 func (p *Parser) Errors() []string {
-	return p.errors
+	return diag.Errors(p.diagnostics)
+}
+
+// Diagnostics returns the syntax errors found in the code.
+func (p *Parser) Diagnostics() []*diag.Diagnostic {
+	return p.diagnostics
+}
+
+// errorAt records an error about the span of the code. The error is returned, so that notes can be added to it.
+func (p *Parser) errorAt(code string, span diag.Span, format string, a ...interface{}) *diag.Diagnostic {
+	d := diag.Errorf(code, span, format, a...)
+	p.addError(d)
+
+	return d
+}
+
+// failAt records an error that leaves the parser lost in the statement it's parsing,
+// unless it's already lost, and has it skip the rest of the statement.
+func (p *Parser) failAt(code string, span diag.Span, format string, a ...interface{}) *diag.Diagnostic {
+	d := diag.Errorf(code, span, format, a...)
+	if !p.recovering {
+		p.addError(d)
+	}
+
+	p.recovering = true
+
+	return d
+}
+
+// addError records the error, unless it's already recorded, or there are too many of them.
+func (p *Parser) addError(d *diag.Diagnostic) {
+	if len(p.diagnostics) > MaxErrors {
+		return
+	}
+
+	for _, e := range p.diagnostics {
+		if e.Error() == d.Error() {
+			return
+		}
+	}
+
+	if len(p.diagnostics) == MaxErrors {
+		d = &diag.Diagnostic{Code: "too-many-errors", Severity: diag.Note, Message: "too many errors"}
+	}
+
+	p.diagnostics = append(p.diagnostics, d)
 }
 
 func (p *Parser) peekError(tok token.Type) {
-	msg := fmt.Sprintf("expected next token to be %s, got %s instead", tok, p.peekToken.Type)
-	p.errors = append(p.errors, msg)
+	d := p.failAt("unexpected-token", spanOf(p.peekToken), "expected %s, got %s instead", expectation(tok), description(p.peekToken))
+
+	// A missing closing delimiter is most likely forgotten, rather than misplaced.
+	switch tok {
+	case token.RPAREN, token.RBRACKET, token.RBRACE:
+		d.Fix = &diag.Fix{
+			Message: fmt.Sprintf("insert '%s'", tok),
+			Span:    diag.Point(p.peekToken.Pos),
+			NewText: string(tok),
+		}
+	}
+}
+
+// spanOf returns the span of the token.
+func spanOf(tok token.Token) diag.Span {
+	return diag.Span{Start: tok.Pos, End: tok.End}
+}
+
+// expectation describes the tokens of the type, the way an error message expects them, like "')'" or "a name".
+func expectation(t token.Type) string {
+	switch t {
+	case token.IDENT:
+		return "a name"
+
+	case token.STRING:
+		return "a string"
+
+	case token.TEMPLATE_END:
+		return "the end of the string"
+
+	case token.EOF:
+		return "the end of the file"
+	}
+
+	if literal := token.Literal(t); literal != "" {
+		return "'" + literal + "'"
+	}
+
+	return string(t)
+}
+
+// description describes the token, the way an error message tells what it got instead, like "'x'" or "the end of the file".
+func description(tok token.Token) string {
+	switch tok.Type {
+	case token.EOF:
+		return "the end of the file"
+
+	case token.ILLEGAL:
+		return fmt.Sprintf("the invalid character '%s'", tok.Literal)
+
+	case token.STRING:
+		return "the string " + strconv.Quote(tok.Literal)
+
+	case token.TEMPLATE_START, token.TEMPLATE_MIDDLE, token.TEMPLATE_END:
+		return "a string"
+	}
+
+	return "'" + tok.Literal + "'"
 }
 
 func (p *Parser) ParseProgram() *ast.Program {
@@ -87,6 +216,12 @@ func (p *Parser) ParseProgram() *ast.Program {
 
 	for !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
+		if p.recovering {
+			p.synchronize(0)
+
+			continue
+		}
+
 		if stmt != nil {
 			program.Statements = append(program.Statements, stmt)
 		}
@@ -96,9 +231,63 @@ func (p *Parser) ParseProgram() *ast.Program {
 	return program
 }
 
+// synchronize skips the rest of the statement the parser got lost in, which is left out of the program, so that
+// it can carry on with the next one. The statement ends with the semicolon following it at the given brace depth,
+// or with the brace closing the block around it. A statement keyword, like 'let', starts the next statement too.
+func (p *Parser) synchronize(depth int) {
+	for skipped := 0; !p.curTokenIs(token.EOF); skipped++ {
+		if p.curTokenIs(token.RBRACE) && p.braceDepth < depth {
+			break
+		}
+
+		if p.braceDepth == depth {
+			if p.curTokenIs(token.SEMICOLON) {
+				p.nextToken()
+
+				break
+			}
+
+			if skipped > 0 && isStatementKeyword(p.curToken.Type) {
+				break
+			}
+		}
+
+		p.nextToken()
+	}
+
+	p.recovering = false
+}
+
+func isStatementKeyword(t token.Type) bool {
+	switch t {
+	case token.CONST, token.LET, token.RETURN, token.WHILE, token.FOR, token.BREAK, token.CONTINUE,
+		token.TRY, token.THROW, token.IMPORT, token.EXPORT:
+		return true
+	}
+
+	return false
+}
+
 func (p *Parser) nextToken() {
 	p.curToken = p.peekToken
 	p.peekToken = p.l.NextToken()
+
+	switch p.curToken.Type {
+	case token.LBRACE:
+		p.braceDepth++
+
+	case token.RBRACE:
+		if p.braceDepth > 0 {
+			p.braceDepth--
+		}
+	}
+
+	if lexerErrors := p.l.Diagnostics(); len(lexerErrors) > p.lexerErrors {
+		for _, d := range lexerErrors[p.lexerErrors:] {
+			p.addError(d)
+		}
+		p.lexerErrors = len(lexerErrors)
+	}
 }
 
 func (p *Parser) curTokenIs(t token.Type) bool {
@@ -133,8 +322,26 @@ func (p *Parser) parseStatement() ast.Statement {
 	switch p.curToken.Type {
 	case token.CONST:
 		return p.parseConstStatement()
+	case token.LET:
+		return p.parseLetStatement()
 	case token.RETURN:
 		return p.parseReturnStatement()
+	case token.WHILE:
+		return p.parseWhileStatement()
+	case token.FOR:
+		return p.parseForStatement()
+	case token.BREAK:
+		return p.parseBreakStatement()
+	case token.CONTINUE:
+		return p.parseContinueStatement()
+	case token.TRY:
+		return p.parseTryStatement()
+	case token.THROW:
+		return p.parseThrowStatement()
+	case token.IMPORT:
+		return p.parseImportStatement()
+	case token.EXPORT:
+		return p.parseExportStatement()
 	default:
 		return p.parseExpressionStatement()
 	}
@@ -160,7 +367,42 @@ func (p *Parser) parseConstStatement() *ast.ConstStatement {
 
 	stmt.Value = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseLetStatement() *ast.LetStatement {
+	stmt := &ast.LetStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Name = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	if !p.expectPeek(token.ASSIGN) {
+		return nil
+	}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if fl, ok := stmt.Value.(*ast.FunctionLiteral); ok {
+		fl.Name = stmt.Name.Value
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
@@ -174,22 +416,255 @@ func (p *Parser) parseReturnStatement() *ast.ReturnStatement {
 
 	stmt.ReturnValue = p.parseExpression(LOWEST)
 
-	for !p.curTokenIs(token.SEMICOLON) {
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseThrowStatement() *ast.ThrowStatement {
+	stmt := &ast.ThrowStatement{Token: p.curToken}
+
+	p.nextToken()
+
+	stmt.Value = p.parseExpression(LOWEST)
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseImportStatement() *ast.ImportStatement {
+	stmt := &ast.ImportStatement{Token: p.curToken}
+
+	if p.blockDepth > 0 {
+		d := p.errorAt("misplaced-statement", spanOf(p.curToken), "import is only allowed at the top level")
+		d.Notes = append(d.Notes, "the imported names are global, so the imports are declared at the top level of the file")
+	}
+
+	if !p.expectPeek(token.STRING) {
+		return nil
+	}
+	stmt.Path = &ast.StringLiteral{Token: p.curToken, Value: p.curToken.Literal}
+
+	if !p.expectPeek(token.AS) || !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	stmt.Name = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+	if p.peekTokenIs(token.SEMICOLON) {
 		p.nextToken()
 	}
 
 	return stmt
 }
 
-func (p *Parser) noPrefixParseFnError(t token.Type) {
-	msg := fmt.Sprintf("no prefix parse function for %s found", t)
-	p.errors = append(p.errors, msg)
+func (p *Parser) parseExportStatement() *ast.ExportStatement {
+	stmt := &ast.ExportStatement{Token: p.curToken}
+
+	if p.blockDepth > 0 {
+		d := p.errorAt("misplaced-statement", spanOf(p.curToken), "export is only allowed at the top level")
+		d.Notes = append(d.Notes, "only the global constants and variables of a module can be exported")
+	}
+
+	p.nextToken()
+
+	switch p.curToken.Type {
+	case token.CONST:
+		decl := p.parseConstStatement()
+		if decl == nil {
+			return nil
+		}
+		stmt.Declaration = decl
+
+	case token.LET:
+		decl := p.parseLetStatement()
+		if decl == nil {
+			return nil
+		}
+		stmt.Declaration = decl
+
+	default:
+		p.failAt("unexpected-token", spanOf(p.curToken), "expected a declaration after export, got %s instead", description(p.curToken))
+
+		return nil
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseTryStatement() *ast.TryStatement {
+	stmt := &ast.TryStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Block = p.parseBlockStatement()
+
+	if p.peekTokenIs(token.CATCH) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LPAREN) || !p.expectPeek(token.IDENT) {
+			return nil
+		}
+		stmt.Param = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		if !p.expectPeek(token.RPAREN) || !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stmt.Catch = p.parseBlockStatement()
+	}
+
+	if p.peekTokenIs(token.FINALLY) {
+		p.nextToken()
+
+		if !p.expectPeek(token.LBRACE) {
+			return nil
+		}
+		stmt.Finally = p.parseBlockStatement()
+	}
+
+	if stmt.Catch == nil && stmt.Finally == nil {
+		p.failAt("unexpected-token", spanOf(p.peekToken), "expected 'catch' or 'finally' after the try block, got %s instead", description(p.peekToken))
+
+		return nil
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseWhileStatement() *ast.WhileStatement {
+	stmt := &ast.WhileStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Condition = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseForStatement() *ast.ForStatement {
+	stmt := &ast.ForStatement{Token: p.curToken}
+
+	if !p.expectPeek(token.LPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+
+	stmt.Value = &ast.Identifier{
+		Token: p.curToken,
+		Value: p.curToken.Literal,
+	}
+
+	if p.peekTokenIs(token.COMMA) {
+		p.nextToken()
+
+		if !p.expectPeek(token.IDENT) {
+			return nil
+		}
+
+		stmt.Key = stmt.Value
+		stmt.Value = &ast.Identifier{
+			Token: p.curToken,
+			Value: p.curToken.Literal,
+		}
+	}
+
+	if !p.expectPeek(token.IN) {
+		return nil
+	}
+
+	p.nextToken()
+	stmt.Iterable = p.parseExpression(LOWEST)
+
+	if !p.expectPeek(token.RPAREN) {
+		return nil
+	}
+
+	if !p.expectPeek(token.LBRACE) {
+		return nil
+	}
+
+	stmt.Body = p.parseLoopBody()
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseLoopBody() *ast.BlockStatement {
+	p.loopDepth++
+	defer func() { p.loopDepth-- }()
+
+	return p.parseBlockStatement()
+}
+
+func (p *Parser) parseBreakStatement() *ast.BreakStatement {
+	stmt := &ast.BreakStatement{Token: p.curToken}
+
+	if p.loopDepth == 0 {
+		p.errorAt("misplaced-statement", spanOf(p.curToken), "break is not in a loop")
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) parseContinueStatement() *ast.ContinueStatement {
+	stmt := &ast.ContinueStatement{Token: p.curToken}
+
+	if p.loopDepth == 0 {
+		p.errorAt("misplaced-statement", spanOf(p.curToken), "continue is not in a loop")
+	}
+
+	if p.peekTokenIs(token.SEMICOLON) {
+		p.nextToken()
+	}
+
+	return stmt
+}
+
+func (p *Parser) noPrefixParseFnError() {
+	p.failAt("unexpected-token", spanOf(p.curToken), "expected an expression, got %s instead", description(p.curToken))
 }
 
 func (p *Parser) parseExpression(precedence int) ast.Expression {
 	prefix := p.prefixParseFns[p.curToken.Type]
 	if prefix == nil {
-		p.noPrefixParseFnError(p.curToken.Type)
+		p.noPrefixParseFnError()
 
 		return nil
 	}
@@ -234,8 +709,22 @@ func (p *Parser) parseIntegerLiteral() ast.Expression {
 
 	value, err := strconv.ParseInt(p.curToken.Literal, 0, 64)
 	if err != nil {
-		msg := fmt.Sprintf("could not parse %q as an integer", p.curToken.Literal)
-		p.errors = append(p.errors, msg)
+		p.errorAt("invalid-number", spanOf(p.curToken), "could not parse %q as an integer", p.curToken.Literal)
+
+		return nil
+	}
+
+	literal.Value = value
+
+	return literal
+}
+
+func (p *Parser) parseFloatLiteral() ast.Expression {
+	literal := &ast.FloatLiteral{Token: p.curToken}
+
+	value, err := strconv.ParseFloat(p.curToken.Literal, 64)
+	if err != nil {
+		p.errorAt("invalid-number", spanOf(p.curToken), "could not parse %q as a float", p.curToken.Literal)
 
 		return nil
 	}
@@ -254,6 +743,36 @@ func (p *Parser) parseStringLiteral() ast.Expression {
 	return literal
 }
 
+func (p *Parser) parseTemplateLiteral() ast.Expression {
+	template := &ast.TemplateLiteral{Token: p.curToken}
+
+	for {
+		// The empty pieces of text between the interpolations are left out.
+		if p.curToken.Literal != "" {
+			template.Parts = append(template.Parts, p.parseStringLiteral())
+		}
+
+		if p.curTokenIs(token.TEMPLATE_END) {
+			template.Close = p.curToken
+
+			return template
+		}
+
+		p.nextToken()
+		template.Parts = append(template.Parts, p.parseExpression(LOWEST))
+
+		if p.peekTokenIs(token.TEMPLATE_MIDDLE) {
+			p.nextToken()
+
+			continue
+		}
+
+		if !p.expectPeek(token.TEMPLATE_END) {
+			return nil
+		}
+	}
+}
+
 func (p *Parser) parsePrefixExpression() ast.Expression {
 	expression := &ast.PrefixExpression{
 		Token:    p.curToken,
@@ -281,6 +800,27 @@ func (p *Parser) parseInfixExpression(left ast.Expression) ast.Expression {
 	return expression
 }
 
+func (p *Parser) parseAssignExpression(target ast.Expression) ast.Expression {
+	expression := &ast.AssignExpression{
+		Token:    p.curToken,
+		Target:   target,
+		Operator: p.curToken.Literal,
+	}
+
+	switch target.(type) {
+	case *ast.Identifier, *ast.IndexExpression, *ast.MemberExpression:
+	default:
+		d := p.errorAt("invalid-assignment", spanOf(p.curToken), "cannot assign to %s", target.String())
+		d.Notes = append(d.Notes, "only names, indexes and members can be assigned to")
+	}
+
+	// Assignments are right associative, so 'a = b = c' assigns 'c' to both.
+	p.nextToken()
+	expression.Value = p.parseExpression(ASSIGN - 1)
+
+	return expression
+}
+
 func (p *Parser) parseBoolean() ast.Expression {
 	return &ast.Boolean{Token: p.curToken, Value: p.curTokenIs(token.TRUE)}
 }
@@ -334,16 +874,29 @@ func (p *Parser) parseBlockStatement() *ast.BlockStatement {
 	block := &ast.BlockStatement{Token: p.curToken}
 	block.Statements = []ast.Statement{}
 
+	p.blockDepth++
+	defer func() { p.blockDepth-- }()
+
+	// The brace opening the block is the current token.
+	depth := p.braceDepth
 	p.nextToken()
 
 	for !p.curTokenIs(token.RBRACE) && !p.curTokenIs(token.EOF) {
 		stmt := p.parseStatement()
+		if p.recovering {
+			p.synchronize(depth)
+
+			continue
+		}
+
 		if stmt != nil {
 			block.Statements = append(block.Statements, stmt)
 		}
 		p.nextToken()
 	}
 
+	block.Rbrace = p.curToken
+
 	return block
 }
 
@@ -354,57 +907,87 @@ func (p *Parser) parseFunctionLiteral() ast.Expression {
 		return nil
 	}
 
-	lit.Parameters = p.parseFunctionParameters()
+	if !p.parseFunctionParameters(lit) {
+		return nil
+	}
 
 	if !p.expectPeek(token.LBRACE) {
 		return nil
 	}
 
+	// Loops can't be broken out of from within a function.
+	loopDepth := p.loopDepth
+	p.loopDepth = 0
 	lit.Body = p.parseBlockStatement()
+	p.loopDepth = loopDepth
 
 	return lit
 }
 
-func (p *Parser) parseFunctionParameters() []*ast.Identifier {
-	var identifiers []*ast.Identifier
-
+// parseFunctionParameters fills in the parameters of the function literal,
+// along with their default values and the rest parameter.
+func (p *Parser) parseFunctionParameters(lit *ast.FunctionLiteral) bool {
 	if p.peekTokenIs(token.RPAREN) {
 		p.nextToken()
 
-		return identifiers
+		return true
 	}
 
-	p.nextToken()
+	hasDefaults := false
 
-	ident := &ast.Identifier{
-		Token: p.curToken,
-		Value: p.curToken.Literal,
-	}
-	identifiers = append(identifiers, ident)
+	for {
+		if p.peekTokenIs(token.ELLIPSIS) {
+			p.nextToken()
+			if !p.expectPeek(token.IDENT) {
+				return false
+			}
+			lit.Rest = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
 
-	for p.peekTokenIs(token.COMMA) {
-		p.nextToken()
-		p.nextToken()
-		ident := &ast.Identifier{
-			Token: p.curToken,
-			Value: p.curToken.Literal,
+			if !p.peekTokenIs(token.RPAREN) {
+				d := p.failAt("invalid-parameters", spanOf(p.peekToken), "rest parameter %s must be the last one", lit.Rest.Value)
+				d.Notes = append(d.Notes, "the rest parameter takes the arguments left over by the other ones")
+
+				return false
+			}
+
+			break
 		}
-		identifiers = append(identifiers, ident)
-	}
 
-	if !p.expectPeek(token.RPAREN) {
-		return nil
+		if !p.expectPeek(token.IDENT) {
+			return false
+		}
+		ident := &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
+		var defaultValue ast.Expression
+		if p.peekTokenIs(token.ASSIGN) {
+			p.nextToken()
+			p.nextToken()
+			defaultValue = p.parseExpression(ASSIGN)
+			hasDefaults = true
+		} else if hasDefaults {
+			d := p.errorAt("invalid-parameters", diag.Span{Start: ident.Pos(), End: ident.End()}, "parameter %s without a default value follows one with it", ident.Value)
+			d.Notes = append(d.Notes, "the arguments are given in order, so the parameters that can be left out have to come last")
+		}
+
+		lit.Parameters = append(lit.Parameters, ident)
+		lit.Defaults = append(lit.Defaults, defaultValue)
+
+		if !p.peekTokenIs(token.COMMA) {
+			break
+		}
+		p.nextToken()
 	}
 
-	return identifiers
+	return p.expectPeek(token.RPAREN)
 }
 
 func (p *Parser) parseCallExpression(function ast.Expression) ast.Expression {
 	exp := &ast.CallExpression{
-		Token:     p.curToken,
-		Function:  function,
-		Arguments: p.parseExpressionList(token.RPAREN),
+		Token:    p.curToken,
+		Function: function,
 	}
+	exp.Arguments = p.parseExpressionList(token.RPAREN)
+	exp.Rparen = p.curToken
 
 	return exp
 }
@@ -437,6 +1020,7 @@ func (p *Parser) parseExpressionList(end token.Type) []ast.Expression {
 func (p *Parser) parseArrayLiteral() ast.Expression {
 	array := &ast.ArrayLiteral{Token: p.curToken}
 	array.Elements = p.parseExpressionList(token.RBRACKET)
+	array.Rbracket = p.curToken
 
 	return array
 }
@@ -454,6 +1038,22 @@ func (p *Parser) parseIndexExpression(left ast.Expression) ast.Expression {
 		return nil
 	}
 
+	exp.Rbracket = p.curToken
+
+	return exp
+}
+
+func (p *Parser) parseMemberExpression(object ast.Expression) ast.Expression {
+	exp := &ast.MemberExpression{
+		Token:  p.curToken,
+		Object: object,
+	}
+
+	if !p.expectPeek(token.IDENT) {
+		return nil
+	}
+	exp.Property = &ast.Identifier{Token: p.curToken, Value: p.curToken.Literal}
+
 	return exp
 }
 
@@ -483,5 +1083,7 @@ func (p *Parser) parseMapLiteral() ast.Expression {
 		return nil
 	}
 
+	m.Rbrace = p.curToken
+
 	return m
 }
@@ -2,11 +2,15 @@ package parser
 
 import (
 	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/diag"
 	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/token"
 )
 
 func checkParserErrors(t *testing.T) func(p *Parser) {
@@ -90,6 +94,108 @@ func testConstStatement(t *testing.T) func(ast.Statement, string) bool {
 	}
 }
 
+func TestLetStatements(t *testing.T) {
+	testCases := []struct {
+		input              string
+		expectedIdentifier string
+		expectedValue      interface{}
+	}{
+		{"let x = 5;", "x", 5},
+		{"let y = true", "y", true},
+		{"let foobar = y;", "foobar", "y"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d",
+				len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.LetStatement)
+		if !ok {
+			t.Fatalf("stmt not *ast.LetStatement. got=%T", program.Statements[0])
+		}
+
+		if stmt.Name.Value != tc.expectedIdentifier {
+			t.Errorf("stmt.Name.Value not '%s'. got=%s", tc.expectedIdentifier, stmt.Name.Value)
+		}
+
+		if !testLiteralExpression(t)(stmt.Value, tc.expectedValue) {
+			return
+		}
+	}
+}
+
+func TestAssignExpressions(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{"x = 5", "x = 5"},
+		{"x += y * 2", "x += (y * 2)"},
+		{"x -= 1", "x -= 1"},
+		{"x *= 2", "x *= 2"},
+		{"x /= 2", "x /= 2"},
+		{"x %= 2", "x %= 2"},
+		{"a = b = c", "a = b = c"},
+		{"a[1] = 2", "(a[1]) = 2"},
+		{`m["k"] += a == b`, "(m[k]) += (a == b)"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain 1 statement. got=%d", len(program.Statements))
+		}
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		if _, ok := stmt.Expression.(*ast.AssignExpression); !ok {
+			t.Fatalf("stmt.Expression is not ast.AssignExpression. got=%T", stmt.Expression)
+		}
+
+		if actual := program.String(); actual != tc.expected {
+			t.Errorf("expected=%q, got=%q", tc.expected, actual)
+		}
+	}
+
+	// Assignments are right associative.
+	l := lexer.New("a = b = c")
+	p := New(l)
+	program := p.ParseProgram()
+
+	outer := program.Statements[0].(*ast.ExpressionStatement).Expression.(*ast.AssignExpression)
+	if _, ok := outer.Value.(*ast.AssignExpression); !ok {
+		t.Errorf("outer.Value is not ast.AssignExpression. got=%T", outer.Value)
+	}
+}
+
+func TestInvalidAssignmentTarget(t *testing.T) {
+	l := lexer.New("1 + 2 = 3")
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 {
+		t.Fatalf("expected 1 parser error, got %d", len(errors))
+	}
+
+	expected := "1:7: cannot assign to (1 + 2)"
+	if errors[0] != expected {
+		t.Errorf("wrong error message. expected=%q, got=%q", expected, errors[0])
+	}
+}
+
 func TestReturnStatements(t *testing.T) {
 	testCases := []struct {
 		input         string
@@ -185,6 +291,40 @@ func TestIntegerLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestFloatLiteralExpression(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected float64
+	}{
+		{"3.14;", 3.14},
+		{"1e3;", 1000},
+		{"2.5E-1;", 0.25},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program doesn't have enough statements. got=%d", len(program.Statements))
+		}
+		stmt, ok := program.Statements[0].(*ast.ExpressionStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExpressionStatement. got=%T", program.Statements[0])
+		}
+
+		literal, ok := stmt.Expression.(*ast.FloatLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.FloatLiteral. got=%T", stmt.Expression)
+		}
+		if literal.Value != tc.expected {
+			t.Errorf("literal.Value not %g. got %g", tc.expected, literal.Value)
+		}
+	}
+}
+
 func TestParsingPrefixExpressions(t *testing.T) {
 	prefixTests := []struct {
 		input    string
@@ -260,6 +400,7 @@ func TestParsingInfixExpressions(t *testing.T) {
 		{"5 - 5;", 5, "-", 5},
 		{"5 * 5;", 5, "*", 5},
 		{"5 / 5;", 5, "/", 5},
+		{"5 % 5;", 5, "%", 5},
 		{"5 > 5;", 5, ">", 5},
 		{"5 < 5;", 5, "<", 5},
 		{"5 == 5;", 5, "==", 5},
@@ -390,6 +531,10 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"a * b / c",
 			"((a * b) / c)",
 		},
+		{
+			"a + b % c * d",
+			"(a + ((b % c) * d))",
+		},
 		{
 			"a + b / c",
 			"(a + (b / c))",
@@ -470,6 +615,10 @@ func TestOperatorPrecedenceParsing(t *testing.T) {
 			"add(a * b[2], b[1], 2 * [1, 2][1])",
 			"add((a * (b[2])), (b[1]), (2 * ([1, 2][1])))",
 		},
+		{
+			"-util.math.max(a, b)[0] * 2",
+			"((-(util.math.max(a, b)[0])) * 2)",
+		},
 	}
 
 	for _, tc := range testCases {
@@ -628,6 +777,285 @@ func TestIfElseExpression(t *testing.T) {
 	}
 }
 
+func TestWhileStatement(t *testing.T) {
+	input := `while (x < y) { x; break; continue; };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t)(p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.WhileStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.WhileStatement. got=%T", program.Statements[0])
+	}
+
+	if !testInfixExpression(t)(stmt.Condition, "x", "<", "y") {
+		return
+	}
+
+	if len(stmt.Body.Statements) != 3 {
+		t.Fatalf("body is not 3 statements. got=%d\n", len(stmt.Body.Statements))
+	}
+
+	if _, ok := stmt.Body.Statements[1].(*ast.BreakStatement); !ok {
+		t.Errorf("Statements[1] is not ast.BreakStatement. got=%T", stmt.Body.Statements[1])
+	}
+
+	if _, ok := stmt.Body.Statements[2].(*ast.ContinueStatement); !ok {
+		t.Errorf("Statements[2] is not ast.ContinueStatement. got=%T", stmt.Body.Statements[2])
+	}
+}
+
+func TestForStatement(t *testing.T) {
+	testCases := []struct {
+		input            string
+		expectedKey      string
+		expectedValue    string
+		expectedIterable string
+	}{
+		{"for (x in xs) { x }", "", "x", "xs"},
+		{"for (k, v in m) { v }", "k", "v", "m"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ForStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ForStatement. got=%T", program.Statements[0])
+		}
+
+		if tc.expectedKey == "" {
+			if stmt.Key != nil {
+				t.Errorf("stmt.Key was not nil. got=%+v", stmt.Key)
+			}
+		} else {
+			testIdentifier(t)(stmt.Key, tc.expectedKey)
+		}
+
+		testIdentifier(t)(stmt.Value, tc.expectedValue)
+		testIdentifier(t)(stmt.Iterable, tc.expectedIterable)
+	}
+}
+
+func TestTryStatement(t *testing.T) {
+	testCases := []struct {
+		input           string
+		expectedParam   string
+		expectedCatch   bool
+		expectedFinally bool
+	}{
+		{"try { x } catch (e) { e }", "e", true, false},
+		{"try { x } finally { y }", "", false, true},
+		{"try { x } catch (e) { e } finally { y }", "e", true, true},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.TryStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.TryStatement. got=%T", program.Statements[0])
+		}
+
+		if len(stmt.Block.Statements) != 1 {
+			t.Errorf("try block is not 1 statement. got=%d", len(stmt.Block.Statements))
+		}
+
+		if tc.expectedCatch {
+			testIdentifier(t)(stmt.Param, tc.expectedParam)
+		}
+
+		if (stmt.Catch != nil) != tc.expectedCatch {
+			t.Errorf("wrong catch block for %q. got=%+v", tc.input, stmt.Catch)
+		}
+
+		if (stmt.Finally != nil) != tc.expectedFinally {
+			t.Errorf("wrong finally block for %q. got=%+v", tc.input, stmt.Finally)
+		}
+	}
+}
+
+func TestThrowStatement(t *testing.T) {
+	input := `throw "oops" + x;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t)(p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ThrowStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ThrowStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.String() != "throw (oops + x);" {
+		t.Errorf("stmt.String() wrong. got=%q", stmt.String())
+	}
+}
+
+func TestImportStatement(t *testing.T) {
+	input := `import "lib/util.doggo" as util;`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t)(p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ImportStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ImportStatement. got=%T", program.Statements[0])
+	}
+
+	if stmt.Path.Value != "lib/util.doggo" {
+		t.Errorf("stmt.Path.Value not %q. got=%q", "lib/util.doggo", stmt.Path.Value)
+	}
+
+	testIdentifier(t)(stmt.Name, "util")
+}
+
+func TestExportStatement(t *testing.T) {
+	testCases := []struct {
+		input        string
+		expectedName string
+	}{
+		{"export const x = 1;", "x"},
+		{"export let y = fn() { 2 };", "y"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		if len(program.Statements) != 1 {
+			t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+		}
+
+		stmt, ok := program.Statements[0].(*ast.ExportStatement)
+		if !ok {
+			t.Fatalf("program.Statements[0] is not ast.ExportStatement. got=%T", program.Statements[0])
+		}
+
+		testIdentifier(t)(stmt.Name(), tc.expectedName)
+	}
+}
+
+func TestInvalidModuleStatements(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{`if (true) { import "x.doggo" as x; }`, "1:13: import is only allowed at the top level"},
+		{"fn() { export const x = 1; }", "1:8: export is only allowed at the top level"},
+		{"export x = 1;", "1:8: expected a declaration after export, got 'x' instead"},
+		{`import "x.doggo";`, "1:17: expected 'as', got ';' instead"},
+		{"import x as y;", "1:8: expected a string, got 'x' instead"},
+		{"util.1", "1:6: expected a name, got '1' instead"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tc.input)
+
+			continue
+		}
+
+		if errors[0] != tc.expectedError {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedError, errors[0])
+		}
+	}
+}
+
+func TestInvalidTryStatements(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"try { x }", "1:10: expected 'catch' or 'finally' after the try block, got the end of the file instead"},
+		{"try { x } catch { y }", "1:17: expected '(', got '{' instead"},
+		{"try { x } catch () { y }", "1:18: expected a name, got ')' instead"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tc.input)
+
+			continue
+		}
+
+		if errors[0] != tc.expectedError {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedError, errors[0])
+		}
+	}
+}
+
+func TestLoopControlOutsideLoop(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"break;", "1:1: break is not in a loop"},
+		{"if (true) { continue; }", "1:13: continue is not in a loop"},
+		{"while (true) { fn() { break; } }", "1:23: break is not in a loop"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) != 1 {
+			t.Errorf("expected 1 parser error for %q, got %d", tc.input, len(errors))
+
+			continue
+		}
+
+		if errors[0] != tc.expectedError {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedError, errors[0])
+		}
+	}
+}
+
 func TestFunctionLiteralParsing(t *testing.T) {
 	input := `fn(x, y) { x + y; }`
 
@@ -701,6 +1129,73 @@ func TestFunctionParameterParsing(t *testing.T) {
 	}
 }
 
+func TestFunctionDefaultAndRestParameters(t *testing.T) {
+	testCases := []struct {
+		input        string
+		expected     string
+		expectedRest string
+	}{
+		{"fn(x, y = 2) {}", "fn(x, y = 2)", ""},
+		{"fn(x = 1, y = x * 2) {}", "fn(x = 1, y = (x * 2))", ""},
+		{"fn(...rest) {}", "fn(...rest)", "rest"},
+		{"fn(first, second = [], ...rest) {}", "fn(first, second = [], ...rest)", "rest"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		function, ok := stmt.Expression.(*ast.FunctionLiteral)
+		if !ok {
+			t.Fatalf("stmt.Expression is not ast.FunctionLiteral. got=%T", stmt.Expression)
+		}
+
+		if function.String() != tc.expected {
+			t.Errorf("wrong function literal. expected=%q, got=%q", tc.expected, function.String())
+		}
+
+		rest := ""
+		if function.Rest != nil {
+			rest = function.Rest.Value
+		}
+		if rest != tc.expectedRest {
+			t.Errorf("wrong rest parameter. expected=%q, got=%q", tc.expectedRest, rest)
+		}
+	}
+}
+
+func TestInvalidFunctionParameters(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"fn(...rest, x) {}", "1:11: rest parameter rest must be the last one"},
+		{"fn(x = 1, y) {}", "1:11: parameter y without a default value follows one with it"},
+		{"fn(...) {}", "1:7: expected a name, got ')' instead"},
+		{"fn(1) {}", "1:4: expected a name, got '1' instead"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tc.input)
+
+			continue
+		}
+
+		if errors[0] != tc.expectedError {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedError, errors[0])
+		}
+	}
+}
+
 func TestCallExpressionParsing(t *testing.T) {
 	input := "add(1, 2 * 3, 4 + 5);"
 
@@ -806,6 +1301,50 @@ func TestStringLiteralExpression(t *testing.T) {
 	}
 }
 
+func TestTemplateLiteralParsing(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expected      string
+		expectedParts int
+	}{
+		{`"hello ${name}!"`, `"hello ${name}!"`, 3},
+		{`"${a + 1}${b}"`, `"${(a + 1)}${b}"`, 2},
+		{`"${f("${x}")} end"`, `"${f("${x}")} end"`, 2},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.New(tc.input)
+		p := New(l)
+		program := p.ParseProgram()
+		checkParserErrors(t)(p)
+
+		stmt := program.Statements[0].(*ast.ExpressionStatement)
+		template, ok := stmt.Expression.(*ast.TemplateLiteral)
+		if !ok {
+			t.Fatalf("exp not *ast.TemplateLiteral. got=%T", stmt.Expression)
+		}
+
+		if template.String() != tc.expected {
+			t.Errorf("wrong template. expected=%q, got=%q", tc.expected, template.String())
+		}
+
+		if len(template.Parts) != tc.expectedParts {
+			t.Errorf("wrong number of parts. expected=%d, got=%d", tc.expectedParts, len(template.Parts))
+		}
+	}
+}
+
+func TestLexerErrorsAreReported(t *testing.T) {
+	l := lexer.New(`const s = "abc`)
+	p := New(l)
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != 1 || errors[0] != "1:11: unterminated string" {
+		t.Errorf("wrong parser errors. got=%q", errors)
+	}
+}
+
 func TestParsingArrayLiterals(t *testing.T) {
 	input := "[1, 2 * 2, 3 + 3]"
 
@@ -1035,3 +1574,214 @@ func TestParsingMapLiteralsWithExpressions(t *testing.T) {
 		testFunc(value)
 	}
 }
+
+func TestNodePositions(t *testing.T) {
+	input := `const add = fn(x, y) {
+    x + y;
+};
+add(1, [2][0]);`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t)(p)
+
+	if len(program.Statements) != 2 {
+		t.Fatalf("program.Statements does not contain 2 statements. got=%d", len(program.Statements))
+	}
+
+	fn := program.Statements[0].(*ast.ConstStatement).Value.(*ast.FunctionLiteral)
+	call := program.Statements[1].(*ast.ExpressionStatement).Expression.(*ast.CallExpression)
+	index := call.Arguments[1].(*ast.IndexExpression)
+
+	testCases := []struct {
+		node          ast.Node
+		expectedStart string
+		expectedEnd   string
+	}{
+		{program, "1:1", "4:15"},
+		{fn, "1:13", "3:2"},
+		{fn.Body.Statements[0], "2:5", "2:10"},
+		{call, "4:1", "4:15"},
+		{index, "4:8", "4:14"},
+	}
+
+	for i, tc := range testCases {
+		if tc.node.Pos().String() != tc.expectedStart {
+			t.Errorf("Case %d: node %q starts at wrong position. expected=%s, got=%s", i, tc.node, tc.expectedStart, tc.node.Pos())
+		}
+
+		if tc.node.End().String() != tc.expectedEnd {
+			t.Errorf("Case %d: node %q ends at wrong position. expected=%s, got=%s", i, tc.node, tc.expectedEnd, tc.node.End())
+		}
+	}
+}
+
+func TestParserErrorPositions(t *testing.T) {
+	testCases := []struct {
+		input         string
+		expectedError string
+	}{
+		{"const x = (1;", "main.doggo:1:13: expected ')', got ';' instead"},
+		{"const x = 1;\nconst = 2;", "main.doggo:2:7: expected a name, got '=' instead"},
+		{"\n  ) + 1", "main.doggo:2:3: expected an expression, got ')' instead"},
+	}
+
+	for _, tc := range testCases {
+		l := lexer.NewWithFilename("main.doggo", tc.input)
+		p := New(l)
+		p.ParseProgram()
+
+		errors := p.Errors()
+		if len(errors) == 0 {
+			t.Errorf("expected parser errors for %q, got none", tc.input)
+
+			continue
+		}
+
+		if errors[0] != tc.expectedError {
+			t.Errorf("wrong error message. expected=%q, got=%q", tc.expectedError, errors[0])
+		}
+	}
+}
+
+func TestErrorRecovery(t *testing.T) {
+	testCases := []struct {
+		input              string
+		expectedErrors     []string
+		expectedStatements int
+	}{
+		{
+			"let = 1;\nlet y = 2;\nlet = 3;",
+			[]string{"1:5: expected a name, got '=' instead", "3:5: expected a name, got '=' instead"},
+			1,
+		},
+		{"print(1 +);\nprint(2);", []string{"1:10: expected an expression, got ')' instead"}, 1},
+		{"fn() { let x = ; print(x) }; print(1)", []string{"1:16: expected an expression, got ';' instead"}, 2},
+		{"if (x { print(1) }\nlet y = 1;", []string{"1:7: expected ')', got '{' instead"}, 1},
+		{"let m = {a: , b: 1};\nm", []string{"1:13: expected an expression, got ',' instead"}, 1},
+		{"const f = fn() {\n  let = 1;\n  return 2;\n};\nconst g = fn() { 3 };", []string{"2:7: expected a name, got '=' instead"}, 2},
+		{"let x = 1 }; let y = 2;", []string{"1:11: expected an expression, got '}' instead"}, 2},
+		{"let 🐶 = 1;", []string{"1:5: expected a name, got the invalid character '🐶' instead"}, 0},
+		{`import "a.doggo" as "b";`, []string{`1:21: expected a name, got the string "b" instead`}, 0},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.input))
+		program := p.ParseProgram()
+
+		if !reflect.DeepEqual(p.Errors(), tc.expectedErrors) {
+			t.Errorf("wrong errors for %q.\nexpected=%q\ngot=     %q", tc.input, tc.expectedErrors, p.Errors())
+		}
+
+		if len(program.Statements) != tc.expectedStatements {
+			t.Errorf("wrong number of statements for %q. expected=%d, got=%d", tc.input, tc.expectedStatements, len(program.Statements))
+		}
+	}
+}
+
+func TestErrorLimit(t *testing.T) {
+	p := New(lexer.New(strings.Repeat("let = 1;\n", MaxErrors+5)))
+	p.ParseProgram()
+
+	errors := p.Errors()
+	if len(errors) != MaxErrors+1 || errors[MaxErrors] != "too many errors" {
+		t.Errorf("wrong errors. got=%q", errors)
+	}
+
+	p = New(lexer.New(""))
+	p.errorAt("oops", diag.Point(token.Position{Line: 1, Column: 1}), "oops")
+	p.errorAt("oops", diag.Point(token.Position{Line: 1, Column: 1}), "oops")
+	if len(p.Errors()) != 1 {
+		t.Errorf("expected the same error to be reported once. got=%q", p.Errors())
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected *diag.Diagnostic
+	}{
+		{
+			"let = 1;",
+			&diag.Diagnostic{
+				Code:    "unexpected-token",
+				Span:    diag.Span{Start: token.Position{Offset: 4, Line: 1, Column: 5}, End: token.Position{Offset: 5, Line: 1, Column: 6}},
+				Message: "expected a name, got '=' instead",
+			},
+		},
+		{
+			"print(1, 2;",
+			&diag.Diagnostic{
+				Code:    "unexpected-token",
+				Span:    diag.Span{Start: token.Position{Offset: 10, Line: 1, Column: 11}, End: token.Position{Offset: 11, Line: 1, Column: 12}},
+				Message: "expected ')', got ';' instead",
+				Fix: &diag.Fix{
+					Message: "insert ')'",
+					Span:    diag.Point(token.Position{Offset: 10, Line: 1, Column: 11}),
+					NewText: ")",
+				},
+			},
+		},
+		{
+			"fn(a = 1, bb) {}",
+			&diag.Diagnostic{
+				Code:    "invalid-parameters",
+				Span:    diag.Span{Start: token.Position{Offset: 10, Line: 1, Column: 11}, End: token.Position{Offset: 12, Line: 1, Column: 13}},
+				Message: "parameter bb without a default value follows one with it",
+				Notes:   []string{"the arguments are given in order, so the parameters that can be left out have to come last"},
+			},
+		},
+		{
+			`"a\qb"`,
+			&diag.Diagnostic{
+				Code:    "invalid-escape",
+				Span:    diag.Span{Start: token.Position{Offset: 2, Line: 1, Column: 3}, End: token.Position{Offset: 4, Line: 1, Column: 5}},
+				Message: "unknown escape sequence: \\q",
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		p := New(lexer.New(tc.input))
+		p.ParseProgram()
+
+		diagnostics := p.Diagnostics()
+		if len(diagnostics) != 1 {
+			t.Errorf("wrong number of diagnostics for %q. expected=1, got=%q", tc.input, p.Errors())
+
+			continue
+		}
+
+		if !reflect.DeepEqual(diagnostics[0], tc.expected) {
+			t.Errorf("wrong diagnostic for %q.\nexpected=%+v\ngot=     %+v", tc.input, tc.expected, diagnostics[0])
+		}
+	}
+}
+
+func TestFunctionLiteralWithName(t *testing.T) {
+	input := `const myFunction = fn() { };`
+
+	l := lexer.New(input)
+	p := New(l)
+	program := p.ParseProgram()
+	checkParserErrors(t)(p)
+
+	if len(program.Statements) != 1 {
+		t.Fatalf("program.Statements does not contain %d statements. got=%d\n", 1, len(program.Statements))
+	}
+
+	stmt, ok := program.Statements[0].(*ast.ConstStatement)
+	if !ok {
+		t.Fatalf("program.Statements[0] is not ast.ConstStatement. got=%T", program.Statements[0])
+	}
+
+	function, ok := stmt.Value.(*ast.FunctionLiteral)
+	if !ok {
+		t.Fatalf("stmt.Value is not ast.FunctionLiteral. got=%T", stmt.Value)
+	}
+
+	if function.Name != "myFunction" {
+		t.Fatalf("function literal name wrong. want 'myFunction', got=%q\n", function.Name)
+	}
+}
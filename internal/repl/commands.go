@@ -0,0 +1,183 @@
+package repl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/runner"
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// COMMAND_PREFIX starts the commands of the REPL, like ':help', which are handled by the REPL instead of being run.
+const COMMAND_PREFIX = ":"
+
+// command is a command of the REPL, typed as ':name argument'.
+type command struct {
+	name string
+	// arg describes the argument of the command, if it takes one.
+	arg  string
+	help string
+	run  func(out io.Writer, r *runner.Runner, arg string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"help", "", "list the commands", helpCommand},
+		{"env", "", "list the global bindings, with the types of their values", envCommand},
+		{"type", "code", "run the code and show the type of its result", typeCommand},
+		{"ast", "code", "show the syntax tree of the code", astCommand},
+		{"tokens", "code", "show the tokens the code is made of", tokensCommand},
+		{"load", "file", "run the file in the session", loadCommand},
+		{"reset", "", "forget all the bindings and the imported modules", resetCommand},
+		{"time", "code", "run the code and show how long it took", timeCommand},
+	}
+}
+
+// isCommand reports whether the line is a command of the REPL.
+func isCommand(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), COMMAND_PREFIX)
+}
+
+// runCommand runs the command typed on the line.
+func runCommand(out io.Writer, r *runner.Runner, line string) {
+	line = strings.TrimPrefix(strings.TrimSpace(line), COMMAND_PREFIX)
+
+	name, arg := line, ""
+	if i := strings.IndexAny(line, " \t"); i >= 0 {
+		name, arg = line[:i], strings.TrimSpace(line[i+1:])
+	}
+
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+
+		if c.arg != "" && arg == "" {
+			fmt.Fprintf(out, "usage: %s%s %s\n", COMMAND_PREFIX, c.name, c.arg)
+
+			return
+		}
+
+		c.run(out, r, arg)
+
+		return
+	}
+
+	fmt.Fprintf(out, "unknown command: %s%s, try %shelp\n", COMMAND_PREFIX, name, COMMAND_PREFIX)
+}
+
+func helpCommand(out io.Writer, r *runner.Runner, arg string) {
+	for _, c := range commands {
+		usage := COMMAND_PREFIX + c.name
+		if c.arg != "" {
+			usage += " " + c.arg
+		}
+
+		fmt.Fprintf(out, "%-14s %s\n", usage, c.help)
+	}
+}
+
+func envCommand(out io.Writer, r *runner.Runner, arg string) {
+	names := r.Names()
+	if len(names) == 0 {
+		io.WriteString(out, "no bindings yet\n")
+
+		return
+	}
+
+	for _, name := range names {
+		val, _ := r.Global(name)
+		fmt.Fprintf(out, "%s: %s\n", name, val.Type())
+	}
+}
+
+func typeCommand(out io.Writer, r *runner.Runner, arg string) {
+	program, errors := runner.Parse("", arg)
+	if len(errors) != 0 {
+		io.WriteString(out, runner.ParserErrors(errors))
+
+		return
+	}
+
+	result, err := r.Exec(context.Background(), program)
+	if err != nil {
+		fmt.Fprintf(out, "compiler error: %s\n", err)
+
+		return
+	}
+
+	switch result := result.(type) {
+	case nil:
+		io.WriteString(out, "the code has no value\n")
+
+	case *object.Error:
+		fmt.Fprintln(out, runner.Traceback(result))
+
+	default:
+		fmt.Fprintln(out, result.Type())
+	}
+}
+
+func astCommand(out io.Writer, r *runner.Runner, arg string) {
+	program, errors := runner.Parse("", arg)
+	if len(errors) != 0 {
+		io.WriteString(out, runner.ParserErrors(errors))
+
+		return
+	}
+
+	for _, statement := range program.Statements {
+		io.WriteString(out, ast.Dump(statement))
+	}
+}
+
+func tokensCommand(out io.Writer, r *runner.Runner, arg string) {
+	l := lexer.New(arg)
+	l.SetKeepComments(true)
+
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		fmt.Fprintf(out, "%s\t%s\t%q\n", tok.Pos, tok.Type, tok.Literal)
+	}
+
+	for _, err := range l.Errors() {
+		fmt.Fprintln(out, err)
+	}
+}
+
+func loadCommand(out io.Writer, r *runner.Runner, arg string) {
+	code, err := ioutil.ReadFile(arg)
+	if err != nil {
+		fmt.Fprintf(out, "cannot read file: %s\n", err)
+
+		return
+	}
+
+	if output := r.RunFile(context.Background(), arg, string(code)); output != "" {
+		fmt.Fprintln(out, output)
+	}
+}
+
+func resetCommand(out io.Writer, r *runner.Runner, arg string) {
+	r.Reset()
+	io.WriteString(out, "all the bindings are gone\n")
+}
+
+func timeCommand(out io.Writer, r *runner.Runner, arg string) {
+	start := time.Now()
+	output := r.Run(context.Background(), arg)
+	elapsed := time.Since(start)
+
+	if output != "" {
+		fmt.Fprintln(out, output)
+	}
+	fmt.Fprintf(out, "took %s\n", elapsed)
+}
@@ -0,0 +1,294 @@
+package repl
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// errInterrupted is returned when the user gives up on the line being typed, with Ctrl-C.
+var errInterrupted = errors.New("interrupted")
+
+// The keys the editor understands, besides the printable ones.
+const (
+	keyCtrlA     = 1
+	keyCtrlB     = 2
+	keyCtrlC     = 3
+	keyCtrlD     = 4
+	keyCtrlE     = 5
+	keyCtrlF     = 6
+	keyBackspace = 8
+	keyTab       = 9
+	keyLineFeed  = 10
+	keyCtrlK     = 11
+	keyEnter     = 13
+	keyCtrlN     = 14
+	keyCtrlP     = 16
+	keyCtrlU     = 21
+	keyCtrlW     = 23
+	keyEscape    = 27
+	keyDelete    = 127
+)
+
+// editor reads lines typed on a terminal in raw mode, letting the user move around them,
+// recall the previous ones from the history and complete names with the tab key.
+type editor struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	history *history
+	// names returns the names that can be completed.
+	names func() []string
+
+	// line is the line being typed, and pos the position of the cursor in it.
+	line []rune
+	pos  int
+	// recalled is the index of the history entry being shown, or the number of entries for the line being typed.
+	recalled int
+	// typed keeps the line being typed while browsing the history.
+	typed []rune
+}
+
+func newEditor(in *bufio.Reader, out io.Writer, h *history, names func() []string) *editor {
+	return &editor{in: in, out: out, history: h, names: names}
+}
+
+// readLine shows the prompt and reads a line, until the enter key. It returns io.EOF when
+// Ctrl-D is pressed on an empty line, and errInterrupted when Ctrl-C is.
+func (e *editor) readLine(prompt string) (string, error) {
+	e.line = nil
+	e.pos = 0
+	e.recalled = len(e.history.entries)
+	e.refresh(prompt)
+
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return "", err
+		}
+
+		switch r {
+		case keyEnter, keyLineFeed:
+			io.WriteString(e.out, "\r\n")
+			line := string(e.line)
+			e.history.add(line)
+
+			return line, nil
+
+		case keyCtrlC:
+			io.WriteString(e.out, "^C\r\n")
+
+			return "", errInterrupted
+
+		case keyCtrlD:
+			if len(e.line) == 0 {
+				io.WriteString(e.out, "\r\n")
+
+				return "", io.EOF
+			}
+			e.deleteAt(e.pos)
+
+		case keyBackspace, keyDelete:
+			if e.pos > 0 {
+				e.pos--
+				e.deleteAt(e.pos)
+			}
+
+		case keyCtrlA:
+			e.pos = 0
+
+		case keyCtrlE:
+			e.pos = len(e.line)
+
+		case keyCtrlB:
+			e.moveBy(-1)
+
+		case keyCtrlF:
+			e.moveBy(1)
+
+		case keyCtrlK:
+			e.line = e.line[:e.pos]
+
+		case keyCtrlU:
+			e.line = append([]rune{}, e.line[e.pos:]...)
+			e.pos = 0
+
+		case keyCtrlW:
+			start := e.wordStart(unicode.IsSpace)
+			e.line = append(e.line[:start], e.line[e.pos:]...)
+			e.pos = start
+
+		case keyCtrlP:
+			e.recall(-1)
+
+		case keyCtrlN:
+			e.recall(1)
+
+		case keyTab:
+			e.complete()
+
+		case keyEscape:
+			e.readEscapeSequence()
+
+		default:
+			if unicode.IsPrint(r) {
+				e.insert(r)
+			}
+		}
+
+		e.refresh(prompt)
+	}
+}
+
+// readEscapeSequence handles the keys sent as escape sequences, like the arrow keys.
+func (e *editor) readEscapeSequence() {
+	kind, _, err := e.in.ReadRune()
+	if err != nil || kind != '[' && kind != 'O' {
+		return
+	}
+
+	// The parameters of the sequence, if any, come before its final letter or tilde.
+	var params strings.Builder
+	for {
+		r, _, err := e.in.ReadRune()
+		if err != nil {
+			return
+		}
+
+		if r < '0' || r > '9' && r != ';' {
+			switch {
+			case r == 'A':
+				e.recall(-1)
+			case r == 'B':
+				e.recall(1)
+			case r == 'C':
+				e.moveBy(1)
+			case r == 'D':
+				e.moveBy(-1)
+			case r == 'H' || r == '~' && (params.String() == "1" || params.String() == "7"):
+				e.pos = 0
+			case r == 'F' || r == '~' && (params.String() == "4" || params.String() == "8"):
+				e.pos = len(e.line)
+			case r == '~' && params.String() == "3":
+				e.deleteAt(e.pos)
+			}
+
+			return
+		}
+
+		params.WriteRune(r)
+	}
+}
+
+// refresh redraws the prompt and the line, and puts the cursor back in its place.
+func (e *editor) refresh(prompt string) {
+	fmt.Fprintf(e.out, "\r%s%s\x1b[K", prompt, string(e.line))
+	if back := len(e.line) - e.pos; back > 0 {
+		fmt.Fprintf(e.out, "\x1b[%dD", back)
+	}
+}
+
+func (e *editor) insert(r rune) {
+	e.line = append(e.line, 0)
+	copy(e.line[e.pos+1:], e.line[e.pos:])
+	e.line[e.pos] = r
+	e.pos++
+}
+
+func (e *editor) deleteAt(pos int) {
+	if pos < len(e.line) {
+		e.line = append(e.line[:pos], e.line[pos+1:]...)
+	}
+}
+
+func (e *editor) moveBy(n int) {
+	if pos := e.pos + n; pos >= 0 && pos <= len(e.line) {
+		e.pos = pos
+	}
+}
+
+// wordStart returns where the word before the cursor starts, the word being made of the chars not matching the separator.
+func (e *editor) wordStart(separator func(rune) bool) int {
+	start := e.pos
+	for start > 0 && separator(e.line[start-1]) {
+		start--
+	}
+	for start > 0 && !separator(e.line[start-1]) {
+		start--
+	}
+
+	return start
+}
+
+// recall replaces the line with an older (-1) or a newer (1) entry of the history.
+func (e *editor) recall(step int) {
+	index := e.recalled + step
+	if index < 0 || index > len(e.history.entries) {
+		return
+	}
+
+	if e.recalled == len(e.history.entries) {
+		e.typed = e.line
+	}
+	e.recalled = index
+
+	if index == len(e.history.entries) {
+		e.line = e.typed
+	} else {
+		e.line = []rune(e.history.entries[index])
+	}
+	e.pos = len(e.line)
+}
+
+// complete completes the name before the cursor. When there's more than one way to do it, the line is
+// completed as far as all the names agree, and the names are listed if that doesn't get any further.
+func (e *editor) complete() {
+	isNameChar := func(r rune) bool {
+		return unicode.IsLetter(r) || r == '_'
+	}
+
+	start := e.pos
+	for start > 0 && isNameChar(e.line[start-1]) {
+		start--
+	}
+
+	word := string(e.line[start:e.pos])
+	if word == "" {
+		return
+	}
+
+	matches := completions(word, e.names())
+	if len(matches) == 0 {
+		return
+	}
+
+	prefix := commonPrefix(matches)
+	if len(prefix) > len(word) {
+		for _, r := range prefix[len(word):] {
+			e.insert(r)
+		}
+
+		return
+	}
+
+	if len(matches) > 1 {
+		fmt.Fprintf(e.out, "\r\n%s\r\n", strings.Join(matches, "  "))
+	}
+}
+
+// commonPrefix returns the longest prefix shared by all the names.
+func commonPrefix(names []string) string {
+	prefix := names[0]
+	for _, name := range names[1:] {
+		for !strings.HasPrefix(name, prefix) {
+			_, size := utf8.DecodeLastRuneInString(prefix)
+			prefix = prefix[:len(prefix)-size]
+		}
+	}
+
+	return prefix
+}
@@ -0,0 +1,84 @@
+package repl
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HISTORY_FILE is where the lines typed in the REPL are kept, in the home directory of the user.
+const HISTORY_FILE = ".doggo_history"
+
+// maxHistory is the number of lines kept in the history.
+const maxHistory = 1000
+
+// history holds the lines typed in the REPL, oldest first, and saves them to a file as they are added.
+type history struct {
+	entries []string
+	// path is the file the history is saved to. Without one, it lasts for the session only.
+	path string
+}
+
+// historyPath returns the path of the history file, or an empty one if the home directory is unknown.
+func historyPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+
+	return filepath.Join(home, HISTORY_FILE)
+}
+
+// loadHistory reads the history saved to the file. A missing or unreadable file makes an empty history.
+func loadHistory(path string) *history {
+	h := &history{path: path}
+	if path == "" {
+		return h
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		h.entries = append(h.entries, scanner.Text())
+	}
+
+	// Only the latest lines are kept, so the file doesn't grow forever.
+	if len(h.entries) > maxHistory {
+		h.entries = h.entries[len(h.entries)-maxHistory:]
+		content := strings.Join(h.entries, "\n") + "\n"
+		ioutil.WriteFile(path, []byte(content), 0600)
+	}
+
+	return h
+}
+
+// add appends the line to the history, unless it's blank or the same as the previous one.
+func (h *history) add(line string) {
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	if len(h.entries) > 0 && h.entries[len(h.entries)-1] == line {
+		return
+	}
+
+	h.entries = append(h.entries, line)
+	if h.path == "" {
+		return
+	}
+
+	f, err := os.OpenFile(h.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	f.WriteString(line + "\n")
+}
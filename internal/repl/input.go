@@ -0,0 +1,65 @@
+package repl
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// isIncomplete reports whether the code stops in the middle of something, like a block,
+// a call or a string, so more lines should be read before running it.
+func isIncomplete(code string) bool {
+	l := lexer.New(code)
+
+	depth := 0
+	for tok := l.NextToken(); tok.Type != token.EOF; tok = l.NextToken() {
+		switch tok.Type {
+		case token.LPAREN, token.LBRACE, token.LBRACKET, token.TEMPLATE_START:
+			depth++
+		case token.RPAREN, token.RBRACE, token.RBRACKET, token.TEMPLATE_END:
+			depth--
+		}
+	}
+
+	if depth > 0 {
+		return true
+	}
+
+	// Strings and comments can span multiple lines too.
+	for _, d := range l.Diagnostics() {
+		if d.Code == "unterminated-string" || d.Code == "unterminated-comment" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// completions returns the names starting with the word, sorted and without duplicates.
+func completions(word string, names []string) []string {
+	seen := make(map[string]bool)
+	var matches []string
+
+	for _, name := range names {
+		if strings.HasPrefix(name, word) && !seen[name] {
+			seen[name] = true
+			matches = append(matches, name)
+		}
+	}
+	sort.Strings(matches)
+
+	return matches
+}
+
+// builtinNames returns the names of the builtin functions.
+func builtinNames() []string {
+	names := make([]string, len(object.Builtins))
+	for i, b := range object.Builtins {
+		names[i] = b.Name
+	}
+
+	return names
+}
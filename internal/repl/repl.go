@@ -2,8 +2,11 @@ package repl
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"io"
+	"os"
+	"strings"
 
 	"github.com/axbarsan/doggo/internal/runner"
 )
@@ -15,25 +18,131 @@ import (
 
 const PROMPT = ">> "
 
+// CONTINUATION_PROMPT is shown while reading the rest of some code spanning multiple lines.
+const CONTINUATION_PROMPT = ".. "
+
+// lineReader reads the lines of code typed in the REPL.
+type lineReader interface {
+	readLine(prompt string) (string, error)
+}
+
 // Start parses each line of the file and returns
-// the result to the output stream.
-func Start(in io.Reader, out io.Writer) {
-	scanner := bufio.NewScanner(in)
-	r := runner.New()
+// the result to the output stream, running the code
+// with the given runner. The code reads from the same
+// input, prints to the same output, and writes its errors
+// to errOut.
+// Code left incomplete, like a block missing its closing
+// brace, is run once the lines after it complete it.
+// Lines starting with ':' are commands, see ':help'.
+// On a terminal, the lines can be edited, the previous
+// ones recalled, and names completed with the tab key.
+func Start(in io.Reader, out, errOut io.Writer, r *runner.Runner) {
+	reader := bufio.NewReader(in)
+	r.SetIO(reader, out, errOut)
 
+	lines := newLineReader(in, reader, out, r)
+
+	var code []string
 	for {
-		fmt.Fprintf(out, PROMPT)
-		scanned := scanner.Scan()
-		if !scanned {
+		prompt := PROMPT
+		if len(code) > 0 {
+			prompt = CONTINUATION_PROMPT
+		}
+
+		line, err := lines.readLine(prompt)
+		if err == errInterrupted {
+			code = nil
+
+			continue
+		}
+		if err != nil {
+			// Whatever was left incomplete still gets run, so its errors are reported.
+			if len(code) > 0 {
+				run(out, r, strings.Join(code, "\n"))
+			}
+
 			return
 		}
 
-		line := scanner.Text()
-		output := r.Run(line)
+		if len(code) == 0 && isCommand(line) {
+			runCommand(out, r, line)
 
-		io.WriteString(out, output)
-		if output != "" {
-			io.WriteString(out, "\n")
+			continue
 		}
+
+		code = append(code, line)
+		source := strings.Join(code, "\n")
+		if isIncomplete(source) {
+			continue
+		}
+
+		code = nil
+		run(out, r, source)
+	}
+}
+
+func run(out io.Writer, r *runner.Runner, code string) {
+	output := r.Run(context.Background(), code)
+
+	io.WriteString(out, output)
+	if output != "" {
+		io.WriteString(out, "\n")
+	}
+}
+
+// newLineReader returns the line editor when the input is a terminal, and a plain line reader otherwise.
+func newLineReader(in io.Reader, reader *bufio.Reader, out io.Writer, r *runner.Runner) lineReader {
+	f, ok := in.(*os.File)
+	if !ok {
+		return &plainReader{in: reader, out: out}
 	}
+
+	restore, err := makeRaw(f.Fd())
+	if err != nil {
+		return &plainReader{in: reader, out: out}
+	}
+	restore()
+
+	names := func() []string {
+		return append(r.Names(), builtinNames()...)
+	}
+
+	return &terminalReader{
+		fd:     f.Fd(),
+		editor: newEditor(reader, out, loadHistory(historyPath()), names),
+	}
+}
+
+// plainReader reads lines as they come, for input that isn't typed on a terminal.
+type plainReader struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func (p *plainReader) readLine(prompt string) (string, error) {
+	fmt.Fprint(p.out, prompt)
+
+	line, err := p.in.ReadString('\n')
+	if line == "" && err != nil {
+		return "", err
+	}
+
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// terminalReader reads lines with the editor, keeping the terminal in raw mode only while doing it,
+// so the code being run gets it as usual.
+type terminalReader struct {
+	fd     uintptr
+	editor *editor
+}
+
+func (t *terminalReader) readLine(prompt string) (string, error) {
+	restore, err := makeRaw(t.fd)
+	if err != nil {
+		return "", err
+	}
+	defer restore()
+
+	return t.editor.readLine(prompt)
 }
@@ -0,0 +1,215 @@
+package repl
+
+import (
+	"bufio"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/runner"
+)
+
+func TestIsIncomplete(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected bool
+	}{
+		{"1 + 2", false},
+		{"const f = fn(x) {", true},
+		{"const f = fn(x) {\n  x\n};", false},
+		{"print(1,", true},
+		{"[1, [2,", true},
+		{`"hello`, true},
+		{`"hello ${name`, true},
+		{`"hello ${ {"a": 1}["a"] } there"`, false},
+		{"`raw", true},
+		{"/* a comment", true},
+		{"// a comment {", false},
+		{"1 + 2)", false},
+	}
+
+	for _, tc := range testCases {
+		if incomplete := isIncomplete(tc.input); incomplete != tc.expected {
+			t.Errorf("wrong result for %q. expected=%t, got=%t", tc.input, tc.expected, incomplete)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	input := `const add = fn(a, b) {
+  a + b
+};
+add(1,
+  2)
+let name = input("name? ");
+doggo
+print("hi ${name}")
+[1,`
+
+	for _, engine := range []runner.Engine{runner.EngineEval, runner.EngineVM} {
+		out := new(strings.Builder)
+		Start(strings.NewReader(input), out, ioutil.Discard, runner.NewWithEngine(engine))
+
+		expected := ">> .. .. >> .. 3\n>> name? >> hi doggo\nnull\n>> .. "
+		if !strings.HasPrefix(out.String(), expected) {
+			t.Errorf("wrong output on the %q engine.\nexpected=%q\ngot=%q", engine, expected, out.String())
+		}
+
+		// The incomplete code left at the end is still run, to report its errors.
+		if !strings.Contains(out.String(), "syntax error") {
+			t.Errorf("expected parser errors on the %q engine. got=%q", engine, out.String())
+		}
+	}
+}
+
+func TestStartErrorOutput(t *testing.T) {
+	for _, engine := range []runner.Engine{runner.EngineEval, runner.EngineVM} {
+		out := new(strings.Builder)
+		errOut := new(strings.Builder)
+		Start(strings.NewReader(`eprint("oops")`), out, errOut, runner.NewWithEngine(engine))
+
+		if strings.Contains(out.String(), "oops") {
+			t.Errorf("expected the errors to be kept out of the output on the %q engine. got=%q", engine, out.String())
+		}
+
+		if errOut.String() != "oops\n" {
+			t.Errorf("wrong error output on the %q engine. got=%q", engine, errOut.String())
+		}
+	}
+}
+
+func TestEditor(t *testing.T) {
+	testCases := []struct {
+		keys     string
+		expected []string
+	}{
+		{"let x = 1;\r", []string{"let x = 1;"}},
+		// Moving around the line, deleting and inserting.
+		{"1 + 3\x1b[D2\x1b[C4\r", []string{"1 + 234"}},
+		{"12\x7f3\x1b[D\x1b[3~\r", []string{"1"}},
+		{"world\x01hello \x05!\r", []string{"hello world!"}},
+		{"one two\x17three\r", []string{"one three"}},
+		{"abc\x02\x02\x0b\r", []string{"a"}},
+		// Completing names.
+		{"pr\t(1)\r", []string{"print(1)"}},
+		{"longN\t\r", []string{"longName"}},
+		{"lo\t\r", []string{"long"}},
+		// Recalling the previous lines.
+		{"first\rsecond\r\x1b[A\x1b[A\r", []string{"first", "second", "first"}},
+		{"first\rtyped\x1b[A\x1b[B!\r", []string{"first", "typed!"}},
+	}
+
+	names := func() []string {
+		return []string{"longName", "longer", "print", "push"}
+	}
+
+	for _, tc := range testCases {
+		e := newEditor(bufio.NewReader(strings.NewReader(tc.keys)), ioutil.Discard, &history{}, names)
+
+		var lines []string
+		for {
+			line, err := e.readLine(PROMPT)
+			if err != nil {
+				break
+			}
+			lines = append(lines, line)
+		}
+
+		if !reflect.DeepEqual(lines, tc.expected) {
+			t.Errorf("wrong lines for %q. expected=%q, got=%q", tc.keys, tc.expected, lines)
+		}
+	}
+}
+
+func TestEditorControlKeys(t *testing.T) {
+	e := newEditor(bufio.NewReader(strings.NewReader("1 +\x03\x04")), ioutil.Discard, &history{}, nil)
+
+	if _, err := e.readLine(PROMPT); err != errInterrupted {
+		t.Errorf("expected the line to be interrupted. got=%v", err)
+	}
+
+	if _, err := e.readLine(PROMPT); err != io.EOF {
+		t.Errorf("expected the end of the input. got=%v", err)
+	}
+}
+
+func TestHistory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doggo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, HISTORY_FILE)
+
+	h := loadHistory(path)
+	for _, line := range []string{"let x = 1;", "", "x", "x", "x + 1"} {
+		h.add(line)
+	}
+
+	expected := []string{"let x = 1;", "x", "x + 1"}
+	if loaded := loadHistory(path); !reflect.DeepEqual(loaded.entries, expected) {
+		t.Errorf("wrong history. expected=%q, got=%q", expected, loaded.entries)
+	}
+
+	lines := make([]string, maxHistory+10)
+	for i := range lines {
+		lines[i] = strings.Repeat("x", i+1)
+	}
+	if err := ioutil.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	loadHistory(path)
+	if loaded := loadHistory(path); len(loaded.entries) != maxHistory || loaded.entries[0] != lines[10] {
+		t.Errorf("wrong trimmed history. got %d entries", len(loaded.entries))
+	}
+}
+
+func TestCommands(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doggo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	lib := filepath.Join(dir, "lib.doggo")
+	if err := ioutil.WriteFile(lib, []byte(`const double = fn(x) { x * 2 };`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{":help", ":tokens code   show the tokens the code is made of\n"},
+		{":env", "no bindings yet\n"},
+		{"let x = 1; const f = fn() {};\n:env", "f: FUNCTION\nx: INTEGER\n"},
+		{":type [1]", "ARRAY\n"},
+		{":type let y = 1;", "the code has no value\n"},
+		{":type 1 + true", "ERROR: 1:1: type mismatch: INTEGER + BOOLEAN\n"},
+		{":ast -x", "ExpressionStatement 1:1\n  Expression: PrefixExpression 1:1\n    Operator: \"-\"\n    Right: Identifier 1:2\n      Value: \"x\"\n"},
+		{":tokens x += 1", "1:1\tIDENT\t\"x\"\n1:3\t+=\t\"+=\"\n1:6\tINT\t\"1\"\n"},
+		{":load " + lib + "\ndouble(21)", "42\n"},
+		{":load " + filepath.Join(dir, "missing.doggo"), "cannot read file: "},
+		{"let x = 1;\n:reset\nx", "identifier not found: x\n"},
+		{":time 1 + 1", "2\ntook "},
+		{":type", "usage: :type code\n"},
+		{":nope", "unknown command: :nope, try :help\n"},
+	}
+
+	for _, engine := range []runner.Engine{runner.EngineEval, runner.EngineVM} {
+		for _, tc := range testCases {
+			out := new(strings.Builder)
+			Start(strings.NewReader(tc.input), out, ioutil.Discard, runner.NewWithEngine(engine))
+
+			if !strings.Contains(out.String(), tc.expected) {
+				t.Errorf("wrong output on the %q engine for %q.\nexpected to contain=%q\ngot=%q", engine, tc.input, tc.expected, out.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package runner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/compiler"
+	"github.com/axbarsan/doggo/internal/diag"
+	"github.com/axbarsan/doggo/internal/evaluator"
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/parser"
+	"github.com/axbarsan/doggo/internal/token"
+	"github.com/axbarsan/doggo/internal/vm"
+)
+
+// SearchPathEnv is the environment variable holding the directories searched for modules,
+// after the one of the importing file.
+const SearchPathEnv = "DOGGO_PATH"
+
+// moduleLoader runs each imported module once, on the engine of the runner, and keeps its exports around.
+type moduleLoader struct {
+	engine  Engine
+	options *object.Options
+
+	searchPath []string
+
+	// modules maps the absolute paths of the modules which have been run to their exports.
+	modules map[string]*object.Module
+	// loading holds the paths of the modules being run, in the order they were imported in.
+	loading []string
+}
+
+func newModuleLoader(engine Engine, options *object.Options) *moduleLoader {
+	var searchPath []string
+	for _, dir := range filepath.SplitList(os.Getenv(SearchPathEnv)) {
+		if dir != "" {
+			searchPath = append(searchPath, dir)
+		}
+	}
+
+	return &moduleLoader{
+		engine:     engine,
+		options:    options,
+		searchPath: searchPath,
+		modules:    make(map[string]*object.Module),
+	}
+}
+
+func (ml *moduleLoader) Load(path string, from token.Position) (*object.Module, *object.Error) {
+	fileName, ok := ml.resolve(path, from)
+	if !ok {
+		return nil, newError("module-not-found", "cannot find module %s", path)
+	}
+
+	key, err := filepath.Abs(fileName)
+	if err != nil {
+		return nil, newError("module-not-found", "cannot find module %s: %s", path, err)
+	}
+
+	if module, ok := ml.modules[key]; ok {
+		return module, nil
+	}
+
+	for i, loading := range ml.loading {
+		if loading == key {
+			var cycle []string
+			for _, k := range ml.loading[i:] {
+				cycle = append(cycle, displayName(k))
+			}
+			cycle = append(cycle, displayName(key))
+
+			return nil, newError("import-cycle", "import cycle: %s", strings.Join(cycle, " -> "))
+		}
+	}
+
+	ml.loading = append(ml.loading, key)
+	defer func() { ml.loading = ml.loading[:len(ml.loading)-1] }()
+
+	module, loadErr := ml.run(fileName)
+	if loadErr != nil {
+		return nil, loadErr
+	}
+	ml.modules[key] = module
+
+	return module, nil
+}
+
+// resolve finds the file of the module, looking next to the importing file first, then in the search path.
+func (ml *moduleLoader) resolve(path string, from token.Position) (string, bool) {
+	if filepath.IsAbs(path) {
+		return path, isFile(path)
+	}
+
+	dirs := append([]string{filepath.Dir(from.Filename)}, ml.searchPath...)
+	for _, dir := range dirs {
+		if fileName := filepath.Join(dir, path); isFile(fileName) {
+			return fileName, true
+		}
+	}
+
+	return "", false
+}
+
+// displayName returns the path of the module relative to the working directory, if possible.
+func displayName(key string) string {
+	wd, err := os.Getwd()
+	if err != nil {
+		return key
+	}
+
+	rel, err := filepath.Rel(wd, key)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return key
+	}
+
+	return rel
+}
+
+// run executes the module in a fresh environment and collects the bindings it exports.
+// The environment is kept along with the module, which reads the exported bindings from it.
+func (ml *moduleLoader) run(fileName string) (*object.Module, *object.Error) {
+	code, err := ioutil.ReadFile(fileName)
+	if err != nil {
+		return nil, newError("module-not-found", "cannot read module %s: %s", fileName, err)
+	}
+
+	l := lexer.NewWithFilename(fileName, string(code))
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+	if errors := p.Diagnostics(); len(errors) != 0 {
+		return nil, errorAt(errors[0], "syntax error")
+	}
+
+	module := &object.Module{Path: fileName, Exports: make(map[string]func() object.Object)}
+
+	switch ml.engine {
+	case EngineVM:
+		symbolTable := compiler.NewSymbolTable()
+		comp := compiler.NewWithState(symbolTable, []object.Object{})
+		if err := comp.Compile(program); err != nil {
+			return nil, errorAt(err.(*diag.Diagnostic), "compiler error")
+		}
+
+		globals := &object.Globals{Values: make([]object.Object, vm.GlobalsSize)}
+		machine := vm.NewWithGlobalsState(comp.Bytecode(), globals)
+		machine.SetOptions(ml.options)
+		if err, ok := machine.Run().(*object.Error); ok {
+			return nil, err
+		}
+
+		for _, name := range exportedNames(program) {
+			if symbol, ok := symbolTable.Resolve(name); ok {
+				index := symbol.Index
+				module.Exports[name] = func() object.Object { return globals.Values[index] }
+			}
+		}
+
+	default:
+		env := object.NewEnvironmentWithOptions(ml.options)
+		if err, ok := evaluator.Eval(program, env).(*object.Error); ok {
+			return nil, err
+		}
+
+		for _, name := range exportedNames(program) {
+			name := name
+			module.Exports[name] = func() object.Object {
+				val, _ := env.Get(name)
+
+				return val
+			}
+		}
+	}
+
+	return module, nil
+}
+
+func exportedNames(program *ast.Program) []string {
+	var names []string
+	for _, s := range program.Statements {
+		if export, ok := s.(*ast.ExportStatement); ok {
+			names = append(names, export.Name().Value)
+		}
+	}
+
+	return names
+}
+
+func isFile(path string) bool {
+	info, err := os.Stat(path)
+
+	return err == nil && !info.IsDir()
+}
+
+// errorAt returns the problem found in the code of a module as an error raised at its position,
+// rather than at the import statement, with the kind of the problem prefixed to its message.
+func errorAt(d *diag.Diagnostic, kind string) *object.Error {
+	return &object.Error{Code: d.Code, Message: fmt.Sprintf("%s: %s", kind, d.Message), Pos: d.Span.Start}
+}
+
+func newError(code, format string, a ...interface{}) *object.Error {
+	return &object.Error{Code: code, Message: fmt.Sprintf(format, a...)}
+}
@@ -1,40 +1,118 @@
 package runner
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/compiler"
+	"github.com/axbarsan/doggo/internal/diag"
 	"github.com/axbarsan/doggo/internal/evaluator"
 	"github.com/axbarsan/doggo/internal/lexer"
 	"github.com/axbarsan/doggo/internal/object"
 	"github.com/axbarsan/doggo/internal/parser"
+	"github.com/axbarsan/doggo/internal/vm"
+)
+
+// Engine is the backend used to execute the code.
+type Engine string
+
+const (
+	// EngineEval walks the AST of the program.
+	EngineEval Engine = "eval"
+	// EngineVM compiles the program to bytecode and executes it on the virtual machine.
+	EngineVM Engine = "vm"
 )
 
 type Runner struct {
+	engine  Engine
+	options *object.Options
+	limits  object.Limits
+	// onReset holds the functions called after each reset.
+	onReset []func()
+
 	env *object.Environment
+
+	// The state the virtual machine keeps between runs.
+	symbolTable *compiler.SymbolTable
+	constants   []object.Object
+	globals     *object.Globals
 }
 
 func New() *Runner {
-	env := object.NewEnvironment()
+	return NewWithEngine(EngineEval)
+}
 
+// NewWithEngine creates a runner that executes the code on the given backend.
+func NewWithEngine(engine Engine) *Runner {
 	r := &Runner{
-		env: env,
+		engine:  engine,
+		options: &object.Options{},
 	}
+	r.Reset()
 
 	return r
 }
 
-func (r *Runner) Run(code string) string {
-	l := lexer.New(code)
-	p := parser.New(l)
+// Reset forgets the global bindings declared so far, along with the modules imported.
+// The settings of the runner, like its limits and streams, are kept.
+func (r *Runner) Reset() {
+	r.options.Modules = newModuleLoader(r.engine, r.options)
+	r.env = object.NewEnvironmentWithOptions(r.options)
+	r.symbolTable = compiler.NewSymbolTable()
+	r.constants = []object.Object{}
+	r.globals = &object.Globals{Values: make([]object.Object, vm.GlobalsSize)}
 
-	program := p.ParseProgram()
-	if len(p.Errors()) != 0 {
-		return getParserErrors(p.Errors())
+	for _, f := range r.onReset {
+		f()
+	}
+}
+
+// OnReset calls the function after each reset, e.g. to bind again the globals set by the host.
+func (r *Runner) OnReset(f func()) {
+	r.onReset = append(r.onReset, f)
+}
+
+// SetStrict turns the strict mode on or off. In strict mode, integer overflows are runtime errors.
+func (r *Runner) SetStrict(strict bool) {
+	r.options.Strict = strict
+}
+
+// SetIO sets the streams the code reads its input from, and writes its output and errors to.
+// By default, they are the standard streams of the process.
+func (r *Runner) SetIO(in io.Reader, out, errOut io.Writer) {
+	r.options.IO = object.NewIO(in, out, errOut)
+}
+
+// SetLimits bounds the resources used by each run. See object.Limits.
+func (r *Runner) SetLimits(limits object.Limits) {
+	r.limits = limits
+}
+
+// Run runs the code until it ends or the context is done, returning its result, or its errors, as text.
+func (r *Runner) Run(ctx context.Context, code string) string {
+	return r.RunFile(ctx, "", code)
+}
+
+// RunFile works like Run, but positions in error messages will also mention the given file name.
+func (r *Runner) RunFile(ctx context.Context, fileName, code string) string {
+	program, errors := Parse(fileName, code)
+	if len(errors) != 0 {
+		return ParserErrors(errors)
+	}
+
+	evaluated, err := r.Exec(ctx, program)
+	if err != nil {
+		return fmt.Sprintf("compiler error: %s", err)
+	}
+
+	if err, ok := evaluated.(*object.Error); ok {
+		return Traceback(err)
 	}
 
-	evaluated := evaluator.Eval(program, r.env)
 	if evaluated != nil {
 		return evaluated.Inspect()
 	}
@@ -42,14 +120,146 @@ func (r *Runner) Run(code string) string {
 	return ""
 }
 
-func getParserErrors(errors []string) string {
+// Parse builds the syntax tree of the code, returning the syntax errors found in it, if any.
+func Parse(fileName, code string) (*ast.Program, []*diag.Diagnostic) {
+	l := lexer.NewWithFilename(fileName, code)
+	p := parser.New(l)
+
+	program := p.ParseProgram()
+
+	return program, p.Diagnostics()
+}
+
+// Exec runs a parsed program, returning its result, or the runtime error that stopped it.
+// The error is only set when the program can't be compiled, as a *diag.Diagnostic. Once the context is done, or the run
+// goes over its limits, the program is stopped by an error of the object.LimitError kind.
+func (r *Runner) Exec(ctx context.Context, program *ast.Program) (object.Object, error) {
+	// The modules imported by the program share its meter.
+	r.options.Meter = object.NewMeter(ctx, r.limits)
+
+	switch r.engine {
+	case EngineVM:
+		comp := compiler.NewWithState(r.symbolTable, r.constants)
+		if err := comp.Compile(program); err != nil {
+			return nil, err
+		}
+
+		bytecode := comp.Bytecode()
+		r.constants = bytecode.Constants
+
+		machine := vm.NewWithGlobalsState(bytecode, r.globals)
+		machine.SetOptions(r.options)
+
+		return machine.Run(), nil
+
+	default:
+		return evaluator.Eval(program, r.env), nil
+	}
+}
+
+// SetGlobal binds the name to the value, as a global variable of the code run afterwards.
+func (r *Runner) SetGlobal(name string, val object.Object) {
+	switch r.engine {
+	case EngineVM:
+		symbol := r.symbolTable.Define(name)
+		r.globals.Values[symbol.Index] = val
+		delete(r.globals.Decls, symbol.Index)
+
+	default:
+		r.env.Set(name, val)
+	}
+}
+
+// Global returns the value bound to the global name, if there's one.
+func (r *Runner) Global(name string) (object.Object, bool) {
+	switch r.engine {
+	case EngineVM:
+		symbol, ok := r.symbolTable.Resolve(name)
+		if !ok || r.globals.Values[symbol.Index] == nil {
+			return nil, false
+		}
+
+		return r.globals.Values[symbol.Index], true
+
+	default:
+		return r.env.Get(name)
+	}
+}
+
+// Names returns the names of the global bindings, sorted.
+func (r *Runner) Names() []string {
+	switch r.engine {
+	case EngineVM:
+		var names []string
+		for _, name := range r.symbolTable.Names() {
+			if _, ok := r.Global(name); ok {
+				names = append(names, name)
+			}
+		}
+		sort.Strings(names)
+
+		return names
+
+	default:
+		return r.env.Names()
+	}
+}
+
+// ParserErrors renders the syntax errors found in some code.
+func ParserErrors(errors []*diag.Diagnostic) string {
 	buf := new(strings.Builder)
 
-	io.WriteString(buf, fmt.Sprintf("There are %d errors in your code.\n", len(errors)))
-	io.WriteString(buf, " parser errors: \n")
-	for _, msg := range errors {
-		io.WriteString(buf, fmt.Sprintf("\t%s\n", msg))
+	if len(errors) == 1 {
+		io.WriteString(buf, "The code has a syntax error:\n")
+	} else {
+		io.WriteString(buf, "The code has syntax errors:\n")
+	}
+
+	for _, d := range errors {
+		io.WriteString(buf, fmt.Sprintf("\t%s\n", d.Error()))
+	}
+
+	return buf.String()
+}
+
+// Traceback renders an error along with the function calls it unwound through.
+func Traceback(err *object.Error) string {
+	if len(err.Stack) == 0 {
+		return err.Inspect()
+	}
+
+	// Each frame holds the position of a call site, which lies in the function of the frame above it.
+	var lines []string
+	for i := len(err.Stack) - 1; i >= 0; i-- {
+		caller := "<main>"
+		if i+1 < len(err.Stack) {
+			caller = err.Stack[i+1].Function
+		}
+		lines = append(lines, fmt.Sprintf("%s, in %s", err.Stack[i].Pos, caller))
+	}
+	lines = append(lines, fmt.Sprintf("%s, in %s", err.Pos, err.Stack[0].Function))
+
+	buf := new(strings.Builder)
+
+	io.WriteString(buf, "Traceback (most recent call last):\n")
+	for i := 0; i < len(lines); {
+		// Collapse the lines produced by deep recursion.
+		repeated := 0
+		for i+repeated+1 < len(lines) && lines[i+repeated+1] == lines[i] {
+			repeated++
+		}
+
+		io.WriteString(buf, fmt.Sprintf("\t%s\n", lines[i]))
+		switch {
+		case repeated == 1:
+			io.WriteString(buf, "\t[previous line repeated 1 more time]\n")
+		case repeated > 1:
+			io.WriteString(buf, fmt.Sprintf("\t[previous line repeated %d more times]\n", repeated))
+		}
+
+		i += repeated + 1
 	}
+	io.WriteString(buf, err.Inspect())
 
 	return buf.String()
 }
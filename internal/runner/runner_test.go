@@ -0,0 +1,258 @@
+package runner
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+func TestRunTraceback(t *testing.T) {
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{
+			"1 + true;",
+			"ERROR: main.doggo:1:1: type mismatch: INTEGER + BOOLEAN",
+		},
+		{
+			`const inner = fn(x) {
+    return x + true;
+};
+const outer = fn(x) {
+    return inner(x);
+};
+outer(1);`,
+			`Traceback (most recent call last):
+	main.doggo:7:1, in <main>
+	main.doggo:5:12, in outer
+	main.doggo:2:12, in inner
+ERROR: main.doggo:2:12: type mismatch: INTEGER + BOOLEAN`,
+		},
+		{
+			`const countdown = fn(n) {
+    if (n == 0) {
+        return missing;
+    }
+
+    return countdown(n - 1);
+};
+fn(x) { countdown(x) }(3);`,
+			`Traceback (most recent call last):
+	main.doggo:8:1, in <main>
+	main.doggo:8:9, in <anonymous>
+	main.doggo:6:12, in countdown
+	[previous line repeated 2 more times]
+	main.doggo:3:16, in countdown
+ERROR: main.doggo:3:16: identifier not found: missing`,
+		},
+	}
+
+	for _, tc := range testCases {
+		r := New()
+
+		output := r.RunFile(context.Background(), "main.doggo", tc.input)
+		if output != tc.expected {
+			t.Errorf("wrong output.\nexpected=\n%s\ngot=\n%s", tc.expected, output)
+		}
+	}
+}
+
+func TestModules(t *testing.T) {
+	dir, err := ioutil.TempDir("", "doggo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	files := map[string]string{
+		"lib/util.doggo": `import "counter.doggo" as counter;
+export const double = fn(x) { x * 2 };
+export let loads = counter.next();
+const hidden = 1;`,
+		"lib/counter.doggo": `export let count = 0;
+export const next = fn() { count += 1 };`,
+		"lib/a.doggo":       `import "b.doggo" as b;`,
+		"lib/b.doggo":       `import "a.doggo" as a;`,
+		"lib/broken.doggo":  `export const x = 1 + true;`,
+		"path/shared.doggo": `export const name = "shared";`,
+	}
+
+	for name, code := range files {
+		fileName := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(fileName), 0755); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(fileName, []byte(code), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	os.Setenv(SearchPathEnv, filepath.Join(dir, "path"))
+	defer os.Unsetenv(SearchPathEnv)
+
+	testCases := []struct {
+		input    string
+		expected string
+	}{
+		{`import "lib/util.doggo" as util; util.double(21)`, "42"},
+		// Each module runs once, however many times it's imported.
+		{`import "lib/util.doggo" as util; import "lib/counter.doggo" as counter; [util.loads, counter.next()]`, "[1, 2]"},
+		{`import "shared.doggo" as shared; shared.name`, "shared"},
+		// The exported variables are read with their current value.
+		{`import "lib/counter.doggo" as counter; const before = counter.count; counter.next(); counter.next(); [before, counter.count]`, "[0, 2]"},
+		{`import "lib/util.doggo" as util; util.hidden`, "ERROR: main.doggo:1:34: module $DIR/lib/util.doggo does not export hidden"},
+		{`import "missing.doggo" as missing;`, "ERROR: main.doggo:1:1: cannot find module missing.doggo"},
+		{`import "lib/a.doggo" as a;`, "ERROR: $DIR/lib/b.doggo:1:1: import cycle: $DIR/lib/a.doggo -> $DIR/lib/b.doggo -> $DIR/lib/a.doggo"},
+		{`import "lib/broken.doggo" as broken;`, "ERROR: $DIR/lib/broken.doggo:1:18: type mismatch: INTEGER + BOOLEAN"},
+		{`const util = 1; import "lib/util.doggo" as util;`, "main.doggo:1:17: cannot redeclare constant: util"},
+	}
+
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		for _, tc := range testCases {
+			r := NewWithEngine(engine)
+
+			expected := strings.ReplaceAll(tc.expected, "$DIR", dir)
+			output := r.RunFile(context.Background(), filepath.Join(dir, "main.doggo"), tc.input)
+			output = strings.ReplaceAll(output, filepath.Join(dir, "main.doggo"), "main.doggo")
+			if !strings.HasSuffix(output, expected) {
+				t.Errorf("wrong output on the %q engine for %q.\nexpected=%s\ngot=%s", engine, tc.input, expected, output)
+			}
+		}
+	}
+}
+
+func TestLimits(t *testing.T) {
+	testCases := []struct {
+		input    string
+		limits   object.Limits
+		expected string
+	}{
+		{`while (true) {}`, object.Limits{MaxSteps: 1000}, "step limit exceeded: 1000"},
+		{`const f = fn(n) { f(n + 1) }; f(0);`, object.Limits{}, "call depth limit exceeded: 10000"},
+		{`const f = fn(n) { f(n + 1) }; f(0);`, object.Limits{MaxCallDepth: 50}, "call depth limit exceeded: 50"},
+		{`let xs = []; while (true) { xs = push(xs, 1) }`, object.Limits{MaxAllocations: 1000}, "allocation limit exceeded: 1000"},
+		{`let s = "dog"; while (true) { s = "${s}go" }`, object.Limits{MaxAllocations: 1000}, "allocation limit exceeded: 1000"},
+		// The errors stopping a run can't be caught.
+		{`while (true) { try { while (true) {} } catch (e) {} finally { continue } }`, object.Limits{MaxSteps: 1000}, "step limit exceeded: 1000"},
+		// The calls an error unwinds through aren't counted anymore.
+		{
+			`const f = fn(n) { if (n == 0) { throw "bottom" }; f(n - 1) };
+let caught = 0;
+while (caught < 100) { try { f(40) } catch (e) { caught += 1 } };
+caught`,
+			object.Limits{MaxCallDepth: 50},
+			"100",
+		},
+	}
+
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		for _, tc := range testCases {
+			r := NewWithEngine(engine)
+			r.SetLimits(tc.limits)
+
+			output := r.Run(context.Background(), tc.input)
+			if !strings.HasSuffix(output, tc.expected) {
+				t.Errorf("wrong output on the %q engine for %q.\nexpected=%s\ngot=%s", engine, tc.input, tc.expected, output)
+			}
+
+			// The limits apply to each run on its own.
+			if output := r.Run(context.Background(), "1 + 1"); output != "2" {
+				t.Errorf("wrong output on the %q engine after %q. got=%s", engine, tc.input, output)
+			}
+		}
+	}
+}
+
+func TestRunCancellation(t *testing.T) {
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		r := NewWithEngine(engine)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		output := r.Run(ctx, `const loop = fn() { while (true) {} }; loop();`)
+		cancel()
+
+		expected := "execution stopped: context deadline exceeded"
+		if !strings.HasSuffix(output, expected) {
+			t.Errorf("wrong output on the %q engine.\nexpected=%s\ngot=%s", engine, expected, output)
+		}
+	}
+}
+
+func TestIO(t *testing.T) {
+	input := `let name = input("name? ");
+let lines = [];
+let line = readLine();
+while (line != {}["x"]) { lines = push(lines, line); line = readLine(); }
+print("hello ${name}", length(lines));
+eprint("done");`
+
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		r := NewWithEngine(engine)
+
+		out := new(strings.Builder)
+		errOut := new(strings.Builder)
+		r.SetIO(strings.NewReader("doggo\r\nfirst\nsecond"), out, errOut)
+
+		if output := r.Run(context.Background(), input); output != "null" {
+			t.Fatalf("unexpected output on the %q engine: %s", engine, output)
+		}
+
+		if expected := "name? hello doggo\n2\n"; out.String() != expected {
+			t.Errorf("wrong output on the %q engine. expected=%q, got=%q", engine, expected, out.String())
+		}
+
+		if expected := "done\n"; errOut.String() != expected {
+			t.Errorf("wrong error output on the %q engine. expected=%q, got=%q", engine, expected, errOut.String())
+		}
+	}
+}
+
+func TestNames(t *testing.T) {
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		r := NewWithEngine(engine)
+		r.SetGlobal("host", &object.Integer{Value: 1})
+		r.Run(context.Background(), `const b = 1; let a = fn() { let local = 2; }; missing;`)
+
+		expected := []string{"a", "b", "host"}
+		if names := r.Names(); !reflect.DeepEqual(names, expected) {
+			t.Errorf("wrong names on the %q engine. expected=%q, got=%q", engine, expected, names)
+		}
+	}
+}
+
+func TestConstantsAcrossRuns(t *testing.T) {
+	for _, engine := range []Engine{EngineEval, EngineVM} {
+		r := NewWithEngine(engine)
+
+		runs := []struct {
+			input    string
+			expected string
+		}{
+			{"const x = 1;", ""},
+			{"x = 2", "cannot assign to constant: x"},
+			{"const x = 3", "cannot redeclare constant: x"},
+			{"x", "1"},
+		}
+
+		for _, run := range runs {
+			if output := r.Run(context.Background(), run.input); !strings.HasSuffix(output, run.expected) {
+				t.Errorf("wrong output on the %q engine for %q.\nexpected=%s\ngot=%s", engine, run.input, run.expected, output)
+			}
+		}
+
+		// The bindings set by the host aren't constants anymore.
+		r.SetGlobal("x", &object.Integer{Value: 4})
+		if output := r.Run(context.Background(), "x = 5"); output != "5" {
+			t.Errorf("wrong output on the %q engine after setting the binding. got=%s", engine, output)
+		}
+	}
+}
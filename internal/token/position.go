@@ -0,0 +1,46 @@
+package token
+
+import (
+	"fmt"
+)
+
+// Position describes a location in the source code.
+type Position struct {
+	// Filename is the name of the source file, if any.
+	Filename string `json:"filename,omitempty"`
+	// Offset is the byte offset, starting at 0.
+	Offset int `json:"offset"`
+	// Line is the line number, starting at 1.
+	Line int `json:"line"`
+	// Column is the column number, starting at 1, counted in runes (characters), not bytes.
+	// Editors counting UTF-16 code units, like the LSP ones, have to convert it.
+	Column int `json:"column"`
+}
+
+// IsValid reports whether the position has been set.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+// String returns the position in one of the following forms:
+//
+//	file:line:column    valid position with file name
+//	line:column         valid position without file name
+//	file                invalid position with file name
+//	-                   invalid position without file name
+func (p Position) String() string {
+	s := p.Filename
+
+	if p.IsValid() {
+		if s != "" {
+			s += ":"
+		}
+		s += fmt.Sprintf("%d:%d", p.Line, p.Column)
+	}
+
+	if s == "" {
+		s = "-"
+	}
+
+	return s
+}
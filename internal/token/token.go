@@ -9,6 +9,10 @@ type Token struct {
 	Type Type
 	// Literal represents the token, but in a literal fashion (e.g. func).
 	Literal string
+	// Pos is the position of the first character of the token.
+	Pos Position
+	// End is the position immediately after the last character of the token.
+	End Position
 }
 
 const (
@@ -18,8 +22,17 @@ const (
 	// Identifiers and literals.
 	IDENT  = "IDENT"  // Function / variable name.
 	INT    = "INT"    // Integer.
+	FLOAT  = "FLOAT"  // Floating-point number.
 	STRING = "STRING" // String.
 
+	// A string with interpolated expressions is split in multiple tokens:
+	// "a ${x} b ${y} c" becomes TEMPLATE_START, x, TEMPLATE_MIDDLE, y and TEMPLATE_END.
+	TEMPLATE_START  = "TEMPLATE_START"  // The text before the first interpolation.
+	TEMPLATE_MIDDLE = "TEMPLATE_MIDDLE" // The text between two interpolations.
+	TEMPLATE_END    = "TEMPLATE_END"    // The text after the last interpolation.
+
+	COMMENT = "COMMENT" // A line or a block comment, only produced on demand.
+
 	// Operators.
 	ASSIGN   = "="
 	PLUS     = "+"
@@ -27,6 +40,7 @@ const (
 	BANG     = "!"
 	ASTERISK = "*"
 	SLASH    = "/"
+	PERCENT  = "%"
 
 	LT = "<"
 	GT = ">"
@@ -34,10 +48,18 @@ const (
 	EQ     = "=="
 	NOT_EQ = "!="
 
+	PLUS_ASSIGN     = "+="
+	MINUS_ASSIGN    = "-="
+	ASTERISK_ASSIGN = "*="
+	SLASH_ASSIGN    = "/="
+	PERCENT_ASSIGN  = "%="
+
 	// Delimiters.
 	COMMA     = ","
 	SEMICOLON = ";"
 	COLON     = ":"
+	DOT       = "."
+	ELLIPSIS  = "..."
 
 	LPAREN   = "("
 	RPAREN   = ")"
@@ -49,22 +71,48 @@ const (
 	// Keywords.
 	FUNCTION = "FUNCTION"
 	CONST    = "CONST"
+	LET      = "LET"
 	TRUE     = "TRUE"
 	FALSE    = "FALSE"
 	IF       = "IF"
 	ELSE     = "ELSE"
 	RETURN   = "RETURN"
+	WHILE    = "WHILE"
+	FOR      = "FOR"
+	IN       = "IN"
+	BREAK    = "BREAK"
+	CONTINUE = "CONTINUE"
+	TRY      = "TRY"
+	CATCH    = "CATCH"
+	FINALLY  = "FINALLY"
+	THROW    = "THROW"
+	IMPORT   = "IMPORT"
+	EXPORT   = "EXPORT"
+	AS       = "AS"
 )
 
 var (
 	keywords = map[string]Type{
-		"fn":     FUNCTION,
-		"const":  CONST,
-		"true":   TRUE,
-		"false":  FALSE,
-		"if":     IF,
-		"else":   ELSE,
-		"return": RETURN,
+		"fn":       FUNCTION,
+		"const":    CONST,
+		"let":      LET,
+		"true":     TRUE,
+		"false":    FALSE,
+		"if":       IF,
+		"else":     ELSE,
+		"return":   RETURN,
+		"while":    WHILE,
+		"for":      FOR,
+		"in":       IN,
+		"break":    BREAK,
+		"continue": CONTINUE,
+		"try":      TRY,
+		"catch":    CATCH,
+		"finally":  FINALLY,
+		"throw":    THROW,
+		"import":   IMPORT,
+		"export":   EXPORT,
+		"as":       AS,
 	}
 )
 
@@ -75,3 +123,20 @@ func LookupIdentifier(identifier string) Type {
 
 	return IDENT
 }
+
+// Literal returns the text of the tokens of the given type, for the operators, the delimiters
+// and the keywords, or an empty string for the other types, whose tokens have a text of their own.
+func Literal(t Type) string {
+	for literal, keyword := range keywords {
+		if keyword == t {
+			return literal
+		}
+	}
+
+	switch t {
+	case ILLEGAL, EOF, IDENT, INT, FLOAT, STRING, TEMPLATE_START, TEMPLATE_MIDDLE, TEMPLATE_END, COMMENT:
+		return ""
+	}
+
+	return string(t)
+}
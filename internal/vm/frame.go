@@ -0,0 +1,38 @@
+package vm
+
+import (
+	"github.com/axbarsan/doggo/internal/code"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/token"
+)
+
+// Frame holds the execution state of a single function call.
+type Frame struct {
+	fn *object.Function
+	// ip is the position of the instruction being executed.
+	ip int
+	// scope holds the local bindings of the call, it's nil for the main program.
+	scope *object.Scope
+	// basePointer is the stack pointer before the call, which is restored when the function returns.
+	basePointer int
+}
+
+func NewFrame(fn *object.Function, scope *object.Scope, basePointer int) *Frame {
+	f := &Frame{
+		fn:          fn,
+		ip:          -1,
+		scope:       scope,
+		basePointer: basePointer,
+	}
+
+	return f
+}
+
+func (f *Frame) Instructions() code.Instructions {
+	return f.fn.Compiled.Instructions
+}
+
+// position returns the source position of the instruction being executed.
+func (f *Frame) position() token.Position {
+	return f.fn.Compiled.SourceMap.Lookup(f.ip)
+}
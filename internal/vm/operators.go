@@ -0,0 +1,330 @@
+package vm
+
+import (
+	"math"
+
+	"github.com/axbarsan/doggo/internal/code"
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+// The operators behave exactly like they do in the evaluator, down to the error messages.
+
+var binaryOperators = map[code.Opcode]string{
+	code.OpAdd:         "+",
+	code.OpSub:         "-",
+	code.OpMul:         "*",
+	code.OpDiv:         "/",
+	code.OpMod:         "%",
+	code.OpEqual:       "==",
+	code.OpNotEqual:    "!=",
+	code.OpGreaterThan: ">",
+	code.OpLessThan:    "<",
+}
+
+func (vm *VM) executeBinaryOperation(op code.Opcode) *object.Error {
+	right := vm.pop()
+	left := vm.pop()
+
+	switch {
+	case left.Type() == object.INTEGER_OBJ && right.Type() == object.INTEGER_OBJ:
+		return vm.executeIntegerOperation(op, left, right)
+
+	case isNumber(left) && isNumber(right):
+		return vm.executeFloatOperation(op, left, right)
+
+	case left.Type() == object.STRING_OBJ && right.Type() == object.STRING_OBJ:
+		return vm.executeStringOperation(op, left, right)
+
+	case op == code.OpEqual:
+		vm.push(nativeBoolToBooleanObject(left == right))
+
+	case op == code.OpNotEqual:
+		vm.push(nativeBoolToBooleanObject(left != right))
+
+	case left.Type() != right.Type():
+		return newError("type-mismatch", "type mismatch: %s %s %s", left.Type(), binaryOperators[op], right.Type())
+
+	default:
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), binaryOperators[op], right.Type())
+	}
+
+	return nil
+}
+
+func (vm *VM) executeIntegerOperation(op code.Opcode, left, right object.Object) *object.Error {
+	leftVal := left.(*object.Integer).Value
+	rightVal := right.(*object.Integer).Value
+
+	if vm.options.Strict && object.IntegerOverflows(binaryOperators[op], leftVal, rightVal) {
+		return newError("integer-overflow", "integer overflow: %d %s %d", leftVal, binaryOperators[op], rightVal)
+	}
+
+	switch op {
+	case code.OpAdd:
+		vm.push(&object.Integer{Value: leftVal + rightVal})
+
+	case code.OpSub:
+		vm.push(&object.Integer{Value: leftVal - rightVal})
+
+	case code.OpMul:
+		vm.push(&object.Integer{Value: leftVal * rightVal})
+
+	case code.OpDiv:
+		if rightVal == 0 {
+			return newError("division-by-zero", "division by zero")
+		}
+
+		vm.push(&object.Integer{Value: leftVal / rightVal})
+
+	case code.OpMod:
+		if rightVal == 0 {
+			return newError("division-by-zero", "modulo by zero")
+		}
+
+		vm.push(&object.Integer{Value: leftVal % rightVal})
+
+	case code.OpLessThan:
+		vm.push(nativeBoolToBooleanObject(leftVal < rightVal))
+
+	case code.OpGreaterThan:
+		vm.push(nativeBoolToBooleanObject(leftVal > rightVal))
+
+	case code.OpEqual:
+		vm.push(nativeBoolToBooleanObject(leftVal == rightVal))
+
+	case code.OpNotEqual:
+		vm.push(nativeBoolToBooleanObject(leftVal != rightVal))
+
+	default:
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), binaryOperators[op], right.Type())
+	}
+
+	return nil
+}
+
+func (vm *VM) executeFloatOperation(op code.Opcode, left, right object.Object) *object.Error {
+	leftVal, _ := object.ToFloat(left)
+	rightVal, _ := object.ToFloat(right)
+
+	switch op {
+	case code.OpAdd:
+		vm.push(&object.Float{Value: leftVal + rightVal})
+
+	case code.OpSub:
+		vm.push(&object.Float{Value: leftVal - rightVal})
+
+	case code.OpMul:
+		vm.push(&object.Float{Value: leftVal * rightVal})
+
+	case code.OpDiv:
+		if rightVal == 0 {
+			return newError("division-by-zero", "division by zero")
+		}
+
+		vm.push(&object.Float{Value: leftVal / rightVal})
+
+	case code.OpMod:
+		if rightVal == 0 {
+			return newError("division-by-zero", "modulo by zero")
+		}
+
+		vm.push(&object.Float{Value: math.Mod(leftVal, rightVal)})
+
+	case code.OpLessThan:
+		vm.push(nativeBoolToBooleanObject(leftVal < rightVal))
+
+	case code.OpGreaterThan:
+		vm.push(nativeBoolToBooleanObject(leftVal > rightVal))
+
+	case code.OpEqual:
+		vm.push(nativeBoolToBooleanObject(leftVal == rightVal))
+
+	case code.OpNotEqual:
+		vm.push(nativeBoolToBooleanObject(leftVal != rightVal))
+
+	default:
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), binaryOperators[op], right.Type())
+	}
+
+	return nil
+}
+
+func isNumber(obj object.Object) bool {
+	_, ok := object.ToFloat(obj)
+
+	return ok
+}
+
+func (vm *VM) executeStringOperation(op code.Opcode, left, right object.Object) *object.Error {
+	leftVal := left.(*object.String).Value
+	rightVal := right.(*object.String).Value
+
+	switch op {
+	case code.OpAdd:
+		str := &object.String{Value: leftVal + rightVal}
+		if err := vm.options.Meter.Allocate(str); err != nil {
+			return err
+		}
+		vm.push(str)
+
+	case code.OpEqual:
+		vm.push(nativeBoolToBooleanObject(leftVal == rightVal))
+
+	case code.OpNotEqual:
+		vm.push(nativeBoolToBooleanObject(leftVal != rightVal))
+
+	default:
+		return newError("unknown-operator", "unknown operator: %s %s %s", left.Type(), binaryOperators[op], right.Type())
+	}
+
+	return nil
+}
+
+func (vm *VM) executeMinusOperator() *object.Error {
+	switch operand := vm.pop().(type) {
+	case *object.Integer:
+		if vm.options.Strict && operand.Value == math.MinInt64 {
+			return newError("integer-overflow", "integer overflow: -%d", operand.Value)
+		}
+
+		vm.push(&object.Integer{Value: -operand.Value})
+
+	case *object.Float:
+		vm.push(&object.Float{Value: -operand.Value})
+
+	default:
+		return newError("unknown-operator", "unknown operator: -%s", operand.Type())
+	}
+
+	return nil
+}
+
+func (vm *VM) executeIndexExpression(left, index object.Object) *object.Error {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		vm.executeArrayIndex(left, index)
+
+		return nil
+
+	case left.Type() == object.STRING_OBJ && index.Type() == object.INTEGER_OBJ:
+		vm.executeStringIndex(left, index)
+
+		return nil
+
+	case left.Type() == object.MAP_OBJ:
+		return vm.executeMapIndex(left, index)
+
+	case left.Type() == object.MODULE_OBJ:
+		member, err := left.(*object.Module).Member(index)
+		if err != nil {
+			return err
+		}
+		vm.push(member)
+
+		return nil
+
+	default:
+		return newError("not-indexable", "index operator not supported: %s", left.Type())
+	}
+}
+
+// executeStringIndex pushes the char at the given index, as a string.
+func (vm *VM) executeStringIndex(str, index object.Object) {
+	chars := []rune(str.(*object.String).Value)
+	idx := index.(*object.Integer).Value
+	max := int64(len(chars) - 1)
+
+	if idx < 0 || idx > max {
+		vm.push(NULL)
+
+		return
+	}
+
+	vm.push(&object.String{Value: string(chars[idx])})
+}
+
+func (vm *VM) executeArrayIndex(array, index object.Object) {
+	arrayObject := array.(*object.Array)
+	idx := index.(*object.Integer).Value
+	max := int64(len(arrayObject.Elements) - 1)
+
+	if idx < 0 || idx > max {
+		vm.push(NULL)
+
+		return
+	}
+
+	vm.push(arrayObject.Elements[idx])
+}
+
+func (vm *VM) executeMapIndex(m, index object.Object) *object.Error {
+	mapObject := m.(*object.Map)
+
+	key, ok := index.(object.Mappable)
+	if !ok {
+		return newError("unusable-key", "unusable as map key: %s", index.Type())
+	}
+
+	pair, ok := mapObject.Pairs[key.MapKey()]
+	if !ok {
+		vm.push(NULL)
+
+		return nil
+	}
+
+	vm.push(pair.Value)
+
+	return nil
+}
+
+func (vm *VM) executeSetIndexExpression(left, index, value object.Object) *object.Error {
+	switch {
+	case left.Type() == object.ARRAY_OBJ && index.Type() == object.INTEGER_OBJ:
+		elements := left.(*object.Array).Elements
+		idx := index.(*object.Integer).Value
+
+		if idx < 0 || idx >= int64(len(elements)) {
+			return newError("index-out-of-range", "index out of range: %d", idx)
+		}
+		elements[idx] = value
+
+	case left.Type() == object.MAP_OBJ:
+		key, ok := index.(object.Mappable)
+		if !ok {
+			return newError("unusable-key", "unusable as map key: %s", index.Type())
+		}
+
+		left.(*object.Map).Pairs[key.MapKey()] = object.MapPair{Key: key, Value: value}
+
+	default:
+		return newError("not-indexable", "index assignment not supported: %s", left.Type())
+	}
+
+	vm.push(value)
+
+	return nil
+}
+
+func isTruthy(obj object.Object) bool {
+	switch obj {
+	case NULL:
+		return false
+
+	case TRUE:
+		return true
+
+	case FALSE:
+		return false
+
+	default:
+		return true
+	}
+}
+
+func nativeBoolToBooleanObject(input bool) *object.Boolean {
+	if input {
+		return TRUE
+	}
+
+	return FALSE
+}
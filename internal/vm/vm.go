@@ -0,0 +1,699 @@
+package vm
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/axbarsan/doggo/internal/ast"
+	"github.com/axbarsan/doggo/internal/code"
+	"github.com/axbarsan/doggo/internal/compiler"
+	"github.com/axbarsan/doggo/internal/object"
+)
+
+// The virtual machine executes the bytecode produced by the compiler, using a stack
+// for intermediate values and a frame for every function call.
+
+const (
+	// StackSize is the initial size of the stack, it grows as needed.
+	StackSize = 2048
+	// GlobalsSize is the number of global bindings that can be addressed by the instructions.
+	GlobalsSize = 65536
+)
+
+var (
+	NULL = object.NULL
+
+	TRUE  = object.TRUE
+	FALSE = object.FALSE
+)
+
+type VM struct {
+	// globals belong to the main program. Each function refers to the ones it was compiled with.
+	globals *object.Globals
+
+	stack []object.Object
+	// sp always points to the next free slot, so the top of the stack is stack[sp-1].
+	sp int
+
+	frames []*Frame
+	// handlers holds the error handlers installed by the try statements being run, innermost last.
+	handlers []handler
+
+	lastPoppedStackElem object.Object
+
+	options *object.Options
+}
+
+// handler is where the execution carries on when an error is raised.
+type handler struct {
+	// frame is the index of the frame the handler belongs to.
+	frame int
+	// sp is the stack pointer at the time the handler was installed.
+	sp int
+	// ip is the offset of the instruction the handler starts at.
+	ip int
+}
+
+func New(bytecode *compiler.Bytecode) *VM {
+	mainFn := &object.Function{
+		Compiled: &object.CompiledFunction{
+			Instructions: bytecode.Instructions,
+			SourceMap:    bytecode.SourceMap,
+			Declarations: bytecode.Declarations,
+		},
+	}
+
+	mainFn.Globals = &object.Globals{
+		Constants: bytecode.Constants,
+		Values:    make([]object.Object, GlobalsSize),
+		Names:     bytecode.GlobalNames,
+	}
+
+	vm := &VM{
+		globals: mainFn.Globals,
+		stack:   make([]object.Object, StackSize),
+		sp:      0,
+		frames:  []*Frame{NewFrame(mainFn, nil, 0)},
+		options: &object.Options{},
+	}
+
+	return vm
+}
+
+// NewWithGlobalsState creates a virtual machine that shares the global bindings of a previous run,
+// so a REPL session can refer to them. Only the values of the bindings, and which ones are constants, are shared.
+func NewWithGlobalsState(bytecode *compiler.Bytecode, globals *object.Globals) *VM {
+	if globals.Decls == nil {
+		globals.Decls = object.Decls{}
+	}
+
+	vm := New(bytecode)
+	vm.globals.Values = globals.Values
+	vm.globals.Decls = globals.Decls
+
+	return vm
+}
+
+// SetOptions configures how the program runs.
+func (vm *VM) SetOptions(options *object.Options) {
+	vm.options = options
+}
+
+// Run executes the program, returning its result, or the error that stopped it.
+func (vm *VM) Run() object.Object {
+	for vm.currentFrame().ip < len(vm.currentFrame().Instructions())-1 {
+		vm.currentFrame().ip++
+
+		frame := vm.currentFrame()
+		ip := frame.ip
+		ins := frame.Instructions()
+		op := code.Opcode(ins[ip])
+
+		err := vm.options.Meter.Step()
+		if err != nil {
+			vm.raise(err)
+			vm.unwind()
+
+			return err
+		}
+
+		switch op {
+		case code.OpConstant:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			vm.push(frame.fn.Globals.Constants[constIndex])
+
+		case code.OpPop:
+			vm.lastPoppedStackElem = vm.pop()
+
+		case code.OpAdd, code.OpSub, code.OpMul, code.OpDiv, code.OpMod,
+			code.OpEqual, code.OpNotEqual, code.OpGreaterThan, code.OpLessThan:
+			err = vm.executeBinaryOperation(op)
+
+		case code.OpTrue:
+			vm.push(TRUE)
+
+		case code.OpFalse:
+			vm.push(FALSE)
+
+		case code.OpNull:
+			vm.push(NULL)
+
+		case code.OpBang:
+			vm.push(nativeBoolToBooleanObject(!isTruthy(vm.pop())))
+
+		case code.OpMinus:
+			err = vm.executeMinusOperator()
+
+		case code.OpJump:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip = pos - 1
+
+		case code.OpJumpNotTruthy:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			condition := vm.pop()
+			if !isTruthy(condition) {
+				frame.ip = pos - 1
+			}
+
+		case code.OpJumpIfSet:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			localIndex := code.ReadUint16(ins[ip+3:])
+			frame.ip += 4
+
+			if frame.scope.Locals[localIndex] != nil {
+				frame.ip = pos - 1
+			}
+
+		case code.OpSetGlobal:
+			globalIndex := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			globals := frame.fn.Globals
+			err = assign(globals.Values, globals.Decls, globalIndex, globals.Names[globalIndex], vm.pop())
+
+		case code.OpDefineGlobal:
+			globalIndex := int(code.ReadUint16(ins[ip+1:]))
+			constant := code.ReadUint8(ins[ip+3:]) == 1
+			frame.ip += 3
+
+			globals := frame.fn.Globals
+			if err = declare(&globals.Decls, globalIndex, globals.Names[globalIndex], declOf(frame, ip, constant)); err == nil {
+				globals.Values[globalIndex] = vm.pop()
+			}
+
+		case code.OpGetGlobal:
+			globalIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			val := frame.fn.Globals.Values[globalIndex]
+			if val == nil {
+				err = newError("unknown-identifier", "identifier not found: %s", frame.fn.Globals.Names[globalIndex])
+				break
+			}
+			vm.push(val)
+
+		case code.OpSetLocal:
+			localIndex := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			scope := frame.scope
+			err = assign(scope.Locals, scope.Decls, localIndex, scope.Names[localIndex], vm.pop())
+
+		case code.OpDefineLocal:
+			localIndex := int(code.ReadUint16(ins[ip+1:]))
+			constant := code.ReadUint8(ins[ip+3:]) == 1
+			frame.ip += 3
+
+			scope := frame.scope
+			if err = declare(&scope.Decls, localIndex, scope.Names[localIndex], declOf(frame, ip, constant)); err == nil {
+				scope.Locals[localIndex] = vm.pop()
+			}
+
+		case code.OpGetLocal:
+			localIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			err = vm.pushLocal(frame.scope, int(localIndex))
+
+		case code.OpGetOuter:
+			depth := code.ReadUint8(ins[ip+1:])
+			localIndex := code.ReadUint16(ins[ip+2:])
+			frame.ip += 3
+
+			scope := frame.scope
+			for i := uint8(0); i < depth; i++ {
+				scope = scope.Outer
+			}
+			err = vm.pushLocal(scope, int(localIndex))
+
+		case code.OpSetOuter:
+			depth := code.ReadUint8(ins[ip+1:])
+			localIndex := code.ReadUint16(ins[ip+2:])
+			frame.ip += 3
+
+			scope := frame.scope
+			for i := uint8(0); i < depth; i++ {
+				scope = scope.Outer
+			}
+			err = assign(scope.Locals, scope.Decls, int(localIndex), scope.Names[localIndex], vm.pop())
+
+		case code.OpGetBuiltin:
+			builtinIndex := code.ReadUint8(ins[ip+1:])
+			frame.ip++
+
+			vm.push(object.Builtins[builtinIndex].Builtin)
+
+		case code.OpArray:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			array := vm.buildArray(vm.sp-numElements, vm.sp)
+			if err = vm.options.Meter.Allocate(array); err != nil {
+				break
+			}
+			vm.sp = vm.sp - numElements
+			vm.push(array)
+
+		case code.OpConcat:
+			numParts := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			str := vm.buildString(vm.sp-numParts, vm.sp)
+			if err = vm.options.Meter.Allocate(str); err != nil {
+				break
+			}
+			vm.sp = vm.sp - numParts
+			vm.push(str)
+
+		case code.OpMap:
+			numElements := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			var m object.Object
+			m, err = vm.buildMap(vm.sp-numElements, vm.sp)
+			if err != nil {
+				break
+			}
+			if err = vm.options.Meter.Allocate(m); err != nil {
+				break
+			}
+			vm.sp = vm.sp - numElements
+			vm.push(m)
+
+		case code.OpIndex:
+			index := vm.pop()
+			left := vm.pop()
+
+			err = vm.executeIndexExpression(left, index)
+
+		case code.OpIter:
+			collection := vm.pop()
+
+			it, ok := object.NewIterator(collection)
+			if !ok {
+				err = newError("not-iterable", "iteration not supported: %s", collection.Type())
+				break
+			}
+			vm.push(it)
+
+		case code.OpIterNext:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			numVars := code.ReadUint8(ins[ip+3:])
+			frame.ip += 3
+
+			if !vm.pushNextMembers(vm.pop().(*object.Iterator), numVars) {
+				frame.ip = pos - 1
+			}
+
+		case code.OpSetIndex:
+			value := vm.pop()
+			index := vm.pop()
+			left := vm.pop()
+
+			err = vm.executeSetIndexExpression(left, index, value)
+
+		case code.OpDup2:
+			vm.push(vm.stack[vm.sp-2])
+			vm.push(vm.stack[vm.sp-2])
+
+		case code.OpTry:
+			pos := int(code.ReadUint16(ins[ip+1:]))
+			frame.ip += 2
+
+			vm.handlers = append(vm.handlers, handler{frame: len(vm.frames) - 1, sp: vm.sp, ip: pos})
+
+		case code.OpEndTry:
+			vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+		case code.OpCatch:
+			caught := vm.pop().(*object.Error)
+			vm.push(caught.ToMap())
+
+		case code.OpThrow:
+			thrown := vm.pop()
+			if raised, ok := thrown.(*object.Error); ok {
+				err = raised
+			} else {
+				err = object.NewThrownError(thrown)
+			}
+
+		case code.OpImport:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			var module *object.Module
+			module, err = vm.importModule(frame.fn.Globals.Constants[constIndex].(*object.String).Value)
+			if err != nil {
+				break
+			}
+			vm.push(module)
+
+		case code.OpClosure:
+			constIndex := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			compiled := frame.fn.Globals.Constants[constIndex].(*object.CompiledFunction)
+			closure := &object.Function{
+				Name:       compiled.Name,
+				Parameters: compiled.Parameters,
+				Defaults:   compiled.Defaults,
+				Rest:       compiled.Rest,
+				Body:       compiled.Body,
+				Compiled:   compiled,
+				Scope:      frame.scope,
+				Globals:    frame.fn.Globals,
+			}
+			if err = vm.options.Meter.Allocate(closure); err != nil {
+				break
+			}
+			vm.push(closure)
+
+		case code.OpCall:
+			numArgs := code.ReadUint16(ins[ip+1:])
+			frame.ip += 2
+
+			err = vm.executeCall(int(numArgs))
+
+		case code.OpReturnValue:
+			returnValue := vm.pop()
+
+			// Returning from the main program stops it.
+			if len(vm.frames) == 1 {
+				return returnValue
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer
+			vm.push(returnValue)
+
+		case code.OpReturn:
+			// The main program ends with it when its last statement doesn't produce a value.
+			if len(vm.frames) == 1 {
+				return nil
+			}
+
+			frame := vm.popFrame()
+			vm.sp = frame.basePointer
+			vm.push(NULL)
+
+		default:
+			def, _ := code.Lookup(byte(op))
+			err = newError("runtime-error", "unhandled instruction %s", def.Name)
+		}
+
+		if err != nil {
+			vm.raise(err)
+
+			if !vm.handle(err) {
+				vm.unwind()
+
+				return err
+			}
+		}
+	}
+
+	return vm.lastPoppedStackElem
+}
+
+func newError(code, format string, a ...interface{}) *object.Error {
+	return &object.Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, a...),
+	}
+}
+
+// raise attaches the position of the failing instruction and the stack trace of the function calls
+// to the error. An error raised again after a finally block keeps the ones it already has.
+func (vm *VM) raise(err *object.Error) {
+	if err.Pos.IsValid() {
+		return
+	}
+
+	err.Pos = vm.currentFrame().position()
+
+	for i := len(vm.frames) - 1; i > 0; i-- {
+		err.Stack = append(err.Stack, object.Frame{
+			Function: vm.frames[i].fn.DisplayName(),
+			Pos:      vm.frames[i-1].position(),
+		})
+	}
+}
+
+// handle hands the error over to the innermost error handler, unwinding the frames and the stack
+// up to it. It reports whether there was a handler to take the error.
+// Once the run is stopped by its meter, no handler can take the errors anymore.
+func (vm *VM) handle(err *object.Error) bool {
+	if len(vm.handlers) == 0 || vm.options.Meter.Tripped() {
+		return false
+	}
+
+	h := vm.handlers[len(vm.handlers)-1]
+	vm.handlers = vm.handlers[:len(vm.handlers)-1]
+
+	for len(vm.frames) > h.frame+1 {
+		vm.popFrame()
+	}
+	vm.sp = h.sp
+	vm.currentFrame().ip = h.ip - 1
+	vm.push(err)
+
+	return true
+}
+
+// unwind leaves all the function calls, when an error stops the program.
+func (vm *VM) unwind() {
+	for len(vm.frames) > 1 {
+		vm.popFrame()
+	}
+}
+
+// importModule loads the module at the path, resolving it from the file of the running code.
+func (vm *VM) importModule(path string) (*object.Module, *object.Error) {
+	if vm.options.Modules == nil {
+		return nil, newError("misplaced-statement", "cannot import modules here")
+	}
+
+	return vm.options.Modules.Load(path, vm.currentFrame().position())
+}
+
+func (vm *VM) currentFrame() *Frame {
+	return vm.frames[len(vm.frames)-1]
+}
+
+func (vm *VM) pushFrame(f *Frame) {
+	vm.frames = append(vm.frames, f)
+}
+
+func (vm *VM) popFrame() *Frame {
+	f := vm.frames[len(vm.frames)-1]
+	vm.frames = vm.frames[:len(vm.frames)-1]
+	vm.options.Meter.Leave()
+
+	return f
+}
+
+func (vm *VM) push(o object.Object) {
+	if vm.sp >= len(vm.stack) {
+		vm.stack = append(vm.stack, make([]object.Object, len(vm.stack))...)
+	}
+
+	vm.stack[vm.sp] = o
+	vm.sp++
+}
+
+func (vm *VM) pop() object.Object {
+	o := vm.stack[vm.sp-1]
+	vm.sp--
+
+	return o
+}
+
+// declOf returns the statement declaring the binding by the instruction at the offset, if it's a constant.
+func declOf(frame *Frame, ip int, constant bool) ast.Node {
+	if !constant {
+		return nil
+	}
+
+	return frame.fn.Compiled.Declarations[ip]
+}
+
+// declare records whether the binding at the index is declared as a constant. Like the evaluator, a constant
+// can only be declared again by the statement that declared it in the first place, e.g. on the next iteration of a loop,
+// or when the same program is compiled and run again.
+func declare(decls *object.Decls, index int, name string, decl ast.Node) *object.Error {
+	if prev, ok := (*decls)[index]; ok && prev != decl {
+		return newError("constant-redeclared", "cannot redeclare constant: %s", name)
+	}
+
+	if decl == nil {
+		delete(*decls, index)
+
+		return nil
+	}
+
+	if *decls == nil {
+		*decls = object.Decls{}
+	}
+	(*decls)[index] = decl
+
+	return nil
+}
+
+// assign sets the binding at the index, which must have been declared and must not be a constant.
+func assign(values []object.Object, decls object.Decls, index int, name string, val object.Object) *object.Error {
+	if values[index] == nil {
+		return newError("unknown-identifier", "identifier not found: %s", name)
+	}
+
+	if _, ok := decls[index]; ok {
+		return newError("constant-assigned", "cannot assign to constant: %s", name)
+	}
+
+	values[index] = val
+
+	return nil
+}
+
+func (vm *VM) pushLocal(scope *object.Scope, index int) *object.Error {
+	val := scope.Locals[index]
+	if val == nil {
+		return newError("unknown-identifier", "identifier not found: %s", scope.Names[index])
+	}
+
+	vm.push(val)
+
+	return nil
+}
+
+// pushNextMembers pushes what the variables of a loop are bound to, in order.
+// It reports false once the iterator is done.
+func (vm *VM) pushNextMembers(it *object.Iterator, numVars uint8) bool {
+	if numVars == 1 {
+		member, ok := it.NextMember()
+		if ok {
+			vm.push(member)
+		}
+
+		return ok
+	}
+
+	key, value, ok := it.Next()
+	if ok {
+		vm.push(key)
+		vm.push(value)
+	}
+
+	return ok
+}
+
+func (vm *VM) executeCall(numArgs int) *object.Error {
+	callee := vm.stack[vm.sp-1-numArgs]
+
+	switch callee := callee.(type) {
+	case *object.Function:
+		if err := callee.CheckArity(numArgs); err != nil {
+			return err
+		}
+
+		return vm.callFunction(callee, numArgs)
+
+	case *object.Builtin:
+		return vm.callBuiltin(callee, numArgs)
+
+	default:
+		return newError("not-callable", "not a function: %s", callee.Type())
+	}
+}
+
+func (vm *VM) callFunction(fn *object.Function, numArgs int) *object.Error {
+	if err := vm.options.Meter.Enter(); err != nil {
+		return err
+	}
+
+	scope := &object.Scope{
+		Locals: make([]object.Object, len(fn.Compiled.LocalNames)),
+		Names:  fn.Compiled.LocalNames,
+		Outer:  fn.Scope,
+	}
+
+	// The parameters without an argument are left unset, so their default values get evaluated.
+	args := vm.stack[vm.sp-numArgs : vm.sp]
+	numParams := len(fn.Parameters)
+	copy(scope.Locals[:numParams], args)
+
+	if fn.Rest != nil {
+		rest := []object.Object{}
+		if numArgs > numParams {
+			rest = append(rest, args[numParams:]...)
+		}
+		scope.Locals[numParams] = &object.Array{Elements: rest}
+	}
+
+	// The function and its arguments are not needed on the stack anymore.
+	vm.sp = vm.sp - numArgs - 1
+
+	vm.pushFrame(NewFrame(fn, scope, vm.sp))
+
+	return nil
+}
+
+func (vm *VM) callBuiltin(builtin *object.Builtin, numArgs int) *object.Error {
+	args := make([]object.Object, numArgs)
+	copy(args, vm.stack[vm.sp-numArgs:vm.sp])
+
+	result := builtin.Call(vm.options, args...)
+	if err, ok := result.(*object.Error); ok {
+		return err
+	}
+
+	if err := vm.options.Meter.Allocate(result); err != nil {
+		return err
+	}
+
+	vm.sp = vm.sp - numArgs - 1
+
+	if result == nil {
+		result = NULL
+	}
+	vm.push(result)
+
+	return nil
+}
+
+func (vm *VM) buildArray(startIndex, endIndex int) object.Object {
+	elements := make([]object.Object, endIndex-startIndex)
+	copy(elements, vm.stack[startIndex:endIndex])
+
+	return &object.Array{Elements: elements}
+}
+
+func (vm *VM) buildString(startIndex, endIndex int) object.Object {
+	var out strings.Builder
+	for _, part := range vm.stack[startIndex:endIndex] {
+		out.WriteString(part.Inspect())
+	}
+
+	return &object.String{Value: out.String()}
+}
+
+func (vm *VM) buildMap(startIndex, endIndex int) (object.Object, *object.Error) {
+	pairs := make(map[object.MapKey]object.MapPair)
+
+	for i := startIndex; i < endIndex; i += 2 {
+		key := vm.stack[i]
+		value := vm.stack[i+1]
+
+		mapKey, ok := key.(object.Mappable)
+		if !ok {
+			return nil, newError("unusable-key", "unusable as map key: %s", key.Type())
+		}
+
+		pairs[mapKey.MapKey()] = object.MapPair{Key: mapKey, Value: value}
+	}
+
+	return &object.Map{Pairs: pairs}, nil
+}
@@ -0,0 +1,78 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/axbarsan/doggo/internal/compiler"
+	"github.com/axbarsan/doggo/internal/lexer"
+	"github.com/axbarsan/doggo/internal/object"
+	"github.com/axbarsan/doggo/internal/parser"
+)
+
+// The language semantics are covered by the evaluator suite, which also runs on the virtual machine.
+// These tests cover what's specific to it.
+
+func compile(t *testing.T, c *compiler.Compiler, input string) *compiler.Bytecode {
+	l := lexer.New(input)
+	p := parser.New(l)
+	program := p.ParseProgram()
+
+	if err := c.Compile(program); err != nil {
+		t.Fatalf("compiler error: %s", err)
+	}
+
+	return c.Bytecode()
+}
+
+func TestDeepRecursionGrowsStack(t *testing.T) {
+	input := `
+const sum = fn(n) {
+    if (n == 0) {
+        return 0;
+    }
+
+    n + sum(n - 1)
+};
+sum(10000);`
+
+	machine := New(compile(t, compiler.New(), input))
+	result := machine.Run()
+
+	integer, ok := result.(*object.Integer)
+	if !ok {
+		t.Fatalf("object is not Integer. got=%T (%+v)", result, result)
+	}
+
+	if integer.Value != 50005000 {
+		t.Errorf("object has wrong value. got=%d, want=%d", integer.Value, 50005000)
+	}
+}
+
+func TestGlobalsState(t *testing.T) {
+	symbolTable := compiler.NewSymbolTable()
+	constants := []object.Object{}
+	globals := &object.Globals{Values: make([]object.Object, GlobalsSize)}
+
+	inputs := []string{
+		"const add = fn(x) { x + later };",
+		"const later = 10;",
+		"add(5)",
+	}
+
+	var result object.Object
+	for _, input := range inputs {
+		bytecode := compile(t, compiler.NewWithState(symbolTable, constants), input)
+		constants = bytecode.Constants
+
+		result = NewWithGlobalsState(bytecode, globals).Run()
+	}
+
+	integer, ok := result.(*object.Integer)
+	if !ok {
+		t.Fatalf("object is not Integer. got=%T (%+v)", result, result)
+	}
+
+	if integer.Value != 15 {
+		t.Errorf("object has wrong value. got=%d, want=%d", integer.Value, 15)
+	}
+}